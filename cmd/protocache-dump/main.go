@@ -0,0 +1,379 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command protocache-dump inspects and converts protocache snapshot files
+// without starting a server.
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/patrostkowski/protocache/internal/config"
+	"github.com/patrostkowski/protocache/internal/dump"
+	"github.com/patrostkowski/protocache/internal/glob"
+	"github.com/patrostkowski/protocache/internal/keyring"
+	"github.com/patrostkowski/protocache/internal/logger"
+	"github.com/patrostkowski/protocache/internal/snapshot"
+	"github.com/patrostkowski/protocache/internal/store"
+	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+// keyFiles collects repeated -key flags.
+type keyFiles []string
+
+func (k *keyFiles) String() string { return strings.Join(*k, ",") }
+
+func (k *keyFiles) Set(path string) error {
+	*k = append(*k, path)
+	return nil
+}
+
+type options struct {
+	keys        *keyring.Keyring
+	compression v1alpha.SnapshotCompression
+}
+
+func main() {
+	log.SetFlags(0)
+	// Values and NDJSON go to stdout, so keep config logs off it.
+	logger.SetOutput(os.Stderr)
+
+	var keys keyFiles
+	flag.Var(&keys, "key", "Encryption key file, hex or base64 (repeatable; the first one encrypts)")
+	configPath := flag.String("config", "", "Server config file to take the encryption keys and compression from")
+	compression := flag.String("compression", "", "Compression for import: none, gzip or flate")
+	flag.Usage = usage
+	flag.Parse()
+	args := flag.Args()
+
+	if len(args) < 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	opts, err := loadOptions(*configPath, keys, v1alpha.SnapshotCompression(*compression))
+	checkErr(err)
+
+	runCommand(opts, args[0], args[1:])
+}
+
+func loadOptions(configPath string, keyFiles []string, compression v1alpha.SnapshotCompression) (options, error) {
+	opts := options{compression: config.SnapshotCompression}
+	if configPath != "" {
+		cfg, err := config.LoadConfig(configPath)
+		if err != nil {
+			return opts, err
+		}
+		if opts.keys, err = cfg.CreateKeyring(); err != nil {
+			return opts, err
+		}
+		opts.compression = cfg.GetSnapshotCompression()
+	}
+
+	if len(keyFiles) > 0 {
+		var raw [][]byte
+		for _, path := range keyFiles {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return opts, err
+			}
+			key, err := keyring.ParseKey(string(data))
+			if err != nil {
+				return opts, fmt.Errorf("key file %s: %w", path, err)
+			}
+			raw = append(raw, key)
+		}
+		keys, err := keyring.New(raw[0], raw[1:]...)
+		if err != nil {
+			return opts, err
+		}
+		opts.keys = keys
+	}
+
+	if compression != "" {
+		opts.compression = compression
+	}
+	return opts, nil
+}
+
+func runCommand(opts options, cmd string, params []string) {
+	switch cmd {
+	case "info":
+		runInfo(opts, params)
+	case "keys":
+		runKeys(opts, params)
+	case "get":
+		runGet(opts, params)
+	case "show":
+		runShow(opts, params)
+	case "stats":
+		runStats(opts, params)
+	case "diff":
+		runDiff(opts, params)
+	case "export":
+		runExport(opts, params)
+	case "import":
+		runImport(opts, params)
+	case "help":
+		usage()
+	default:
+		fmt.Fprintln(os.Stderr, "Unknown command:", cmd)
+		usage()
+		os.Exit(2)
+	}
+}
+
+func readDump(opts options, path string) ([]store.Record, snapshot.Info) {
+	records, info, err := snapshot.ReadFile(path, opts.keys)
+	if errors.Is(err, io.EOF) {
+		log.Fatalf("error: %s is empty", path)
+	}
+	checkErr(err)
+	slices.SortFunc(records, func(a, b store.Record) int { return strings.Compare(a.Key, b.Key) })
+	return records, info
+}
+
+func findRecord(records []store.Record, key string) (store.Record, bool) {
+	i, ok := slices.BinarySearchFunc(records, key, func(r store.Record, key string) int {
+		return strings.Compare(r.Key, key)
+	})
+	if !ok {
+		return store.Record{}, false
+	}
+	return records[i], true
+}
+
+func runInfo(opts options, params []string) {
+	if len(params) != 1 {
+		usageError("info <dump>")
+	}
+	records, info := readDump(opts, params[0])
+	fmt.Printf("format:    %s\n", info.Format)
+	if info.Encrypted {
+		fmt.Printf("encrypted: yes (key %08x)\n", info.KeyID)
+	} else {
+		fmt.Println("encrypted: no")
+	}
+	fmt.Printf("keys:      %d\n", len(records))
+}
+
+func runKeys(opts options, params []string) {
+	if len(params) < 1 || len(params) > 2 {
+		usageError("keys <dump> [pattern]")
+	}
+	records, _ := readDump(opts, params[0])
+	for _, r := range records {
+		if len(params) == 2 && !glob.Match(params[1], r.Key) {
+			continue
+		}
+		fmt.Println(r.Key)
+	}
+}
+
+// runGet writes the raw value to stdout, so it can be piped.
+func runGet(opts options, params []string) {
+	if len(params) != 2 {
+		usageError("get <dump> <key>")
+	}
+	records, _ := readDump(opts, params[0])
+	r, ok := findRecord(records, params[1])
+	if !ok {
+		log.Fatalf("error: key %q not found", params[1])
+	}
+	_, err := os.Stdout.Write(r.Value)
+	checkErr(err)
+}
+
+func runShow(opts options, params []string) {
+	if len(params) < 2 {
+		usageError("show <dump> <key|pattern>...")
+	}
+	records, _ := readDump(opts, params[0])
+	for _, r := range records {
+		if slices.ContainsFunc(params[1:], func(p string) bool { return glob.Match(p, r.Key) }) {
+			printRecord(r)
+		}
+	}
+}
+
+func printRecord(r store.Record) {
+	fmt.Printf("key:          %s\n", r.Key)
+	fmt.Printf("size:         %d bytes\n", len(r.Value))
+	fmt.Printf("version:      %d\n", r.Version)
+	if r.IdleTimeout > 0 {
+		fmt.Printf("idle_timeout: %s\n", r.IdleTimeout)
+	}
+	if !r.StaleAt.IsZero() {
+		fmt.Printf("stale_at:     %s\n", r.StaleAt.Format(time.RFC3339))
+	}
+	if !r.ExpiresAt.IsZero() {
+		fmt.Printf("expires_at:   %s\n", r.ExpiresAt.Format(time.RFC3339))
+	}
+	fmt.Printf("value:        %s\n\n", formatValue(r.Value))
+}
+
+func formatValue(value []byte) string {
+	if utf8.Valid(value) {
+		return string(value)
+	}
+	return "(binary) " + base64.StdEncoding.EncodeToString(value)
+}
+
+func runStats(opts options, params []string) {
+	if len(params) != 1 {
+		usageError("stats <dump>")
+	}
+	records, _ := readDump(opts, params[0])
+	st := dump.ComputeStats(records, time.Now())
+
+	fmt.Printf("keys:              %d\n", st.Keys)
+	fmt.Printf("key bytes:         %d\n", st.KeyBytes)
+	fmt.Printf("value bytes:       %d\n", st.ValueBytes)
+	fmt.Printf("largest value:     %d\n", st.MaxValue)
+	fmt.Printf("with ttl:          %d\n", st.WithTTL)
+	fmt.Printf("with idle timeout: %d\n", st.WithIdleTimeout)
+	fmt.Printf("expired:           %d\n", st.Expired)
+	fmt.Println("value sizes:")
+	for _, b := range st.Histogram {
+		label := "> " + formatSize(st.Histogram[len(st.Histogram)-2].Le)
+		if b.Le != math.MaxInt64 {
+			label = "<= " + formatSize(b.Le)
+		}
+		fmt.Printf("  %-10s %d\n", label, b.Count)
+	}
+}
+
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dMiB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%dKiB", n>>10)
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
+
+// runDiff prints the keys that differ between two dumps and, like diff,
+// exits with status 1 if there are any.
+func runDiff(opts options, params []string) {
+	if len(params) != 2 {
+		usageError("diff <old dump> <new dump>")
+	}
+	from, _ := readDump(opts, params[0])
+	to, _ := readDump(opts, params[1])
+
+	changes := dump.Diff(from, to)
+	for _, c := range changes {
+		switch c.Type {
+		case dump.Added:
+			fmt.Printf("+ %s (%d bytes)\n", c.Key, len(c.New.Value))
+		case dump.Removed:
+			fmt.Printf("- %s (%d bytes)\n", c.Key, len(c.Old.Value))
+		case dump.Changed:
+			fmt.Printf("~ %s (%d -> %d bytes)\n", c.Key, len(c.Old.Value), len(c.New.Value))
+		}
+	}
+	if len(changes) > 0 {
+		os.Exit(1)
+	}
+}
+
+func runExport(opts options, params []string) {
+	if len(params) < 1 || len(params) > 2 {
+		usageError("export <dump> [out.ndjson]")
+	}
+	records, _ := readDump(opts, params[0])
+
+	out := os.Stdout
+	if len(params) == 2 {
+		f, err := os.OpenFile(params[1], os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		checkErr(err)
+		out = f
+	}
+	checkErr(dump.WriteNDJSON(out, records))
+	if out != os.Stdout {
+		checkErr(out.Close())
+		fmt.Fprintf(os.Stderr, "%d keys exported\n", len(records))
+	}
+}
+
+// runImport writes a snapshot from NDJSON. The snapshot is encrypted
+// with the first key, if any.
+func runImport(opts options, params []string) {
+	if len(params) != 2 {
+		usageError("import <in.ndjson|-> <dump>")
+	}
+	in := os.Stdin
+	if params[0] != "-" {
+		f, err := os.Open(params[0])
+		checkErr(err)
+		defer f.Close()
+		in = f
+	}
+	records, err := dump.ReadNDJSON(in)
+	checkErr(err)
+
+	err = snapshot.WriteFile(params[1], slices.Values(records), snapshot.Options{
+		Compression: opts.compression,
+		Keys:        opts.keys,
+	})
+	checkErr(err)
+	fmt.Fprintf(os.Stderr, "%d keys imported\n", len(records))
+}
+
+func usageError(synopsis string) {
+	fmt.Fprintln(os.Stderr, "Usage: protocache-dump [flags]", synopsis)
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `Usage:
+  protocache-dump [-key file]... [-config config.yaml] [-compression gzip] <command> [args]
+
+Flags:
+  -key          Encryption key file, hex or base64. Repeat for retired keys;
+                the first one encrypts imported dumps
+  -config       Server config file to take the encryption keys and
+                snapshot compression from
+  -compression  Compression for imported dumps: none, gzip or flate
+
+Commands:
+  info <dump>                 Show the format, encryption and key count
+  keys <dump> [pattern]       List keys, optionally matching a glob pattern
+  get <dump> <key>            Write the raw value of a key to stdout
+  show <dump> <key|pat>...    Show values and metadata of matching keys
+  stats <dump>                Count keys and bytes, with a value size histogram
+  diff <old> <new>            List added (+), removed (-) and changed (~) keys
+  export <dump> [out]         Convert a dump to NDJSON
+  import <in|-> <dump>        Write a dump from NDJSON
+  help                        Show this help message`)
+}
+
+func checkErr(err error) {
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrostkowski/protocache/internal/snapshot"
+	"github.com/patrostkowski/protocache/internal/store"
+	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+// TestMain runs the command itself when re-executed by runDump.
+func TestMain(m *testing.M) {
+	if os.Getenv("PROTOCACHE_DUMP_RUN_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runDump runs protocache-dump with args and returns its stdout and
+// stderr.
+func runDump(t *testing.T, args ...string) (string, string) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "PROTOCACHE_DUMP_RUN_MAIN=1")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	require.NoError(t, cmd.Run(), stderr.String())
+	return stdout.String(), stderr.String()
+}
+
+func TestConfigLogsStayOffStdout(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(cfgPath, []byte("store:\n  snapshot_compression: none\n"), 0o600))
+
+	dumpPath := filepath.Join(dir, "dump.snap")
+	records := []store.Record{{Key: "k", Value: []byte("raw value")}}
+	err := snapshot.WriteFile(dumpPath, slices.Values(records), snapshot.Options{Compression: v1alpha.SnapshotCompressionNone})
+	require.NoError(t, err)
+
+	stdout, stderr := runDump(t, "-config", cfgPath, "get", dumpPath, "k")
+	assert.Equal(t, "raw value", stdout)
+	assert.Contains(t, stderr, "Configuration loaded")
+
+	stdout, _ = runDump(t, "-config", cfgPath, "export", dumpPath)
+	assert.Equal(t, 1, bytes.Count([]byte(stdout), []byte("\n")), stdout)
+	assert.Contains(t, stdout, `"key":"k"`)
+}
@@ -19,10 +19,18 @@ import (
 	"encoding/base64"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 	"unicode/utf8"
 
+	"github.com/patrostkowski/protocache/internal/glob"
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
 	"github.com/patrostkowski/protocache/pkg/client"
 )
 
@@ -33,12 +41,23 @@ func main() {
 	checkErr(err)
 	defer c.Close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	ctx, cancel := commandContext(cmd, cfg.Timeout)
 	defer cancel()
 
 	runCommand(ctx, c, cmd, params)
 }
 
+// commandContext bounds unary commands by timeout. Streaming and admin
+// commands run until done or interrupted.
+func commandContext(cmd string, timeout time.Duration) (context.Context, context.CancelFunc) {
+	switch cmd {
+	case "watch", "subscribe", "snapshot", "backup", "restore":
+		return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	default:
+		return context.WithTimeout(context.Background(), timeout)
+	}
+}
+
 func parseFlags() (client.Config, string, []string) {
 	host := flag.String("host", "localhost", "gRPC server host")
 	port := flag.Int("port", 50051, "gRPC server port")
@@ -73,12 +92,40 @@ func runCommand(ctx context.Context, c *client.Client, cmd string, params []stri
 		runGet(ctx, c, params)
 	case "del":
 		runDelete(ctx, c, params)
+	case "touch":
+		runTouch(ctx, c, params)
 	case "clear":
 		runClear(ctx, c)
 	case "list":
 		runList(ctx, c)
 	case "stats":
 		runStats(ctx, c)
+	case "watch":
+		runWatch(ctx, c, params)
+	case "incr":
+		runIncr(ctx, c, params)
+	case "delmatch":
+		runDeleteMatching(ctx, c, params)
+	case "mget":
+		runMGet(ctx, c, params)
+	case "mset":
+		runMSet(ctx, c, params)
+	case "mdel":
+		runMDelete(ctx, c, params)
+	case "publish":
+		runPublish(ctx, c, params)
+	case "subscribe":
+		runSubscribe(ctx, c, params)
+	case "snapshot":
+		runSnapshot(ctx, c)
+	case "lastsnapshot":
+		runLastSnapshot(ctx, c)
+	case "backup":
+		runBackup(ctx, c)
+	case "restore":
+		runRestore(ctx, c, params)
+	case "snapshots":
+		runSnapshots(ctx, c, params)
 	case "help":
 		usage()
 	default:
@@ -106,13 +153,96 @@ func runGet(ctx context.Context, c *client.Client, params []string) {
 	checkErr(err)
 	if !res.Found {
 		fmt.Println("(nil)")
-	} else if utf8.Valid(res.Value) {
-		fmt.Printf("%s\n", res.Value)
 	} else {
-		fmt.Printf("(binary) %s\n", base64.StdEncoding.EncodeToString(res.Value))
+		fmt.Println(formatValue(res.Value))
+	}
+}
+
+func runIncr(ctx context.Context, c *client.Client, params []string) {
+	if len(params) < 1 || len(params) > 2 {
+		fmt.Println("Usage: incr <key> [delta]")
+		return
+	}
+	delta := int64(1)
+	if len(params) == 2 {
+		var err error
+		delta, err = strconv.ParseInt(params[1], 10, 64)
+		checkErr(err)
+	}
+	n, err := c.Incr(ctx, params[0], delta)
+	checkErr(err)
+	fmt.Println(n)
+}
+
+func runDeleteMatching(ctx context.Context, c *client.Client, params []string) {
+	dryRun := len(params) == 2 && params[0] == "-n"
+	if dryRun {
+		params = params[1:]
+	}
+	if len(params) != 1 {
+		fmt.Println("Usage: delmatch [-n] <pattern>")
+		return
+	}
+	count, err := c.DeleteMatching(ctx, params[0], dryRun)
+	checkErr(err)
+	if dryRun {
+		fmt.Printf("%d keys would be deleted\n", count)
+	} else {
+		fmt.Printf("%d keys deleted\n", count)
+	}
+}
+
+func runMGet(ctx context.Context, c *client.Client, params []string) {
+	if len(params) == 0 {
+		fmt.Println("Usage: mget <key>...")
+		return
+	}
+	results, err := c.MGet(ctx, params...)
+	checkErr(err)
+	for _, res := range results {
+		if !res.Found {
+			fmt.Printf("%s (nil)\n", res.Key)
+		} else {
+			fmt.Printf("%s %s\n", res.Key, formatValue(res.Value))
+		}
+	}
+}
+
+func runMSet(ctx context.Context, c *client.Client, params []string) {
+	if len(params) == 0 || len(params)%2 != 0 {
+		fmt.Println("Usage: mset <key> <value> [<key> <value>...]")
+		return
+	}
+	entries := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		entries[params[i]] = params[i+1]
+	}
+	results, err := c.MSet(ctx, entries)
+	checkErr(err)
+	for _, res := range results {
+		fmt.Printf("%s %s\n", res.Key, res.Message)
+	}
+}
+
+func runMDelete(ctx context.Context, c *client.Client, params []string) {
+	if len(params) == 0 {
+		fmt.Println("Usage: mdel <key>...")
+		return
+	}
+	results, err := c.MDelete(ctx, params...)
+	checkErr(err)
+	for _, res := range results {
+		fmt.Printf("%s %t\n", res.Key, res.Deleted)
 	}
 }
 
+func formatValue(value []byte) string {
+	if utf8.Valid(value) {
+		return string(value)
+	}
+	return "(binary) " + base64.StdEncoding.EncodeToString(value)
+}
+
 func runDelete(ctx context.Context, c *client.Client, params []string) {
 	if len(params) != 1 {
 		fmt.Println("Usage: del <key>")
@@ -123,6 +253,16 @@ func runDelete(ctx context.Context, c *client.Client, params []string) {
 	fmt.Println("Deleted")
 }
 
+func runTouch(ctx context.Context, c *client.Client, params []string) {
+	if len(params) != 1 {
+		fmt.Println("Usage: touch <key>")
+		return
+	}
+	err := c.Touch(ctx, params[0])
+	checkErr(err)
+	fmt.Println("OK")
+}
+
 func runClear(ctx context.Context, c *client.Client) {
 	err := c.Clear(ctx)
 	checkErr(err)
@@ -143,6 +283,177 @@ func runStats(ctx context.Context, c *client.Client) {
 	fmt.Println(stats)
 }
 
+func runWatch(ctx context.Context, c *client.Client, params []string) {
+	if len(params) == 0 {
+		fmt.Println("Usage: watch <key|prefix*>...")
+		return
+	}
+
+	var keys, prefixes []string
+	for _, p := range params {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			prefixes = append(prefixes, prefix)
+		} else {
+			keys = append(keys, p)
+		}
+	}
+
+	stream, err := c.Watch(ctx, keys, prefixes, true)
+	checkErr(err)
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF || ctx.Err() != nil {
+			return
+		}
+		checkErr(err)
+
+		switch event.Type {
+		case cachev1alpha.WatchEventType_WATCH_EVENT_TYPE_PUT:
+			fmt.Printf("PUT %s %s\n", event.Key, formatValue(event.Value))
+		case cachev1alpha.WatchEventType_WATCH_EVENT_TYPE_DELETE:
+			fmt.Printf("DELETE %s\n", event.Key)
+		case cachev1alpha.WatchEventType_WATCH_EVENT_TYPE_CLEAR:
+			fmt.Println("CLEAR")
+		case cachev1alpha.WatchEventType_WATCH_EVENT_TYPE_RESYNC:
+			fmt.Println("RESYNC")
+		}
+	}
+}
+
+func runPublish(ctx context.Context, c *client.Client, params []string) {
+	if len(params) != 2 {
+		fmt.Println("Usage: publish <channel> <message>")
+		return
+	}
+	receivers, err := c.Publish(ctx, params[0], []byte(params[1]))
+	checkErr(err)
+	fmt.Println(receivers)
+}
+
+func runSubscribe(ctx context.Context, c *client.Client, params []string) {
+	if len(params) == 0 {
+		fmt.Println("Usage: subscribe <channel|pattern>...")
+		return
+	}
+
+	var channels, patterns []string
+	for _, p := range params {
+		if glob.IsPattern(p) {
+			patterns = append(patterns, p)
+		} else {
+			channels = append(channels, p)
+		}
+	}
+
+	stream, err := c.Subscribe(ctx, channels, patterns)
+	checkErr(err)
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF || ctx.Err() != nil {
+			return
+		}
+		checkErr(err)
+		fmt.Printf("%s %s\n", msg.Channel, formatValue(msg.Payload))
+	}
+}
+
+func runSnapshot(ctx context.Context, c *client.Client) {
+	info, err := c.Snapshot(ctx)
+	checkErr(err)
+	printSnapshotInfo(info)
+}
+
+func runLastSnapshot(ctx context.Context, c *client.Client) {
+	info, err := c.LastSnapshot(ctx)
+	checkErr(err)
+	printSnapshotInfo(info)
+}
+
+func printSnapshotInfo(info *cachev1alpha.SnapshotInfo) {
+	fmt.Printf("time:     %s\n", time.Unix(0, info.Timestamp).UTC().Format(time.RFC3339Nano))
+	fmt.Printf("duration: %s\n", info.Duration.AsDuration())
+	fmt.Printf("size:     %d bytes\n", info.Size)
+	if info.Error != "" {
+		fmt.Printf("error:    %s\n", info.Error)
+	}
+}
+
+// runBackup writes the dump to stdout, so messages go to stderr.
+func runBackup(ctx context.Context, c *client.Client) {
+	n, err := c.Backup(ctx, os.Stdout)
+	checkErr(err)
+	fmt.Fprintf(os.Stderr, "Backup written (%d bytes)\n", n)
+}
+
+func runSnapshots(ctx context.Context, c *client.Client, params []string) {
+	if len(params) > 1 {
+		fmt.Println("Usage: snapshots [sink]")
+		return
+	}
+	var sink string
+	if len(params) == 1 {
+		sink = params[0]
+	}
+
+	entries, err := c.ListSnapshots(ctx, sink)
+	checkErr(err)
+	for _, e := range entries {
+		fmt.Printf("%s  %10d bytes  %s\n", time.Unix(0, e.Timestamp).UTC().Format(time.RFC3339Nano), e.Size, e.Name)
+	}
+}
+
+// runRestore loads a dump from stdin or, with -at, one of the snapshots
+// kept by the server or a sink.
+func runRestore(ctx context.Context, c *client.Client, params []string) {
+	mode := cachev1alpha.RestoreMode_RESTORE_MODE_REPLACE
+	var at, sink string
+	for len(params) > 0 {
+		switch {
+		case params[0] == "-merge":
+			mode = cachev1alpha.RestoreMode_RESTORE_MODE_MERGE
+			params = params[1:]
+		case params[0] == "-at" && len(params) > 1:
+			at = params[1]
+			params = params[2:]
+		case params[0] == "-sink" && len(params) > 1:
+			sink = params[1]
+			params = params[2:]
+		default:
+			fmt.Println("Usage: restore [-merge] < dump\n       restore [-merge] [-sink name] -at <time>")
+			return
+		}
+	}
+	if at == "" && sink != "" {
+		fmt.Println("Usage: restore [-merge] [-sink name] -at <time>")
+		return
+	}
+
+	var keys uint64
+	var err error
+	if at != "" {
+		t, parseErr := parseSnapshotTime(at)
+		checkErr(parseErr)
+		keys, err = c.RestoreSnapshot(ctx, t, sink, mode)
+	} else {
+		keys, err = c.Restore(ctx, os.Stdin, mode)
+	}
+	checkErr(err)
+	fmt.Printf("%d keys restored\n", keys)
+}
+
+// parseSnapshotTime accepts the RFC 3339 times printed by the snapshots
+// command and Unix times in nanoseconds.
+func parseSnapshotTime(s string) (time.Time, error) {
+	if ns, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(0, ns), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid snapshot time %q", s)
+	}
+	return t, nil
+}
+
 func usage() {
 	fmt.Println(`Usage:
   protocachecli [-host localhost] [-port 50051] [-socket /path/to/socket] <command> [args]
@@ -158,9 +469,25 @@ Commands:
   set <key> <value>     Set a value
   get <key>             Get a value
   del <key>             Delete a key
+  incr <key> [delta]    Increment an integer value
+  delmatch [-n] <pat>   Delete keys matching a glob pattern (-n: dry run)
+  mget <key>...         Get several values
+  mset <key> <value>... Set several values
+  mdel <key>...         Delete several keys
+  touch <key>           Refresh the idle timeout of a key
   list                  List all keys
   stats                 Print server stats
+  watch <key|prefix*>   Stream changes to keys, or to prefixes ending in *
+  publish <ch> <msg>    Publish a message to a channel
+  subscribe <ch|pat>    Stream messages from channels or glob patterns
   clear                 Clear the cache
+  snapshot              Write the server memory store dump and wait for it
+  lastsnapshot          Show the outcome of the last snapshot
+  backup > <file>       Stream a dump of the cache to stdout
+  restore [-merge] < f  Replace (or merge into) the cache from a dump on stdin
+  restore [-merge] [-sink name] -at <time>
+                        Restore the snapshot taken at time (RFC 3339 or Unix ns)
+  snapshots [sink]      List the timestamped snapshots kept by the server or a sink
   help                  Show this help message`)
 }
 
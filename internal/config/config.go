@@ -15,6 +15,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
@@ -23,6 +24,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/patrostkowski/protocache/internal/keyring"
 	"github.com/patrostkowski/protocache/internal/logger"
 	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
 	"google.golang.org/grpc"
@@ -38,7 +40,27 @@ const (
 	ServerShutdownTimeout     = 30 * time.Second
 	GracefulTimeout           = 10 * time.Second
 	MemoryDumpPath            = "/var/lib/protocache/"
-	MemoryDumpFileName        = "protocache.gob.gz"
+	MemoryDumpFileName        = "protocache.snap"
+	LegacyMemoryDumpFileName  = "protocache.gob.gz"
+	SnapshotCompression       = v1alpha.SnapshotCompressionGzip
+	SnapshotSinkTimeout       = 30 * time.Second
+	ExpirationInterval        = 1 * time.Second
+	LeaseTTL                  = 10 * time.Second
+	MaxValueSize              = 64 << 20
+	MaxRestoreSize            = 1 << 30
+	GRPCMaxRecvMsgSize        = 4 << 20
+	GRPCMessageOverhead       = 1 << 20
+	AppendOnlyFileName        = "appendonly.aof"
+	AppendOnlyFsync           = v1alpha.AppendFsyncEverySec
+	AppendOnlyRewriteSize     = 64 << 20
+	LoaderTimeout             = 1 * time.Second
+	LoaderTTL                 = 1 * time.Minute
+	LoaderNegativeTTL         = 1 * time.Second
+	WriteBehindTimeout        = 5 * time.Second
+	WriteBehindBatchSize      = 100
+	WriteBehindBufferSize     = 10000
+	WriteBehindFlushInterval  = 1 * time.Second
+	WriteBehindMaxBackoff     = 30 * time.Second
 	ConfigFilePath            = "/etc/protocache/"
 	ConfigFileName            = "config.yaml"
 	ConfigFileDefaultFilePath = ConfigFilePath + ConfigFileName
@@ -80,6 +102,8 @@ func DefaultConfig() *Config {
 			DumpEnabled:        false,
 			MemoryDumpPath:     MemoryDumpPath,
 			MemoryDumpFileName: MemoryDumpFileName,
+			ExpirationInterval: ExpirationInterval,
+			LeaseTTL:           LeaseTTL,
 		},
 		TLSConfig: &v1alpha.TLSConfig{
 			Enabled: false,
@@ -229,3 +253,201 @@ func (c *Config) GetStoreEngine() v1alpha.StoreEngine {
 func (c *Config) GetEvictionPolicy() v1alpha.EvictionPolicy {
 	return c.StoreConfig.EvictionPolicy
 }
+
+func (c *Config) GetExpirationInterval() time.Duration {
+	if c.StoreConfig.ExpirationInterval <= 0 {
+		return ExpirationInterval
+	}
+	return c.StoreConfig.ExpirationInterval
+}
+
+func (c *Config) GetLeaseTTL() time.Duration {
+	if c.StoreConfig.LeaseTTL <= 0 {
+		return LeaseTTL
+	}
+	return c.StoreConfig.LeaseTTL
+}
+
+func (c *Config) GetSnapshotInterval() time.Duration {
+	return c.StoreConfig.SnapshotInterval
+}
+
+func (c *Config) GetSnapshotAfterWrites() int {
+	return c.StoreConfig.SnapshotAfterWrites
+}
+
+func (c *Config) GetSnapshotCompression() v1alpha.SnapshotCompression {
+	if c.StoreConfig.SnapshotCompression == "" {
+		return SnapshotCompression
+	}
+	return c.StoreConfig.SnapshotCompression
+}
+
+func (c *Config) GetMaxValueSize() int {
+	if c.StoreConfig.MaxValueSize <= 0 {
+		return MaxValueSize
+	}
+	return c.StoreConfig.MaxValueSize
+}
+
+// GetMaxRecvMsgSize returns the largest message the gRPC server accepts:
+// a value of the maximum size with room for its key and the other fields
+// of the request, and never less than the gRPC default.
+func (c *Config) GetMaxRecvMsgSize() int {
+	return max(GRPCMaxRecvMsgSize, c.GetMaxValueSize()+GRPCMessageOverhead)
+}
+
+// GetMaxRestoreSize returns the largest dump a restore loads.
+func (c *Config) GetMaxRestoreSize() int64 {
+	if c.StoreConfig.MaxRestoreSize <= 0 {
+		return MaxRestoreSize
+	}
+	return c.StoreConfig.MaxRestoreSize
+}
+
+func (c *Config) IsLoaderEnabled() bool {
+	return c.StoreConfig.Loader != nil && c.StoreConfig.Loader.Address != ""
+}
+
+// GetLoaderConfig returns the loader configuration with defaults applied
+// to unset durations. It returns nil when no loader is configured.
+func (c *Config) GetLoaderConfig() *v1alpha.LoaderConfig {
+	if !c.IsLoaderEnabled() {
+		return nil
+	}
+
+	cfg := *c.StoreConfig.Loader
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = LoaderTimeout
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = LoaderTTL
+	}
+	if cfg.NegativeTTL <= 0 {
+		cfg.NegativeTTL = LoaderNegativeTTL
+	}
+	return &cfg
+}
+
+// GetSnapshotRetention returns how many timestamped snapshots are kept
+// locally. 0 means a single snapshot file is overwritten instead.
+func (c *Config) GetSnapshotRetention() int {
+	return max(c.StoreConfig.SnapshotRetention, 0)
+}
+
+// GetSnapshotSinks returns the snapshot sinks with defaults applied.
+func (c *Config) GetSnapshotSinks() []v1alpha.SnapshotSinkConfig {
+	sinks := make([]v1alpha.SnapshotSinkConfig, 0, len(c.StoreConfig.SnapshotSinks))
+	for _, cfg := range c.StoreConfig.SnapshotSinks {
+		if cfg.Name == "" {
+			cfg.Name = string(cfg.Type)
+		}
+		if cfg.Timeout <= 0 {
+			cfg.Timeout = SnapshotSinkTimeout
+		}
+		sinks = append(sinks, cfg)
+	}
+	return sinks
+}
+
+func (c *Config) IsWriteBehindEnabled() bool {
+	return c.StoreConfig.WriteBehind != nil && c.StoreConfig.WriteBehind.Sink != ""
+}
+
+// GetWriteBehindConfig returns the write-behind configuration with
+// defaults applied. It returns nil when write-behind is disabled.
+func (c *Config) GetWriteBehindConfig() *v1alpha.WriteBehindConfig {
+	if !c.IsWriteBehindEnabled() {
+		return nil
+	}
+
+	cfg := *c.StoreConfig.WriteBehind
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = WriteBehindTimeout
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = WriteBehindBatchSize
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = WriteBehindBufferSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = WriteBehindFlushInterval
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = WriteBehindMaxBackoff
+	}
+	return &cfg
+}
+
+func (c *Config) IsAppendOnlyEnabled() bool {
+	return c.StoreConfig.AppendOnly != nil && c.StoreConfig.AppendOnly.Enabled
+}
+
+// GetAppendOnlyConfig returns the append-only file configuration with
+// defaults applied. It returns nil when the append-only file is disabled.
+func (c *Config) GetAppendOnlyConfig() *v1alpha.AppendOnlyConfig {
+	if !c.IsAppendOnlyEnabled() {
+		return nil
+	}
+
+	cfg := *c.StoreConfig.AppendOnly
+	if cfg.Path == "" {
+		cfg.Path = filepath.Join(c.StoreConfig.MemoryDumpPath, AppendOnlyFileName)
+	}
+	if cfg.Fsync == "" {
+		cfg.Fsync = AppendOnlyFsync
+	}
+	if cfg.RewriteSize <= 0 {
+		cfg.RewriteSize = AppendOnlyRewriteSize
+	}
+	return &cfg
+}
+
+func (c *Config) IsEncryptionEnabled() bool {
+	return c.StoreConfig.Encryption != nil
+}
+
+// CreateKeyring loads the snapshot and append-only file encryption keys.
+// It returns nil when encryption is disabled.
+func (c *Config) CreateKeyring() (*keyring.Keyring, error) {
+	if !c.IsEncryptionEnabled() {
+		return nil, nil
+	}
+	cfg := c.StoreConfig.Encryption
+
+	primary, err := loadEncryptionKey(cfg.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encryption key: %w", err)
+	}
+	var decryption [][]byte
+	for i, src := range cfg.DecryptionKeys {
+		key, err := loadEncryptionKey(src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load decryption key %d: %w", i+1, err)
+		}
+		decryption = append(decryption, key)
+	}
+	return keyring.New(primary, decryption...)
+}
+
+func loadEncryptionKey(src v1alpha.EncryptionKeySource) ([]byte, error) {
+	var encoded string
+	switch {
+	case src.File != "":
+		data, err := os.ReadFile(src.File)
+		if err != nil {
+			return nil, err
+		}
+		encoded = string(data)
+	case src.Env != "":
+		value, ok := os.LookupEnv(src.Env)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s is not set", src.Env)
+		}
+		encoded = value
+	default:
+		return nil, errors.New("either file or env must be set")
+	}
+	return keyring.ParseKey(encoded)
+}
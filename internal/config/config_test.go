@@ -17,8 +17,11 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/patrostkowski/protocache/internal/keyring"
 	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -43,6 +46,14 @@ func TestDefaultConfig(t *testing.T) {
 	assert.False(t, cfg.StoreConfig.DumpEnabled)
 	assert.Equal(t, MemoryDumpPath, cfg.StoreConfig.MemoryDumpPath)
 	assert.Equal(t, MemoryDumpFileName, cfg.StoreConfig.MemoryDumpFileName)
+	assert.Equal(t, ExpirationInterval, cfg.GetExpirationInterval())
+	assert.Equal(t, LeaseTTL, cfg.GetLeaseTTL())
+	assert.Equal(t, MaxValueSize, cfg.GetMaxValueSize())
+	assert.Equal(t, MaxValueSize+GRPCMessageOverhead, cfg.GetMaxRecvMsgSize())
+	assert.Equal(t, int64(MaxRestoreSize), cfg.GetMaxRestoreSize())
+
+	cfg.StoreConfig.MaxValueSize = 1024
+	assert.Equal(t, GRPCMaxRecvMsgSize, cfg.GetMaxRecvMsgSize())
 }
 
 func TestMemoryDumpFileFullPath(t *testing.T) {
@@ -132,6 +143,13 @@ func TestLoadConfig_FileNotFound(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestLoadConfig_Example(t *testing.T) {
+	cfg, err := LoadConfig(filepath.Join("..", "..", "example", "config.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha.MapStoreEngine, cfg.GetStoreEngine())
+	assert.True(t, cfg.StoreConfig.DumpEnabled)
+}
+
 func TestLoadConfig_PartialDefaultsApplied(t *testing.T) {
 	tmpDir := t.TempDir()
 	yamlPath := filepath.Join(tmpDir, "partial.yaml")
@@ -167,6 +185,7 @@ store:
 	assert.True(t, cfg.StoreConfig.DumpEnabled)
 	assert.Equal(t, MemoryDumpPath, cfg.StoreConfig.MemoryDumpPath)
 	assert.Equal(t, MemoryDumpFileName, cfg.StoreConfig.MemoryDumpFileName)
+	assert.Equal(t, ExpirationInterval, cfg.GetExpirationInterval())
 }
 
 func TestCreateListener_TCP(t *testing.T) {
@@ -233,3 +252,81 @@ func TestCreateListener_MissingGRPCListener(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "GRPCListener config is nil")
 }
+
+func TestGetLoaderConfig_Defaults(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.False(t, cfg.IsLoaderEnabled())
+	assert.Nil(t, cfg.GetLoaderConfig())
+
+	cfg.StoreConfig.Loader = &v1alpha.LoaderConfig{
+		Address: "localhost:50052",
+		TTL:     5 * time.Minute,
+	}
+	assert.True(t, cfg.IsLoaderEnabled())
+
+	loader := cfg.GetLoaderConfig()
+	assert.Equal(t, "localhost:50052", loader.Address)
+	assert.Equal(t, 5*time.Minute, loader.TTL)
+	assert.Equal(t, LoaderTimeout, loader.Timeout)
+	assert.Equal(t, LoaderNegativeTTL, loader.NegativeTTL)
+}
+
+func TestGetAppendOnlyConfig_Defaults(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.False(t, cfg.IsAppendOnlyEnabled())
+	assert.Nil(t, cfg.GetAppendOnlyConfig())
+
+	cfg.StoreConfig.AppendOnly = &v1alpha.AppendOnlyConfig{
+		Enabled: true,
+		Fsync:   v1alpha.AppendFsyncAlways,
+	}
+	assert.True(t, cfg.IsAppendOnlyEnabled())
+
+	aof := cfg.GetAppendOnlyConfig()
+	assert.Equal(t, filepath.Join(MemoryDumpPath, AppendOnlyFileName), aof.Path)
+	assert.Equal(t, v1alpha.AppendFsyncAlways, aof.Fsync)
+	assert.Equal(t, int64(AppendOnlyRewriteSize), aof.RewriteSize)
+}
+
+func TestGetSnapshotSinks_Defaults(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.Empty(t, cfg.GetSnapshotSinks())
+
+	cfg.StoreConfig.SnapshotSinks = []v1alpha.SnapshotSinkConfig{
+		{Type: v1alpha.HTTPSnapshotSink, URL: "http://backup"},
+		{Name: "nas", Type: v1alpha.DirSnapshotSink, Path: "/mnt/nas", Timeout: time.Second},
+	}
+	sinks := cfg.GetSnapshotSinks()
+	assert.Equal(t, "http", sinks[0].Name)
+	assert.Equal(t, SnapshotSinkTimeout, sinks[0].Timeout)
+	assert.Equal(t, "nas", sinks[1].Name)
+	assert.Equal(t, time.Second, sinks[1].Timeout)
+	assert.Empty(t, cfg.StoreConfig.SnapshotSinks[0].Name)
+}
+
+func TestCreateKeyring(t *testing.T) {
+	cfg := DefaultConfig()
+	keys, err := cfg.CreateKeyring()
+	assert.NoError(t, err)
+	assert.Nil(t, keys)
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	assert.NoError(t, os.WriteFile(keyFile, []byte(strings.Repeat("ab", keyring.KeySize)+"\n"), 0o600))
+	t.Setenv("PROTOCACHE_TEST_OLD_KEY", strings.Repeat("cd", keyring.KeySize))
+
+	cfg.StoreConfig.Encryption = &v1alpha.EncryptionConfig{
+		Key:            v1alpha.EncryptionKeySource{File: keyFile},
+		DecryptionKeys: []v1alpha.EncryptionKeySource{{Env: "PROTOCACHE_TEST_OLD_KEY"}},
+	}
+	keys, err = cfg.CreateKeyring()
+	assert.NoError(t, err)
+	assert.NotNil(t, keys)
+
+	cfg.StoreConfig.Encryption.DecryptionKeys = []v1alpha.EncryptionKeySource{{Env: "PROTOCACHE_TEST_MISSING_KEY"}}
+	_, err = cfg.CreateKeyring()
+	assert.ErrorContains(t, err, "PROTOCACHE_TEST_MISSING_KEY is not set")
+
+	cfg.StoreConfig.Encryption.Key = v1alpha.EncryptionKeySource{}
+	_, err = cfg.CreateKeyring()
+	assert.ErrorContains(t, err, "either file or env must be set")
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dump
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+
+	"github.com/patrostkowski/protocache/internal/store"
+)
+
+type ChangeType int
+
+const (
+	Added ChangeType = iota
+	Removed
+	Changed
+)
+
+func (t ChangeType) String() string {
+	switch t {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	default:
+		return "unknown"
+	}
+}
+
+// Change is a key that differs between two dumps. Old is nil for added
+// keys and New is nil for removed ones.
+type Change struct {
+	Key  string
+	Type ChangeType
+	Old  *store.Record
+	New  *store.Record
+}
+
+// Diff returns the keys that differ between the dumps from and to,
+// sorted by key. A key has changed if its value or expiry settings
+// differ; versions are ignored, since every server assigns its own.
+func Diff(from, to []store.Record) []Change {
+	old := make(map[string]*store.Record, len(from))
+	for i := range from {
+		old[from[i].Key] = &from[i]
+	}
+
+	var changes []Change
+	for i := range to {
+		r := &to[i]
+		prev, ok := old[r.Key]
+		delete(old, r.Key)
+		switch {
+		case !ok:
+			changes = append(changes, Change{Key: r.Key, Type: Added, New: r})
+		case !sameRecord(prev, r):
+			changes = append(changes, Change{Key: r.Key, Type: Changed, Old: prev, New: r})
+		}
+	}
+	for key, r := range old {
+		changes = append(changes, Change{Key: key, Type: Removed, Old: r})
+	}
+
+	slices.SortFunc(changes, func(a, b Change) int { return strings.Compare(a.Key, b.Key) })
+	return changes
+}
+
+func sameRecord(a, b *store.Record) bool {
+	return bytes.Equal(a.Value, b.Value) &&
+		a.IdleTimeout == b.IdleTimeout &&
+		a.StaleAt.Equal(b.StaleAt) &&
+		a.ExpiresAt.Equal(b.ExpiresAt)
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dump
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrostkowski/protocache/internal/store"
+)
+
+func TestDiff(t *testing.T) {
+	expiry := time.Unix(1700000000, 0)
+	from := []store.Record{
+		{Key: "same", Value: []byte("1"), Version: 1},
+		{Key: "value", Value: []byte("old")},
+		{Key: "ttl", Value: []byte("x"), ExpiresAt: expiry},
+		{Key: "removed", Value: []byte("gone")},
+	}
+	to := []store.Record{
+		{Key: "added", Value: []byte("new")},
+		{Key: "ttl", Value: []byte("x"), ExpiresAt: expiry.Add(time.Second)},
+		{Key: "value", Value: []byte("new")},
+		{Key: "same", Value: []byte("1"), Version: 7},
+	}
+
+	changes := Diff(from, to)
+	require.Len(t, changes, 4)
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.Key+" "+c.Type.String())
+	}
+	assert.Equal(t, []string{"added added", "removed removed", "ttl changed", "value changed"}, got)
+
+	assert.Nil(t, changes[0].Old)
+	assert.Equal(t, []byte("new"), changes[0].New.Value)
+	assert.Nil(t, changes[1].New)
+	assert.Equal(t, []byte("old"), changes[3].Old.Value)
+}
+
+func TestDiff_Identical(t *testing.T) {
+	records := []store.Record{{Key: "a", Value: []byte("1")}}
+	assert.Empty(t, Diff(records, records))
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dump inspects and converts snapshot contents offline, for the
+// protocache-dump command.
+package dump
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/patrostkowski/protocache/internal/store"
+)
+
+// maxLineSize bounds a single NDJSON line, which holds a base64 value.
+const maxLineSize = 256 << 20
+
+// jsonRecord is the NDJSON form of a store.Record. Values are base64
+// encoded, like the write-behind file sink.
+type jsonRecord struct {
+	Key         string     `json:"key"`
+	Value       []byte     `json:"value"`
+	Version     uint64     `json:"version,omitempty"`
+	IdleTimeout string     `json:"idle_timeout,omitempty"`
+	StaleAt     *time.Time `json:"stale_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// WriteNDJSON writes one JSON object per record.
+func WriteNDJSON(w io.Writer, records []store.Record) error {
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	for _, r := range records {
+		jr := jsonRecord{
+			Key:       r.Key,
+			Value:     r.Value,
+			Version:   r.Version,
+			StaleAt:   optionalTime(r.StaleAt),
+			ExpiresAt: optionalTime(r.ExpiresAt),
+		}
+		if r.IdleTimeout > 0 {
+			jr.IdleTimeout = r.IdleTimeout.String()
+		}
+		if err := enc.Encode(jr); err != nil {
+			return err
+		}
+	}
+	return buf.Flush()
+}
+
+// ReadNDJSON reads records written by WriteNDJSON. Blank lines are
+// skipped; a missing or repeated key is an error.
+func ReadNDJSON(r io.Reader) ([]store.Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxLineSize)
+
+	var records []store.Record
+	seen := make(map[string]bool)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var jr jsonRecord
+		if err := json.Unmarshal(scanner.Bytes(), &jr); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if jr.Key == "" {
+			return nil, fmt.Errorf("line %d: missing key", line)
+		}
+		if seen[jr.Key] {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line, jr.Key)
+		}
+		seen[jr.Key] = true
+
+		rec := store.Record{Key: jr.Key, Value: jr.Value, Version: jr.Version}
+		if rec.Value == nil {
+			rec.Value = []byte{}
+		}
+		if jr.IdleTimeout != "" {
+			d, err := time.ParseDuration(jr.IdleTimeout)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: idle_timeout: %w", line, err)
+			}
+			rec.IdleTimeout = d
+		}
+		if jr.StaleAt != nil {
+			rec.StaleAt = *jr.StaleAt
+		}
+		if jr.ExpiresAt != nil {
+			rec.ExpiresAt = *jr.ExpiresAt
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dump
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrostkowski/protocache/internal/store"
+)
+
+func TestNDJSON_RoundTrip(t *testing.T) {
+	records := []store.Record{
+		{Key: "plain", Value: []byte("hello"), Version: 3},
+		{Key: "empty", Value: []byte{}},
+		{
+			Key:         "ttl",
+			Value:       []byte{0xff, 0x00},
+			IdleTimeout: time.Minute,
+			StaleAt:     time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+			ExpiresAt:   time.Date(2030, 1, 2, 0, 0, 0, 500, time.UTC),
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteNDJSON(&buf, records))
+	assert.Equal(t, 3, strings.Count(buf.String(), "\n"))
+	assert.Contains(t, buf.String(), `{"key":"plain","value":"aGVsbG8=","version":3}`)
+
+	got, err := ReadNDJSON(&buf)
+	require.NoError(t, err)
+	require.Len(t, got, len(records))
+	for i := range records {
+		assert.Equal(t, records[i].Key, got[i].Key)
+		assert.Equal(t, records[i].Value, got[i].Value)
+		assert.Equal(t, records[i].Version, got[i].Version)
+		assert.Equal(t, records[i].IdleTimeout, got[i].IdleTimeout)
+		assert.True(t, records[i].StaleAt.Equal(got[i].StaleAt))
+		assert.True(t, records[i].ExpiresAt.Equal(got[i].ExpiresAt))
+	}
+}
+
+func TestReadNDJSON_Invalid(t *testing.T) {
+	for name, input := range map[string]string{
+		"malformed":    `{"key":`,
+		"missing key":  `{"value":"aGk="}`,
+		"duplicate":    "{\"key\":\"a\"}\n{\"key\":\"a\"}",
+		"idle timeout": `{"key":"a","idle_timeout":"soon"}`,
+	} {
+		_, err := ReadNDJSON(strings.NewReader(input))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestReadNDJSON_SkipsBlankLines(t *testing.T) {
+	records, err := ReadNDJSON(strings.NewReader("\n{\"key\":\"a\"}\n\n"))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, []byte{}, records[0].Value)
+}
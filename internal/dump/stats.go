@@ -0,0 +1,82 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dump
+
+import (
+	"math"
+	"time"
+
+	"github.com/patrostkowski/protocache/internal/store"
+)
+
+// bucketBounds are the upper bounds, in bytes, of the value size
+// histogram. Values above the last bound fall into a final open bucket.
+var bucketBounds = []int64{0, 64, 256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20}
+
+// Bucket counts the values whose size is at most Le bytes and above the
+// previous bucket's bound. The last bucket has Le set to math.MaxInt64.
+type Bucket struct {
+	Le    int64
+	Count int
+}
+
+// Stats summarizes the records of a dump.
+type Stats struct {
+	Keys       int
+	KeyBytes   int64
+	ValueBytes int64
+	MaxValue   int64
+	// WithTTL counts records with a soft or hard TTL, WithIdleTimeout
+	// those with an idle timeout, and Expired those whose hard TTL has
+	// passed and which a server would drop on restore.
+	WithTTL         int
+	WithIdleTimeout int
+	Expired         int
+	Histogram       []Bucket
+}
+
+// ComputeStats summarizes records as of now.
+func ComputeStats(records []store.Record, now time.Time) Stats {
+	st := Stats{Histogram: make([]Bucket, len(bucketBounds)+1)}
+	for i, le := range bucketBounds {
+		st.Histogram[i].Le = le
+	}
+	st.Histogram[len(bucketBounds)].Le = math.MaxInt64
+
+	for _, r := range records {
+		size := int64(len(r.Value))
+		st.Keys++
+		st.KeyBytes += int64(len(r.Key))
+		st.ValueBytes += size
+		st.MaxValue = max(st.MaxValue, size)
+		if !r.StaleAt.IsZero() || !r.ExpiresAt.IsZero() {
+			st.WithTTL++
+		}
+		if r.IdleTimeout > 0 {
+			st.WithIdleTimeout++
+		}
+		if !r.ExpiresAt.IsZero() && !r.ExpiresAt.After(now) {
+			st.Expired++
+		}
+
+		for i := range st.Histogram {
+			if size <= st.Histogram[i].Le {
+				st.Histogram[i].Count++
+				break
+			}
+		}
+	}
+	return st
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dump
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/patrostkowski/protocache/internal/store"
+)
+
+func TestComputeStats(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	records := []store.Record{
+		{Key: "empty", Value: []byte{}},
+		{Key: "small", Value: make([]byte, 64), IdleTimeout: time.Minute},
+		{Key: "medium", Value: make([]byte, 65), StaleAt: now.Add(time.Hour)},
+		{Key: "expired", Value: make([]byte, 1000), ExpiresAt: now.Add(-time.Second)},
+		{Key: "huge", Value: make([]byte, 5<<20)},
+	}
+
+	st := ComputeStats(records, now)
+	assert.Equal(t, 5, st.Keys)
+	assert.Equal(t, int64(len("emptysmallmediumexpiredhuge")), st.KeyBytes)
+	assert.Equal(t, int64(64+65+1000+5<<20), st.ValueBytes)
+	assert.Equal(t, int64(5<<20), st.MaxValue)
+	assert.Equal(t, 2, st.WithTTL)
+	assert.Equal(t, 1, st.WithIdleTimeout)
+	assert.Equal(t, 1, st.Expired)
+
+	counts := make(map[int64]int)
+	total := 0
+	for _, b := range st.Histogram {
+		counts[b.Le] = b.Count
+		total += b.Count
+	}
+	assert.Equal(t, 5, total)
+	assert.Equal(t, 1, counts[0])
+	assert.Equal(t, 1, counts[64])
+	assert.Equal(t, 1, counts[256])
+	assert.Equal(t, 1, counts[1<<10])
+	assert.Equal(t, 1, counts[math.MaxInt64])
+}
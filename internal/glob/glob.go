@@ -0,0 +1,63 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package glob implements Redis-style key patterns. Unlike path.Match,
+// '*' also matches '/' and ':', which are common key separators.
+package glob
+
+// Match reports whether s matches pattern. '*' matches any sequence of
+// characters, '?' matches a single character and '\' escapes the next
+// character.
+func Match(pattern, s string) bool {
+	var p, i int
+	starP, starI := -1, 0
+
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			starP, starI = p, i
+			p++
+		case p < len(pattern) && pattern[p] == '?':
+			p++
+			i++
+		case p+1 < len(pattern) && pattern[p] == '\\' && pattern[p+1] == s[i]:
+			p += 2
+			i++
+		case p < len(pattern) && pattern[p] != '\\' && pattern[p] == s[i]:
+			p++
+			i++
+		case starP >= 0:
+			starI++
+			p, i = starP+1, starI
+		default:
+			return false
+		}
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// IsPattern reports whether pattern contains any wildcard.
+func IsPattern(pattern string) bool {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?', '\\':
+			return true
+		}
+	}
+	return false
+}
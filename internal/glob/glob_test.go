@@ -0,0 +1,54 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"*", "", true},
+		{"*", "anything", true},
+		{"tenant:42:*", "tenant:42:user/1", true},
+		{"tenant:42:*", "tenant:43:user", false},
+		{"user:?", "user:1", true},
+		{"user:?", "user:12", false},
+		{"*:session", "a:b:session", true},
+		{"*:session", "a:b:sessions", false},
+		{"a*b*c", "aXXbYYc", true},
+		{"a*b*c", "aXXbYY", false},
+		{`literal\*`, "literal*", true},
+		{`literal\*`, "literalX", false},
+		{"exact", "exact", true},
+		{"exact", "exactly", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Match(tt.pattern, tt.s), "Match(%q, %q)", tt.pattern, tt.s)
+	}
+}
+
+func TestIsPattern(t *testing.T) {
+	assert.True(t, IsPattern("news.*"))
+	assert.True(t, IsPattern("user:?"))
+	assert.False(t, IsPattern("news.sports"))
+}
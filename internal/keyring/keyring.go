@@ -0,0 +1,154 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package keyring holds the AES-256-GCM keys that encrypt data at rest.
+// New data is sealed with the primary key; any key of the ring opens it,
+// so keys can be rotated by demoting the old primary to a decryption key.
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the size of an AES-256 key in bytes.
+const KeySize = 32
+
+// SealedOverhead is the number of bytes Seal adds to a message: the key
+// ID, the nonce and the GCM tag.
+const SealedOverhead = 4 + 12 + 16
+
+var (
+	// ErrUnknownKey is returned for data sealed with a key that is not
+	// in the ring.
+	ErrUnknownKey = errors.New("data is encrypted with a key that is not configured")
+	// ErrDecrypt is returned when data fails authentication.
+	ErrDecrypt = errors.New("decryption failed")
+)
+
+// Key is one key of a ring. ID identifies the key in sealed data without
+// revealing it.
+type Key struct {
+	ID   uint32
+	AEAD cipher.AEAD
+}
+
+func newKey(raw []byte) (*Key, error) {
+	if len(raw) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(raw))
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(append([]byte("protocache key id\x00"), raw...))
+	return &Key{ID: binary.BigEndian.Uint32(sum[:]), AEAD: aead}, nil
+}
+
+type Keyring struct {
+	primary *Key
+	keys    []*Key
+}
+
+// New returns a ring that seals with primary and opens with primary or
+// any of the decryption keys.
+func New(primary []byte, decryption ...[]byte) (*Keyring, error) {
+	p, err := newKey(primary)
+	if err != nil {
+		return nil, fmt.Errorf("encryption key: %w", err)
+	}
+	k := &Keyring{primary: p, keys: []*Key{p}}
+	for i, raw := range decryption {
+		key, err := newKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("decryption key %d: %w", i+1, err)
+		}
+		k.keys = append(k.keys, key)
+	}
+	return k, nil
+}
+
+// ParseKey decodes a hex or base64 encoded key. Surrounding whitespace,
+// such as the trailing newline of a key file, is ignored.
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == hex.EncodedLen(KeySize) {
+		if raw, err := hex.DecodeString(s); err == nil {
+			return raw, nil
+		}
+	}
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(raw) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, hex or base64 encoded", KeySize)
+	}
+	return raw, nil
+}
+
+// Primary returns the key new data is sealed with.
+func (k *Keyring) Primary() *Key {
+	return k.primary
+}
+
+// Lookup returns the key with the given ID.
+func (k *Keyring) Lookup(id uint32) (*Key, error) {
+	for _, key := range k.keys {
+		if key.ID == id {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w (key id %08x)", ErrUnknownKey, id)
+}
+
+// Seal encrypts plaintext with the primary key and a random nonce, and
+// appends the key ID, the nonce and the ciphertext to dst.
+func (k *Keyring) Seal(dst, plaintext []byte) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, k.primary.ID)
+	nonce := make([]byte, k.primary.AEAD.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		// crypto/rand.Read never fails on supported platforms.
+		panic(err)
+	}
+	dst = append(dst, nonce...)
+	return k.primary.AEAD.Seal(dst, nonce, plaintext, nil)
+}
+
+// Open decrypts a message produced by Seal and appends the plaintext to
+// dst.
+func (k *Keyring) Open(dst, sealed []byte) ([]byte, error) {
+	if len(sealed) < SealedOverhead {
+		return nil, ErrDecrypt
+	}
+	key, err := k.Lookup(binary.BigEndian.Uint32(sealed))
+	if err != nil {
+		return nil, err
+	}
+	nonce := sealed[4 : 4+key.AEAD.NonceSize()]
+	plaintext, err := key.AEAD.Open(dst, nonce, sealed[4+len(nonce):], nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyring
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rawKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestParseKey(t *testing.T) {
+	key := rawKey(7)
+
+	parsed, err := ParseKey(hex.EncodeToString(key) + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, key, parsed)
+
+	parsed, err = ParseKey(" " + base64.StdEncoding.EncodeToString(key))
+	require.NoError(t, err)
+	assert.Equal(t, key, parsed)
+
+	_, err = ParseKey("too short")
+	assert.Error(t, err)
+	_, err = ParseKey(base64.StdEncoding.EncodeToString(key[:16]))
+	assert.Error(t, err)
+}
+
+func TestNew_InvalidKey(t *testing.T) {
+	_, err := New(rawKey(1)[:16])
+	assert.ErrorContains(t, err, "encryption key")
+
+	_, err = New(rawKey(1), rawKey(2)[:5])
+	assert.ErrorContains(t, err, "decryption key 1")
+}
+
+func TestSealAndOpen(t *testing.T) {
+	keys, err := New(rawKey(1))
+	require.NoError(t, err)
+
+	sealed := keys.Seal(nil, []byte("hello"))
+	assert.Len(t, sealed, len("hello")+SealedOverhead)
+	assert.NotEqual(t, sealed, keys.Seal(nil, []byte("hello")), "nonces must differ")
+
+	opened, err := keys.Open(nil, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), opened)
+
+	sealed[len(sealed)-1] ^= 1
+	_, err = keys.Open(nil, sealed)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = keys.Open(nil, sealed[:3])
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestRotation(t *testing.T) {
+	old, err := New(rawKey(1))
+	require.NoError(t, err)
+	sealed := old.Seal(nil, []byte("hello"))
+
+	rotated, err := New(rawKey(2), rawKey(1))
+	require.NoError(t, err)
+	assert.NotEqual(t, old.Primary().ID, rotated.Primary().ID)
+
+	opened, err := rotated.Open(nil, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), opened)
+
+	other, err := New(rawKey(3))
+	require.NoError(t, err)
+	_, err = other.Open(nil, sealed)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
@@ -15,6 +15,7 @@
 package logger
 
 import (
+	"io"
 	"log/slog"
 	"os"
 )
@@ -29,7 +30,13 @@ var (
 )
 
 func init() {
-	Logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+	SetOutput(os.Stdout)
+}
+
+// SetOutput sends all further log records to w. Tools that write data to
+// stdout use it to move logs out of the way.
+func SetOutput(w io.Writer) {
+	Logger = slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
 		Level: slog.LevelDebug, // Allow all levels
 	}))
 
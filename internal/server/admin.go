@@ -0,0 +1,292 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/patrostkowski/protocache/internal/logger"
+	"github.com/patrostkowski/protocache/internal/snapshot"
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+// adminService implements AdminService on top of a Server. It is a
+// separate type because its method names clash with CacheService and
+// with Server.Snapshot.
+type adminService struct {
+	cachev1alpha.UnimplementedAdminServiceServer
+
+	s *Server
+}
+
+func snapshotInfoProto(info snapshotInfo) *cachev1alpha.SnapshotInfo {
+	res := &cachev1alpha.SnapshotInfo{
+		Timestamp: info.Time.UnixNano(),
+		Duration:  durationpb.New(info.Duration),
+		Size:      info.Size,
+	}
+	if info.Err != nil {
+		res.Error = info.Err.Error()
+	}
+	return res
+}
+
+func (a *adminService) Snapshot(ctx context.Context, _ *cachev1alpha.SnapshotRequest) (*cachev1alpha.SnapshotInfo, error) {
+	if !a.s.config.IsMemoryStoreDumpEnabled() {
+		return nil, status.Error(codes.FailedPrecondition, "memory store dump is disabled")
+	}
+	if err := a.s.Snapshot("admin"); err != nil {
+		return nil, status.Errorf(codes.Internal, "snapshot failed: %v", err)
+	}
+	return snapshotInfoProto(a.s.snapshots.lastSnapshot()), nil
+}
+
+func (a *adminService) LastSnapshot(ctx context.Context, _ *cachev1alpha.LastSnapshotRequest) (*cachev1alpha.SnapshotInfo, error) {
+	info := a.s.snapshots.lastSnapshot()
+	if info.Time.IsZero() {
+		return nil, status.Error(codes.NotFound, "no snapshot has been taken")
+	}
+	return snapshotInfoProto(info), nil
+}
+
+// Backup encodes the store to a temporary file first and then streams the
+// file, so that a slow client does not hold up snapshots for the whole
+// transfer.
+func (a *adminService) Backup(_ *cachev1alpha.BackupRequest, stream cachev1alpha.AdminService_BackupServer) error {
+	f, err := os.CreateTemp("", "protocache-backup-*")
+	if err != nil {
+		return status.Errorf(codes.Internal, "create backup file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := snapshot.Write(f, a.s.store.Snapshot(), a.s.snapshotOptions()); err != nil {
+		logger.Error("Failed to encode backup", "error", err)
+		return status.Errorf(codes.Internal, "encode backup: %v", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return status.Errorf(codes.Internal, "read backup file: %v", err)
+	}
+
+	var sent int
+	for {
+		buf := make([]byte, streamChunkSize)
+		n, err := io.ReadFull(f, buf)
+		if n > 0 {
+			if err := stream.Send(&cachev1alpha.BackupChunk{Data: buf[:n]}); err != nil {
+				return err
+			}
+			sent += n
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "read backup file: %v", err)
+		}
+	}
+	logger.Info("Backup sent", "size", sent)
+	return nil
+}
+
+// Restore loads a dump sent by the caller. The dump is verified before
+// any key is touched. A replace swaps the whole store for the dump in one
+// store call, so no reader sees it empty. Restored keys are not forwarded
+// to the write-behind sink.
+func (a *adminService) Restore(stream cachev1alpha.AdminService_RestoreServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	header := first.GetHeader()
+	if header == nil {
+		return status.Error(codes.InvalidArgument, "first message must be a header")
+	}
+	if !validRestoreMode(header.Mode) {
+		return status.Error(codes.InvalidArgument, "restore mode must be replace or merge")
+	}
+
+	limit := a.s.config.GetMaxRestoreSize()
+	var data bytes.Buffer
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		chunk, ok := msg.Part.(*cachev1alpha.RestoreRequest_Chunk)
+		if !ok {
+			return status.Error(codes.InvalidArgument, "unexpected message after header")
+		}
+		if int64(data.Len()+len(chunk.Chunk)) > limit {
+			return restoreTooLarge(limit)
+		}
+		data.Write(chunk.Chunk)
+	}
+
+	keys, err := a.restore(bytes.NewReader(data.Bytes()), header.Mode)
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(&cachev1alpha.RestoreResponse{Keys: keys})
+}
+
+// validRestoreMode reports whether mode is replace or merge.
+func validRestoreMode(mode cachev1alpha.RestoreMode) bool {
+	switch mode {
+	case cachev1alpha.RestoreMode_RESTORE_MODE_REPLACE, cachev1alpha.RestoreMode_RESTORE_MODE_MERGE:
+		return true
+	default:
+		return false
+	}
+}
+
+func restoreTooLarge(limit int64) error {
+	return status.Errorf(codes.ResourceExhausted, "dump exceeds the maximum of %d bytes", limit)
+}
+
+// restore verifies the dump read from r and loads it into the store
+// according to mode. It returns the number of keys loaded.
+func (a *adminService) restore(r io.ReadSeeker, mode cachev1alpha.RestoreMode) (uint64, error) {
+	records, _, err := snapshot.Read(r, a.s.keys)
+	if err != nil {
+		if isKeyError(err) {
+			return 0, status.Errorf(codes.FailedPrecondition, "invalid dump: %v", err)
+		}
+		if errors.Is(err, snapshot.ErrCorrupt) {
+			return 0, status.Errorf(codes.DataLoss, "invalid dump: %v", err)
+		}
+		return 0, status.Errorf(codes.InvalidArgument, "invalid dump: %v", err)
+	}
+
+	s := a.s
+	load := s.store.Restore
+	if mode == cachev1alpha.RestoreMode_RESTORE_MODE_REPLACE {
+		s.leases.reset()
+		load = s.store.Replace
+	} else {
+		for _, r := range records {
+			s.leases.invalidate(r.Key)
+		}
+	}
+	if err := load(records); err != nil {
+		return 0, status.Errorf(codes.Internal, "restore: %v", err)
+	}
+
+	logger.Info("Restored dump", "mode", mode, "keys", len(records))
+	return uint64(len(records)), nil
+}
+
+// snapshotSink resolves the sink named in a ListSnapshots or
+// RestoreSnapshot request.
+func (a *adminService) snapshotSink(name string) (snapshot.Sink, error) {
+	sink := a.s.snapshotSink(name)
+	if sink != nil {
+		return sink, nil
+	}
+	if name == "" {
+		return nil, status.Error(codes.FailedPrecondition, "snapshot retention is disabled")
+	}
+	return nil, status.Errorf(codes.NotFound, "unknown snapshot sink %q", name)
+}
+
+func (a *adminService) ListSnapshots(ctx context.Context, req *cachev1alpha.ListSnapshotsRequest) (*cachev1alpha.ListSnapshotsResponse, error) {
+	sink, err := a.snapshotSink(req.Sink)
+	if err != nil {
+		return nil, err
+	}
+	lister, ok := sink.(snapshot.Lister)
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "snapshot sink %q cannot list snapshots", req.Sink)
+	}
+
+	entries, err := lister.List(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list snapshots: %v", err)
+	}
+	res := &cachev1alpha.ListSnapshotsResponse{}
+	for _, e := range entries {
+		res.Snapshots = append(res.Snapshots, &cachev1alpha.SnapshotEntry{
+			Name:      e.Name,
+			Timestamp: e.Time.UnixNano(),
+			Size:      e.Size,
+		})
+	}
+	return res, nil
+}
+
+// RestoreSnapshot loads the snapshot taken at the requested time from the
+// local snapshots or a sink, like Restore.
+func (a *adminService) RestoreSnapshot(ctx context.Context, req *cachev1alpha.RestoreSnapshotRequest) (*cachev1alpha.RestoreResponse, error) {
+	if !validRestoreMode(req.Mode) {
+		return nil, status.Error(codes.InvalidArgument, "restore mode must be replace or merge")
+	}
+	if req.Timestamp <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "timestamp is required")
+	}
+	sink, err := a.snapshotSink(req.Sink)
+	if err != nil {
+		return nil, err
+	}
+
+	name := a.s.snapshotName(time.Unix(0, req.Timestamp))
+	r, err := sink.Open(ctx, name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, status.Errorf(codes.NotFound, "no snapshot %s", name)
+		}
+		return nil, status.Errorf(codes.Unavailable, "open snapshot %s: %v", name, err)
+	}
+	defer r.Close()
+
+	// Local snapshots are files and are read in place; anything else is
+	// buffered, up to the restore limit.
+	dump, ok := r.(io.ReadSeeker)
+	limit := a.s.config.GetMaxRestoreSize()
+	if ok {
+		size, err := dump.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "read snapshot %s: %v", name, err)
+		}
+		if size > limit {
+			return nil, restoreTooLarge(limit)
+		}
+	} else {
+		data, err := io.ReadAll(io.LimitReader(r, limit+1))
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "read snapshot %s: %v", name, err)
+		}
+		if int64(len(data)) > limit {
+			return nil, restoreTooLarge(limit)
+		}
+		dump = bytes.NewReader(data)
+	}
+	keys, err := a.restore(dump, req.Mode)
+	if err != nil {
+		return nil, err
+	}
+	return &cachev1alpha.RestoreResponse{Keys: keys}, nil
+}
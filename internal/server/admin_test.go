@@ -0,0 +1,251 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+func backup(t *testing.T, admin v1alpha.AdminServiceClient) []byte {
+	t.Helper()
+
+	stream, err := admin.Backup(context.Background(), &v1alpha.BackupRequest{})
+	require.NoError(t, err)
+
+	var data []byte
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return data
+		}
+		require.NoError(t, err)
+		data = append(data, chunk.Data...)
+	}
+}
+
+func restore(t *testing.T, admin v1alpha.AdminServiceClient, mode v1alpha.RestoreMode, data []byte) (*v1alpha.RestoreResponse, error) {
+	t.Helper()
+
+	stream, err := admin.Restore(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&v1alpha.RestoreRequest{Part: &v1alpha.RestoreRequest_Header{
+		Header: &v1alpha.RestoreHeader{Mode: mode},
+	}}))
+	for len(data) > 0 {
+		n := min(len(data), 7)
+		// A send fails with io.EOF once the server has rejected the
+		// stream; CloseAndRecv returns its status.
+		if err := stream.Send(&v1alpha.RestoreRequest{Part: &v1alpha.RestoreRequest_Chunk{Chunk: data[:n]}}); err != nil {
+			require.ErrorIs(t, err, io.EOF)
+			break
+		}
+		data = data[n:]
+	}
+	return stream.CloseAndRecv()
+}
+
+func TestAdmin_SnapshotAndLastSnapshot(t *testing.T) {
+	s := NewServer(defaultConfig(t.TempDir()), DefaultPrometheusRegistry())
+	admin := NewTestAdminClient(t, s)
+	ctx := context.Background()
+
+	_, err := admin.LastSnapshot(ctx, &v1alpha.LastSnapshotRequest{})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.Set(ctx, &v1alpha.SetRequest{Key: "a", Value: []byte("1")})
+	require.NoError(t, err)
+
+	info, err := admin.Snapshot(ctx, &v1alpha.SnapshotRequest{})
+	require.NoError(t, err)
+	assert.Positive(t, info.Size)
+	assert.Empty(t, info.Error)
+
+	last, err := admin.LastSnapshot(ctx, &v1alpha.LastSnapshotRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, info.Timestamp, last.Timestamp)
+	assert.Equal(t, info.Size, last.Size)
+}
+
+func TestAdmin_SnapshotDisabled(t *testing.T) {
+	s := NewTestServer(t)
+	admin := NewTestAdminClient(t, s)
+
+	_, err := admin.Snapshot(context.Background(), &v1alpha.SnapshotRequest{})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestAdmin_BackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	src := NewTestServer(t)
+	for _, key := range []string{"a", "b"} {
+		_, err := src.Set(ctx, &v1alpha.SetRequest{Key: key, Value: []byte("src-" + key)})
+		require.NoError(t, err)
+	}
+	data := backup(t, NewTestAdminClient(t, src))
+
+	newDest := func() (*Server, v1alpha.AdminServiceClient) {
+		dest := NewTestServer(t)
+		for _, key := range []string{"b", "c"} {
+			_, err := dest.Set(ctx, &v1alpha.SetRequest{Key: key, Value: []byte("dest-" + key)})
+			require.NoError(t, err)
+		}
+		return dest, NewTestAdminClient(t, dest)
+	}
+
+	t.Run("replace", func(t *testing.T) {
+		dest, admin := newDest()
+		res, err := restore(t, admin, v1alpha.RestoreMode_RESTORE_MODE_REPLACE, data)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), res.Keys)
+
+		keys := dest.store.List()
+		sort.Strings(keys)
+		assert.Equal(t, []string{"a", "b"}, keys)
+		value, _ := dest.store.Get("b")
+		assert.Equal(t, []byte("src-b"), value)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		dest, admin := newDest()
+		_, err := restore(t, admin, v1alpha.RestoreMode_RESTORE_MODE_MERGE, data)
+		require.NoError(t, err)
+
+		keys := dest.store.List()
+		sort.Strings(keys)
+		assert.Equal(t, []string{"a", "b", "c"}, keys)
+		value, _ := dest.store.Get("b")
+		assert.Equal(t, []byte("src-b"), value)
+	})
+}
+
+func TestAdmin_RestoreRejectsInvalidDumps(t *testing.T) {
+	ctx := context.Background()
+	src := NewTestServer(t)
+	_, err := src.Set(ctx, &v1alpha.SetRequest{Key: "a", Value: []byte("1")})
+	require.NoError(t, err)
+	data := backup(t, NewTestAdminClient(t, src))
+
+	dest := NewTestServer(t)
+	_, err = dest.Set(ctx, &v1alpha.SetRequest{Key: "keep", Value: []byte("1")})
+	require.NoError(t, err)
+	admin := NewTestAdminClient(t, dest)
+
+	corrupt := append([]byte(nil), data...)
+	corrupt[len(corrupt)-1] ^= 0xff
+	_, err = restore(t, admin, v1alpha.RestoreMode_RESTORE_MODE_REPLACE, corrupt)
+	assert.Equal(t, codes.DataLoss, status.Code(err))
+
+	_, err = restore(t, admin, v1alpha.RestoreMode_RESTORE_MODE_UNSPECIFIED, data)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	assert.Equal(t, []string{"keep"}, dest.store.List())
+}
+
+func TestAdmin_BackupSpansChunks(t *testing.T) {
+	ctx := context.Background()
+	src := NewTestServer(t)
+	value := make([]byte, streamChunkSize)
+	for i := range value {
+		value[i] = byte(rand.Uint32())
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		_, err := src.Set(ctx, &v1alpha.SetRequest{Key: key, Value: value})
+		require.NoError(t, err)
+	}
+
+	stream, err := NewTestAdminClient(t, src).Backup(ctx, &v1alpha.BackupRequest{})
+	require.NoError(t, err)
+	var data []byte
+	chunks := 0
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(chunk.Data), streamChunkSize)
+		data = append(data, chunk.Data...)
+		chunks++
+	}
+	assert.Greater(t, chunks, 3)
+
+	dest := NewTestServer(t)
+	res, err := restore(t, NewTestAdminClient(t, dest), v1alpha.RestoreMode_RESTORE_MODE_REPLACE, data)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), res.Keys)
+	got, err := dest.store.Get("b")
+	require.NoError(t, err)
+	assert.Equal(t, value, got)
+}
+
+func TestAdmin_RestoreTooLarge(t *testing.T) {
+	ctx := context.Background()
+	src := NewTestServer(t)
+	_, err := src.Set(ctx, &v1alpha.SetRequest{Key: "a", Value: []byte("1")})
+	require.NoError(t, err)
+	data := backup(t, NewTestAdminClient(t, src))
+
+	dest := NewTestServer(t)
+	dest.config.StoreConfig.MaxRestoreSize = int64(len(data) - 1)
+	_, err = restore(t, NewTestAdminClient(t, dest), v1alpha.RestoreMode_RESTORE_MODE_REPLACE, data)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Empty(t, dest.store.List())
+}
+
+func TestAdmin_StalledBackupDoesNotBlockSnapshots(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewTestServer(t)
+	value := make([]byte, streamChunkSize)
+	for i := range value {
+		value[i] = byte(rand.Uint32())
+	}
+	for i := range 16 {
+		_, err := s.Set(ctx, &v1alpha.SetRequest{Key: strconv.Itoa(i), Value: value})
+		require.NoError(t, err)
+	}
+
+	// Read one chunk and then stall, leaving the rest to flow control.
+	stream, err := NewTestAdminClient(t, s).Backup(ctx, &v1alpha.BackupRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range s.store.Snapshot() {
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("snapshot blocked by a stalled backup")
+	}
+}
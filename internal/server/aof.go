@@ -0,0 +1,684 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/patrostkowski/protocache/internal/keyring"
+	"github.com/patrostkowski/protocache/internal/logger"
+	"github.com/patrostkowski/protocache/internal/snapshot"
+	"github.com/patrostkowski/protocache/internal/store"
+	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+// The append-only file is a sequence of records:
+//
+//	length   uint32  payload length in bytes
+//	checksum uint32  CRC-32C of the payload
+//	payload  op byte, uvarint key length, key, op-specific data
+//
+// A set record continues with the uvarint entry version, its expiry, as
+// uvarint idle timeout in nanoseconds and varint stale and expiry times
+// in Unix nanoseconds (0 for none), followed by the value. Logs written
+// before versions or expiry were kept hold aofOpSetExpiry records without
+// the version or aofOpSetValue records with only the value; they are
+// still read, and their keys get new versions.
+//
+// With encryption enabled the payload is instead aofOpSealed followed by
+// the plain payload sealed with the keyring, see keyring.Seal.
+//
+// Replaying a log on top of any snapshot taken after the log was started
+// yields the same keyspace, because every record overwrites, removes or
+// clears whole keys, and expiry is kept as absolute times. Versions are
+// kept too, so etags handed out before a restart still match.
+type aofOp byte
+
+const (
+	aofOpSetValue aofOp = iota + 1
+	aofOpDelete
+	aofOpClear
+	aofOpSetExpiry
+	aofOpSet
+
+	aofOpSealed aofOp = 0x80
+)
+
+const (
+	aofRecordHeaderSize = 8
+	aofMaxRecordSize    = 1 << 30
+
+	// rewriteLogSuffix names the log that receives writes while a rewrite
+	// is writing the new snapshot.
+	rewriteLogSuffix = ".rewrite"
+)
+
+var (
+	errAOFCorrupt = errors.New("append-only file is corrupt")
+	errAOFNoKey   = errors.New("append-only file is encrypted but no encryption key is configured")
+)
+
+// appendOnlyFile logs every keyspace change. It is registered as a store
+// event handler before it is opened and ignores events until then, so
+// the restore in Init is not logged again.
+type appendOnlyFile struct {
+	mu     sync.Mutex
+	path   string
+	policy v1alpha.AppendFsyncPolicy
+	keys   *keyring.Keyring
+	// current is the log being appended to: path, or the rewrite log
+	// while a rewrite is in progress or after one failed to finish.
+	current string
+	f       *os.File
+	w       *bufio.Writer
+	size    int64
+	dirty   bool
+	buf     []byte
+	sealed  []byte
+
+	// rewriteMu serializes rewrites.
+	rewriteMu sync.Mutex
+}
+
+func newAppendOnlyFile() *appendOnlyFile {
+	return &appendOnlyFile{}
+}
+
+// encodeAOFRecord appends the record for op on r.Key to dst[:0]. Set
+// records also hold the value, version and expiry of r.
+func encodeAOFRecord(dst []byte, op aofOp, r store.Record) []byte {
+	dst = append(dst[:0], make([]byte, aofRecordHeaderSize)...)
+	dst = append(dst, byte(op))
+	dst = binary.AppendUvarint(dst, uint64(len(r.Key)))
+	dst = append(dst, r.Key...)
+	if op == aofOpSet {
+		dst = binary.AppendUvarint(dst, r.Version)
+		dst = binary.AppendUvarint(dst, uint64(r.IdleTimeout))
+		dst = binary.AppendVarint(dst, unixNanoOrZero(r.StaleAt))
+		dst = binary.AppendVarint(dst, unixNanoOrZero(r.ExpiresAt))
+		dst = append(dst, r.Value...)
+	}
+
+	payload := dst[aofRecordHeaderSize:]
+	binary.BigEndian.PutUint32(dst[0:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(dst[4:], crc32.Checksum(payload, crc32cTable))
+	return dst
+}
+
+func unixNanoOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func timeOrZero(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// sealAOFRecord appends record, as encoded by encodeAOFRecord, to dst[:0]
+// with its payload sealed.
+func sealAOFRecord(dst, record []byte, keys *keyring.Keyring) []byte {
+	dst = append(dst[:0], make([]byte, aofRecordHeaderSize)...)
+	dst = append(dst, byte(aofOpSealed))
+	dst = keys.Seal(dst, record[aofRecordHeaderSize:])
+
+	payload := dst[aofRecordHeaderSize:]
+	binary.BigEndian.PutUint32(dst[0:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(dst[4:], crc32.Checksum(payload, crc32cTable))
+	return dst
+}
+
+// decodeAOFPayload decodes a plain payload. The value of the returned
+// record aliases payload.
+func decodeAOFPayload(payload []byte) (aofOp, store.Record, error) {
+	if len(payload) == 0 {
+		return 0, store.Record{}, fmt.Errorf("%w: empty record", errAOFCorrupt)
+	}
+	op := aofOp(payload[0])
+	keyLen, n := binary.Uvarint(payload[1:])
+	if n <= 0 || keyLen > uint64(len(payload)-1-n) {
+		return 0, store.Record{}, fmt.Errorf("%w: bad key length", errAOFCorrupt)
+	}
+	rest := payload[1+n:]
+	r := store.Record{Key: string(rest[:keyLen])}
+	rest = rest[keyLen:]
+
+	switch op {
+	case aofOpSetValue:
+		r.Value = rest
+	case aofOpSetExpiry, aofOpSet:
+		if op == aofOpSet {
+			version, n := binary.Uvarint(rest)
+			if n <= 0 {
+				return 0, store.Record{}, fmt.Errorf("%w: bad version", errAOFCorrupt)
+			}
+			r.Version = version
+			rest = rest[n:]
+		}
+		idle, n := binary.Uvarint(rest)
+		if n <= 0 {
+			return 0, store.Record{}, fmt.Errorf("%w: bad idle timeout", errAOFCorrupt)
+		}
+		rest = rest[n:]
+		var times [2]int64
+		for i := range times {
+			if times[i], n = binary.Varint(rest); n <= 0 {
+				return 0, store.Record{}, fmt.Errorf("%w: bad expiry", errAOFCorrupt)
+			}
+			rest = rest[n:]
+		}
+		r.IdleTimeout = time.Duration(idle)
+		r.StaleAt = timeOrZero(times[0])
+		r.ExpiresAt = timeOrZero(times[1])
+		r.Value = rest
+	}
+	return op, r, nil
+}
+
+// record is registered as a store event handler. Expired and evicted
+// keys are logged as deletes.
+func (a *appendOnlyFile) record(e store.Event) {
+	var op aofOp
+	switch e.Type {
+	case store.EventSet:
+		op = aofOpSet
+	case store.EventDelete, store.EventExpire, store.EventEvict:
+		op = aofOpDelete
+	case store.EventClear:
+		op = aofOpClear
+	default:
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.w == nil {
+		return
+	}
+
+	r := store.Record{Key: e.Key, Value: e.Value}
+	if e.Entry != nil {
+		r.Version = e.Entry.Version
+		r.IdleTimeout = e.Entry.IdleTimeout
+		r.StaleAt = e.Entry.StaleAt
+		r.ExpiresAt = e.Entry.ExpiresAt
+	}
+	a.buf = encodeAOFRecord(a.buf, op, r)
+	rec := a.buf
+	if a.keys != nil {
+		a.sealed = sealAOFRecord(a.sealed, a.buf, a.keys)
+		rec = a.sealed
+	}
+	if _, err := a.w.Write(rec); err != nil {
+		a.writeFailed("write", err)
+		return
+	}
+	a.size += int64(len(rec))
+	a.dirty = true
+}
+
+// commit is registered as the store commit handler. With the always
+// policy it makes the records of a store call durable before the store
+// releases its lock and the request returns, with one fsync per call.
+func (a *appendOnlyFile) commit() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.policy == v1alpha.AppendFsyncAlways {
+		a.flushLocked(true)
+	}
+}
+
+func (a *appendOnlyFile) writeFailed(op string, err error) {
+	AOFWriteErrors.Inc()
+	logger.Error("Append-only file "+op+" failed", "path", a.path, "error", err)
+}
+
+func (a *appendOnlyFile) flushLocked(sync bool) error {
+	if a.w == nil || !a.dirty {
+		return nil
+	}
+	if err := a.w.Flush(); err != nil {
+		a.writeFailed("write", err)
+		return err
+	}
+	if sync {
+		if err := a.f.Sync(); err != nil {
+			a.writeFailed("sync", err)
+			return err
+		}
+	}
+	a.dirty = false
+	AOFSize.Set(float64(a.size))
+	return nil
+}
+
+// flush writes buffered records to the file, and syncs them unless the
+// policy is never.
+func (a *appendOnlyFile) flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.flushLocked(a.policy != v1alpha.AppendFsyncNever)
+}
+
+func (a *appendOnlyFile) currentSize() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.size
+}
+
+func openAppendLog(path string) (*os.File, int64, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, 0, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, fi.Size(), nil
+}
+
+// switchLocked makes f, opened from path, the file records are appended
+// to.
+func (a *appendOnlyFile) switchLocked(path string, f *os.File, size int64) {
+	a.current = path
+	a.f = f
+	a.w = bufio.NewWriter(f)
+	a.size = size
+	a.dirty = false
+	AOFSize.Set(float64(size))
+}
+
+func (a *appendOnlyFile) open(path string, policy v1alpha.AppendFsyncPolicy, keys *keyring.Keyring) error {
+	f, size, err := openAppendLog(path)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.path = path
+	a.policy = policy
+	a.keys = keys
+	a.switchLocked(path, f, size)
+	return nil
+}
+
+func (a *appendOnlyFile) close() error {
+	a.rewriteMu.Lock()
+	defer a.rewriteMu.Unlock()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.w == nil {
+		return nil
+	}
+	err := a.flushLocked(true)
+	if closeErr := a.f.Close(); err == nil {
+		err = closeErr
+	}
+	a.f, a.w = nil, nil
+	return err
+}
+
+// replayAOF applies the records in path to st, opening sealed records
+// with keys. A record torn by a crash at the end of the log is discarded
+// and, if truncate is set, cut off so that new records follow the last
+// complete one. outdated reports records that keys would not write: plain
+// ones while encryption is enabled, or ones sealed with a retired key.
+func replayAOF(path string, st store.Store, truncate bool, keys *keyring.Keyring) (applied int, outdated bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	now := time.Now()
+	header := make([]byte, aofRecordHeaderSize)
+	var payload, opened []byte
+	var offset int64
+	for {
+		torn, err := readAOFRecord(r, header, &payload)
+		if err == io.EOF {
+			return applied, outdated, nil
+		}
+		if torn {
+			logger.Warn("Discarding incomplete record at the end of the append-only file",
+				"path", path, "offset", offset, "error", err)
+			if truncate {
+				return applied, outdated, os.Truncate(path, offset)
+			}
+			return applied, outdated, nil
+		}
+		if err != nil {
+			return applied, outdated, fmt.Errorf("%s at offset %d: %w", path, offset, err)
+		}
+
+		plain := payload
+		if len(payload) > 0 && aofOp(payload[0]) == aofOpSealed {
+			if keys == nil {
+				return applied, outdated, fmt.Errorf("%s: %w", path, errAOFNoKey)
+			}
+			opened, err = keys.Open(opened[:0], payload[1:])
+			if errors.Is(err, keyring.ErrDecrypt) {
+				err = fmt.Errorf("%w: %v", errAOFCorrupt, err)
+			}
+			if err != nil {
+				return applied, outdated, fmt.Errorf("%s at offset %d: %w", path, offset, err)
+			}
+			plain = opened
+			outdated = outdated || binary.BigEndian.Uint32(payload[1:]) != keys.Primary().ID
+		} else {
+			outdated = outdated || keys != nil
+		}
+
+		op, rec, err := decodeAOFPayload(plain)
+		if err != nil {
+			return applied, outdated, fmt.Errorf("%s at offset %d: %w", path, offset, err)
+		}
+		switch op {
+		case aofOpSetValue, aofOpSetExpiry, aofOpSet:
+			err = replayAOFSet(st, rec, now)
+		case aofOpDelete:
+			err = replayAOFDelete(st, rec.Key)
+		case aofOpClear:
+			st.Clear()
+		default:
+			err = fmt.Errorf("%w: unknown op %d", errAOFCorrupt, op)
+		}
+		if err != nil {
+			return applied, outdated, fmt.Errorf("%s at offset %d: %w", path, offset, err)
+		}
+		offset += int64(aofRecordHeaderSize + len(payload))
+		applied++
+	}
+}
+
+// replayAOFSet restores a logged set with its version and expiry. A key
+// whose entry has expired since is removed, so that it does not fall back
+// to an older value from the snapshot. The logged version is kept even if
+// the snapshot already holds it: both come from the same store, so it
+// still belongs to this write.
+func replayAOFSet(st store.Store, rec store.Record, now time.Time) error {
+	if !rec.ExpiresAt.IsZero() && !now.Before(rec.ExpiresAt) {
+		return replayAOFDelete(st, rec.Key)
+	}
+	rec.Value = bytes.Clone(rec.Value)
+	return st.Replay([]store.Record{rec})
+}
+
+func replayAOFDelete(st store.Store, key string) error {
+	if err := st.Delete(key); err != nil && !errors.Is(err, store.StoreErrorKeyNotFound) {
+		return err
+	}
+	return nil
+}
+
+// readAOFRecord reads the next record into payload. torn reports that the
+// record is incomplete or corrupt and is the last one in the log.
+func readAOFRecord(r *bufio.Reader, header []byte, payload *[]byte) (torn bool, err error) {
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF {
+			return false, io.EOF
+		}
+		return true, err
+	}
+	length := binary.BigEndian.Uint32(header[0:])
+	checksum := binary.BigEndian.Uint32(header[4:])
+	if length > aofMaxRecordSize {
+		return atEOF(r), fmt.Errorf("%w: record of %d bytes", errAOFCorrupt, length)
+	}
+
+	*payload = append((*payload)[:0], make([]byte, length)...)
+	if _, err := io.ReadFull(r, *payload); err != nil {
+		return true, err
+	}
+	if crc32.Checksum(*payload, crc32cTable) != checksum {
+		return atEOF(r), fmt.Errorf("%w: checksum mismatch", errAOFCorrupt)
+	}
+	return false, nil
+}
+
+func atEOF(r *bufio.Reader) bool {
+	_, err := r.Peek(1)
+	return err == io.EOF
+}
+
+// initAppendOnly replays the append-only file on top of the restored
+// snapshot and opens it for appending.
+func (s *Server) initAppendOnly() error {
+	cfg := s.config.GetAppendOnlyConfig()
+	if cfg == nil {
+		return nil
+	}
+	if !s.config.IsMemoryStoreDumpEnabled() {
+		return errors.New("append_only requires dump_enabled")
+	}
+	switch cfg.Fsync {
+	case v1alpha.AppendFsyncAlways, v1alpha.AppendFsyncEverySec, v1alpha.AppendFsyncNever:
+	default:
+		return fmt.Errorf("unknown append_only fsync policy %q", cfg.Fsync)
+	}
+
+	applied, outdated, err := replayAOF(cfg.Path, s.store, true, s.keys)
+	if err != nil {
+		return err
+	}
+
+	// A rewrite interrupted by a crash leaves its log behind. Replay it
+	// too and fold both logs into a fresh snapshot. Logs with records
+	// that are not sealed with the current key are folded the same way,
+	// so that no plaintext or retired key stays in use.
+	rewritePath := cfg.Path + rewriteLogSuffix
+	n, rewriteOutdated, err := replayAOF(rewritePath, s.store, false, s.keys)
+	if err != nil {
+		return err
+	}
+	applied += n
+	_, statErr := os.Stat(rewritePath)
+	if interrupted := statErr == nil; interrupted || outdated || rewriteOutdated {
+		reason := "aof-recovery"
+		if !interrupted {
+			reason = "aof-reencrypt"
+			logger.Info("Append-only file has records not sealed with the current key, rewriting it", "path", cfg.Path)
+		}
+		if err := s.Snapshot(reason); err != nil {
+			return err
+		}
+		if err := os.Remove(rewritePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err := os.Truncate(cfg.Path, 0); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	if err := s.aof.open(cfg.Path, cfg.Fsync, s.keys); err != nil {
+		return err
+	}
+	logger.Info("Append-only file opened", "path", cfg.Path, "fsync", cfg.Fsync, "replayed", applied)
+	return nil
+}
+
+// RewriteAppendOnlyFile compacts the append-only file into a fresh
+// snapshot and an empty log. Writes made during the rewrite go to a
+// second log, which replaces the old one once the snapshot is written.
+func (s *Server) RewriteAppendOnlyFile() error {
+	a := s.aof
+	a.rewriteMu.Lock()
+	defer a.rewriteMu.Unlock()
+
+	rewritePath, err := a.startRewrite()
+	if err != nil {
+		return err
+	}
+
+	if err := s.Snapshot("aof-rewrite"); err != nil {
+		if abortErr := a.abortRewrite(rewritePath); abortErr != nil {
+			logger.Error("Failed to abort append-only file rewrite", "error", abortErr)
+		}
+		return err
+	}
+
+	if err := a.finishRewrite(rewritePath); err != nil {
+		return err
+	}
+	AOFRewrites.Inc()
+	logger.Info("Append-only file rewritten", "path", a.path)
+	return nil
+}
+
+// startRewrite makes the log durable and switches new records to the
+// rewrite log. Every record in the old log is then covered by the
+// snapshot the rewrite takes next.
+func (a *appendOnlyFile) startRewrite() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.w == nil {
+		return "", errors.New("append-only file is not open")
+	}
+	if err := a.flushLocked(true); err != nil {
+		return "", err
+	}
+
+	rewritePath := a.path + rewriteLogSuffix
+	if a.current == rewritePath {
+		// A previous rewrite could not replace the old log; its records
+		// are all covered by the next snapshot as well.
+		return rewritePath, nil
+	}
+	f, size, err := openAppendLog(rewritePath)
+	if err != nil {
+		return "", err
+	}
+	if err := a.f.Close(); err != nil {
+		f.Close()
+		return "", err
+	}
+	a.switchLocked(rewritePath, f, size)
+	return rewritePath, nil
+}
+
+// finishRewrite replaces the old log with the rewrite log.
+func (a *appendOnlyFile) finishRewrite(rewritePath string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.flushLocked(true); err != nil {
+		return err
+	}
+	err := a.f.Close()
+	a.f, a.w = nil, nil
+	if err != nil {
+		return a.reopenLocked(rewritePath, err)
+	}
+
+	if err := os.Rename(rewritePath, a.path); err != nil {
+		return a.reopenLocked(rewritePath, err)
+	}
+	if err := snapshot.SyncDir(filepath.Dir(a.path)); err != nil {
+		logger.Warn("Failed to sync append-only file directory", "error", err)
+	}
+	return a.reopenLocked(a.path, nil)
+}
+
+// abortRewrite appends the records written during a failed rewrite to
+// the old log and switches back to it.
+func (a *appendOnlyFile) abortRewrite(rewritePath string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.flushLocked(true); err != nil {
+		return err
+	}
+	err := a.f.Close()
+	a.f, a.w = nil, nil
+	if err != nil {
+		return a.reopenLocked(rewritePath, err)
+	}
+
+	src, err := os.Open(rewritePath)
+	if err != nil {
+		return a.reopenLocked(rewritePath, err)
+	}
+	defer src.Close()
+	dst, size, err := openAppendLog(a.path)
+	if err != nil {
+		return a.reopenLocked(rewritePath, err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return a.reopenLocked(rewritePath, err)
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return a.reopenLocked(rewritePath, err)
+	}
+	fi, err := dst.Stat()
+	if err == nil {
+		size = fi.Size()
+	}
+	a.switchLocked(a.path, dst, size)
+	return os.Remove(rewritePath)
+}
+
+// reopenLocked resumes appending to path after cause, keeping the log
+// usable when a rewrite step fails.
+func (a *appendOnlyFile) reopenLocked(path string, cause error) error {
+	f, size, err := openAppendLog(path)
+	if err != nil {
+		a.writeFailed("reopen", err)
+		return errors.Join(cause, err)
+	}
+	a.switchLocked(path, f, size)
+	return cause
+}
+
+// runAOFLoop flushes the append-only file every second and rewrites it
+// once it grows past the configured size, until ctx is cancelled.
+func (s *Server) runAOFLoop(ctx context.Context) {
+	cfg := s.config.GetAppendOnlyConfig()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		_ = s.aof.flush()
+		if s.aof.currentSize() >= cfg.RewriteSize {
+			_ = s.RewriteAppendOnlyFile()
+		}
+	}
+}
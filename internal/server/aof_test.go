@@ -0,0 +1,391 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/patrostkowski/protocache/internal/config"
+	"github.com/patrostkowski/protocache/internal/snapshot"
+	"github.com/patrostkowski/protocache/internal/store"
+	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+func aofConfig(t *testing.T, policy v1alpha.AppendFsyncPolicy) *config.Config {
+	dir := t.TempDir()
+	cfg := defaultConfig(dir)
+	cfg.StoreConfig.AppendOnly = &v1alpha.AppendOnlyConfig{
+		Enabled: true,
+		Path:    filepath.Join(dir, "appendonly.aof"),
+		Fsync:   policy,
+	}
+	return cfg
+}
+
+// restartAOF simulates a crash followed by a restart: the log is closed
+// without a final snapshot and a new server restores from disk.
+func restartAOF(t *testing.T, s *Server) *Server {
+	t.Helper()
+	require.NoError(t, s.aof.close())
+
+	s2 := NewServer(s.config, DefaultPrometheusRegistry())
+	require.NoError(t, s2.initEncryption())
+	require.NoError(t, s2.ReadPersistedMemoryStore())
+	require.NoError(t, s2.initAppendOnly())
+	t.Cleanup(func() { _ = s2.aof.close() })
+	return s2
+}
+
+func TestAOF_Replay(t *testing.T) {
+	for _, policy := range []v1alpha.AppendFsyncPolicy{
+		v1alpha.AppendFsyncAlways,
+		v1alpha.AppendFsyncEverySec,
+		v1alpha.AppendFsyncNever,
+	} {
+		t.Run(string(policy), func(t *testing.T) {
+			ctx := context.Background()
+			s := NewServer(aofConfig(t, policy), DefaultPrometheusRegistry())
+			require.NoError(t, s.initAppendOnly())
+
+			_, err := s.Set(ctx, &v1alpha.SetRequest{Key: "a", Value: []byte("1")})
+			require.NoError(t, err)
+			_, err = s.Clear(ctx, &v1alpha.ClearRequest{})
+			require.NoError(t, err)
+			_, err = s.Set(ctx, &v1alpha.SetRequest{Key: "b", Value: []byte("2")})
+			require.NoError(t, err)
+			_, err = s.Set(ctx, &v1alpha.SetRequest{Key: "c", Value: []byte("3")})
+			require.NoError(t, err)
+			_, err = s.Delete(ctx, &v1alpha.DeleteRequest{Key: "c"})
+			require.NoError(t, err)
+			_, err = s.Incr(ctx, &v1alpha.IncrRequest{Key: "n", Delta: 5})
+			require.NoError(t, err)
+
+			s2 := restartAOF(t, s)
+			assert.ElementsMatch(t, []string{"b", "n"}, s2.store.List())
+			resp, err := s2.Get(ctx, &v1alpha.GetRequest{Key: "n"})
+			require.NoError(t, err)
+			assert.Equal(t, []byte("5"), resp.Value)
+		})
+	}
+}
+
+func TestAOF_ReplayOnTopOfSnapshot(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(aofConfig(t, v1alpha.AppendFsyncAlways), DefaultPrometheusRegistry())
+	require.NoError(t, s.initAppendOnly())
+
+	_, err := s.Set(ctx, &v1alpha.SetRequest{Key: "a", Value: []byte("old")})
+	require.NoError(t, err)
+	require.NoError(t, s.Snapshot("test"))
+	_, err = s.Set(ctx, &v1alpha.SetRequest{Key: "a", Value: []byte("new")})
+	require.NoError(t, err)
+
+	s2 := restartAOF(t, s)
+	resp, err := s2.Get(ctx, &v1alpha.GetRequest{Key: "a"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new"), resp.Value)
+}
+
+func TestAOF_KeepsExpiry(t *testing.T) {
+	for name, snapshotFirst := range map[string]bool{"log only": false, "after snapshot": true} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := NewServer(aofConfig(t, v1alpha.AppendFsyncAlways), DefaultPrometheusRegistry())
+			require.NoError(t, s.initAppendOnly())
+
+			if snapshotFirst {
+				_, err := s.Set(ctx, &v1alpha.SetRequest{Key: "a", Value: []byte("old")})
+				require.NoError(t, err)
+				require.NoError(t, s.Snapshot("test"))
+			}
+			_, err := s.Set(ctx, &v1alpha.SetRequest{
+				Key:         "a",
+				Value:       []byte("new"),
+				IdleTimeout: durationpb.New(time.Hour),
+				SoftTtl:     durationpb.New(time.Minute),
+				HardTtl:     durationpb.New(2 * time.Hour),
+			})
+			require.NoError(t, err)
+			want, err := s.store.GetEntry("a")
+			require.NoError(t, err)
+
+			s2 := restartAOF(t, s)
+			got, err := s2.store.GetEntry("a")
+			require.NoError(t, err)
+			assert.Equal(t, []byte("new"), got.Value)
+			assert.Equal(t, time.Hour, got.IdleTimeout)
+			assert.True(t, want.StaleAt.Equal(got.StaleAt))
+			assert.True(t, want.ExpiresAt.Equal(got.ExpiresAt))
+		})
+	}
+}
+
+func TestAOF_KeepsVersions(t *testing.T) {
+	for name, snapshotFirst := range map[string]bool{"log only": false, "after snapshot": true} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := NewServer(aofConfig(t, v1alpha.AppendFsyncAlways), DefaultPrometheusRegistry())
+			require.NoError(t, s.initAppendOnly())
+
+			// Move the version counter ahead of what replaying the sets
+			// alone would hand out.
+			for range 3 {
+				_, err := s.Incr(ctx, &v1alpha.IncrRequest{Key: "n", Delta: 1})
+				require.NoError(t, err)
+			}
+			_, err := s.Delete(ctx, &v1alpha.DeleteRequest{Key: "n"})
+			require.NoError(t, err)
+			for _, key := range []string{"a", "b"} {
+				_, err := s.Set(ctx, &v1alpha.SetRequest{Key: key, Value: []byte(key)})
+				require.NoError(t, err)
+			}
+			if snapshotFirst {
+				require.NoError(t, s.Snapshot("test"))
+			}
+			_, err = s.Set(ctx, &v1alpha.SetRequest{Key: "c", Value: []byte("c")})
+			require.NoError(t, err)
+
+			seen := map[string]*v1alpha.GetResponse{}
+			for _, key := range []string{"a", "b", "c"} {
+				resp, err := s.Get(ctx, &v1alpha.GetRequest{Key: key})
+				require.NoError(t, err)
+				seen[key] = resp
+			}
+
+			s2 := restartAOF(t, s)
+			for key, want := range seen {
+				resp, err := s2.Get(ctx, &v1alpha.GetRequest{Key: key, IfNoneMatch: want.Etag})
+				require.NoError(t, err)
+				assert.True(t, resp.NotModified, key)
+				assert.Equal(t, want.Version, resp.Version, key)
+			}
+		})
+	}
+}
+
+func TestAOF_ExpiredSetRemovesSnapshotValue(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(aofConfig(t, v1alpha.AppendFsyncAlways), DefaultPrometheusRegistry())
+	require.NoError(t, s.initAppendOnly())
+
+	_, err := s.Set(ctx, &v1alpha.SetRequest{Key: "a", Value: []byte("old")})
+	require.NoError(t, err)
+	require.NoError(t, s.Snapshot("test"))
+	_, err = s.Set(ctx, &v1alpha.SetRequest{Key: "a", Value: []byte("new"), HardTtl: durationpb.New(time.Millisecond)})
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	s2 := restartAOF(t, s)
+	_, err = s2.store.Get("a")
+	assert.ErrorIs(t, err, store.StoreErrorKeyNotFound)
+}
+
+func TestAOF_ReadsValueOnlyRecords(t *testing.T) {
+	cfg := aofConfig(t, v1alpha.AppendFsyncAlways)
+	path := cfg.GetAppendOnlyConfig().Path
+
+	// Logs written before expiry was kept hold the key and value only.
+	payload := append([]byte{byte(aofOpSetValue), 1}, "a1"...)
+	rec := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	rec = binary.BigEndian.AppendUint32(rec, crc32.Checksum(payload, crc32cTable))
+	require.NoError(t, os.WriteFile(path, append(rec, payload...), 0o600))
+
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+	require.NoError(t, s.initAppendOnly())
+	t.Cleanup(func() { _ = s.aof.close() })
+	value, err := s.store.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), value)
+}
+
+func TestAOF_TornTailIsTruncated(t *testing.T) {
+	ctx := context.Background()
+	cfg := aofConfig(t, v1alpha.AppendFsyncAlways)
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+	require.NoError(t, s.initAppendOnly())
+
+	_, err := s.Set(ctx, &v1alpha.SetRequest{Key: "a", Value: []byte("1")})
+	require.NoError(t, err)
+	_, err = s.Set(ctx, &v1alpha.SetRequest{Key: "b", Value: []byte("2")})
+	require.NoError(t, err)
+	require.NoError(t, s.aof.close())
+
+	path := cfg.StoreConfig.AppendOnly.Path
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, fi.Size()-1))
+
+	s2 := restartAOF(t, s)
+	assert.Equal(t, []string{"a"}, s2.store.List())
+
+	// New records follow the last complete one.
+	_, err = s2.Set(ctx, &v1alpha.SetRequest{Key: "c", Value: []byte("3")})
+	require.NoError(t, err)
+	s3 := restartAOF(t, s2)
+	assert.ElementsMatch(t, []string{"a", "c"}, s3.store.List())
+}
+
+func TestAOF_CorruptRecord(t *testing.T) {
+	ctx := context.Background()
+	cfg := aofConfig(t, v1alpha.AppendFsyncAlways)
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+	require.NoError(t, s.initAppendOnly())
+
+	_, err := s.Set(ctx, &v1alpha.SetRequest{Key: "a", Value: []byte("1")})
+	require.NoError(t, err)
+	_, err = s.Set(ctx, &v1alpha.SetRequest{Key: "b", Value: []byte("2")})
+	require.NoError(t, err)
+	require.NoError(t, s.aof.close())
+
+	path := cfg.StoreConfig.AppendOnly.Path
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	raw[aofRecordHeaderSize+2] ^= 0xff
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+
+	s2 := NewServer(cfg, DefaultPrometheusRegistry())
+	assert.ErrorIs(t, s2.initAppendOnly(), errAOFCorrupt)
+}
+
+func TestAOF_Rewrite(t *testing.T) {
+	ctx := context.Background()
+	cfg := aofConfig(t, v1alpha.AppendFsyncEverySec)
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+	require.NoError(t, s.initAppendOnly())
+
+	for i := 0; i < 100; i++ {
+		_, err := s.Set(ctx, &v1alpha.SetRequest{Key: "k", Value: []byte(fmt.Sprint(i))})
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.aof.flush())
+	before := s.aof.currentSize()
+
+	require.NoError(t, s.RewriteAppendOnlyFile())
+	assert.Zero(t, s.aof.currentSize())
+	assert.Less(t, s.aof.currentSize(), before)
+
+	path := cfg.StoreConfig.AppendOnly.Path
+	_, err := os.Stat(path + rewriteLogSuffix)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = s.Set(ctx, &v1alpha.SetRequest{Key: "after", Value: []byte("x")})
+	require.NoError(t, err)
+
+	s2 := restartAOF(t, s)
+	assert.ElementsMatch(t, []string{"k", "after"}, s2.store.List())
+	resp, err := s2.Get(ctx, &v1alpha.GetRequest{Key: "k"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("99"), resp.Value)
+}
+
+func TestAOF_RecoversInterruptedRewrite(t *testing.T) {
+	ctx := context.Background()
+	cfg := aofConfig(t, v1alpha.AppendFsyncAlways)
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+	require.NoError(t, s.initAppendOnly())
+
+	_, err := s.Set(ctx, &v1alpha.SetRequest{Key: "a", Value: []byte("1")})
+	require.NoError(t, err)
+
+	// Crash after the rewrite log was started but before the snapshot.
+	_, err = s.aof.startRewrite()
+	require.NoError(t, err)
+	_, err = s.Set(ctx, &v1alpha.SetRequest{Key: "b", Value: []byte("2")})
+	require.NoError(t, err)
+
+	s2 := restartAOF(t, s)
+	assert.ElementsMatch(t, []string{"a", "b"}, s2.store.List())
+
+	path := cfg.StoreConfig.AppendOnly.Path
+	_, err = os.Stat(path + rewriteLogSuffix)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Zero(t, fi.Size())
+}
+
+func TestAOF_RequiresDump(t *testing.T) {
+	cfg := aofConfig(t, v1alpha.AppendFsyncAlways)
+	cfg.StoreConfig.DumpEnabled = false
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+	assert.Error(t, s.initAppendOnly())
+}
+
+func TestAOF_Encrypted(t *testing.T) {
+	ctx := context.Background()
+	cfg := aofConfig(t, v1alpha.AppendFsyncAlways)
+	withEncryption(t, cfg, 1)
+	s := newEncryptedServer(t, cfg)
+	require.NoError(t, s.initAppendOnly())
+
+	_, err := s.Set(ctx, &v1alpha.SetRequest{Key: "ssn", Value: []byte("078-05-1120")})
+	require.NoError(t, err)
+	_, err = s.Delete(ctx, &v1alpha.DeleteRequest{Key: "ssn"})
+	require.NoError(t, err)
+	_, err = s.Set(ctx, &v1alpha.SetRequest{Key: "card", Value: []byte("4111-1111")})
+	require.NoError(t, err)
+	require.NoError(t, s.aof.close())
+
+	raw, err := os.ReadFile(cfg.StoreConfig.AppendOnly.Path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "ssn")
+	assert.NotContains(t, string(raw), "4111")
+
+	s2 := restartAOF(t, s)
+	assert.Equal(t, []string{"card"}, s2.store.List())
+
+	// Without the key the log cannot be replayed.
+	cfg.StoreConfig.Encryption = nil
+	s3 := NewServer(cfg, DefaultPrometheusRegistry())
+	assert.ErrorIs(t, s3.initAppendOnly(), errAOFNoKey)
+}
+
+func TestAOF_EncryptsPlaintextLog(t *testing.T) {
+	ctx := context.Background()
+	cfg := aofConfig(t, v1alpha.AppendFsyncAlways)
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+	require.NoError(t, s.initAppendOnly())
+	_, err := s.Set(ctx, &v1alpha.SetRequest{Key: "foo", Value: []byte("plain")})
+	require.NoError(t, err)
+
+	// The plaintext log is folded into an encrypted snapshot and emptied.
+	withEncryption(t, cfg, 1)
+	s2 := restartAOF(t, s)
+	fi, err := os.Stat(cfg.StoreConfig.AppendOnly.Path)
+	require.NoError(t, err)
+	assert.Zero(t, fi.Size())
+	_, info, err := snapshot.ReadFile(cfg.MemoryDumpFileFullPath(), s2.keys)
+	require.NoError(t, err)
+	assert.True(t, info.Encrypted)
+
+	_, err = s2.Set(ctx, &v1alpha.SetRequest{Key: "bar", Value: []byte("sealed")})
+	require.NoError(t, err)
+
+	// A rotated key keeps replaying records sealed with the old one.
+	withEncryption(t, cfg, 2, 1)
+	s3 := restartAOF(t, s2)
+	assert.ElementsMatch(t, []string{"foo", "bar"}, s3.store.List())
+}
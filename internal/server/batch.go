@@ -0,0 +1,119 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/status"
+
+	"github.com/patrostkowski/protocache/internal/logger"
+	"github.com/patrostkowski/protocache/internal/store"
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+// MGet looks up all keys in one store pass. Stale entries are reported
+// like in Get, so the first caller to observe one is told to refresh it.
+// Misses are reported per key and are not filled through the read-through
+// loader.
+func (s *Server) MGet(ctx context.Context, req *cachev1alpha.MGetRequest) (*cachev1alpha.MGetResponse, error) {
+	now := time.Now()
+	entries := s.store.GetMany(req.Keys)
+
+	results := make([]*cachev1alpha.MGetResult, len(req.Keys))
+	for i, key := range req.Keys {
+		res := &cachev1alpha.MGetResult{Key: key}
+		if e := entries[i]; e != nil {
+			CacheHits.Inc()
+			res.Found = true
+			res.Value = e.Value
+			if e.Stale(now) {
+				StaleHits.Inc()
+				res.Stale = true
+				res.Refresh = e.ClaimRefresh()
+			}
+		} else {
+			CacheMisses.Inc()
+		}
+		results[i] = res
+	}
+	return &cachev1alpha.MGetResponse{Results: results}, nil
+}
+
+// MSet validates every entry and applies the valid ones in one store pass.
+// Invalid entries are reported per key and do not fail the batch.
+func (s *Server) MSet(ctx context.Context, req *cachev1alpha.MSetRequest) (*cachev1alpha.MSetResponse, error) {
+	results := make([]*cachev1alpha.MSetResult, len(req.Entries))
+	items := make([]store.Item, 0, len(req.Entries))
+	for i, entry := range req.Entries {
+		key := entry.GetKey()
+		results[i] = &cachev1alpha.MSetResult{Key: key}
+		if key == "" {
+			results[i].Message = "key must not be empty"
+			continue
+		}
+		if err := s.checkValueSize(len(entry.Value)); err != nil {
+			results[i].Message = status.Convert(err).Message()
+			continue
+		}
+		opts, err := setOptionsFromRequest(entry)
+		if err != nil {
+			results[i].Message = status.Convert(err).Message()
+			continue
+		}
+		items = append(items, store.Item{Key: key, Value: entry.Value, Options: opts})
+	}
+
+	for _, item := range items {
+		s.leases.invalidate(item.Key)
+	}
+	if err := s.store.SetMany(items); err != nil {
+		logger.Error("Failed to set keys in store", "count", len(items), "error", err)
+		for _, res := range results {
+			if res.Message == "" {
+				res.Message = "could not set key"
+			}
+		}
+		return &cachev1alpha.MSetResponse{Results: results}, nil
+	}
+
+	for _, res := range results {
+		if res.Message == "" {
+			res.Success = true
+			res.Message = "OK"
+		}
+	}
+	return &cachev1alpha.MSetResponse{Results: results}, nil
+}
+
+// MDelete removes all keys in one store pass and reports which existed.
+func (s *Server) MDelete(ctx context.Context, req *cachev1alpha.MDeleteRequest) (*cachev1alpha.MDeleteResponse, error) {
+	deleted := s.deleteKeys(req.Keys)
+
+	results := make([]*cachev1alpha.MDeleteResult, len(req.Keys))
+	for i, key := range req.Keys {
+		results[i] = &cachev1alpha.MDeleteResult{Key: key, Deleted: deleted[i]}
+	}
+	return &cachev1alpha.MDeleteResponse{Results: results}, nil
+}
+
+// deleteKeys removes keys in one store pass and reports which existed.
+func (s *Server) deleteKeys(keys []string) []bool {
+	for _, key := range keys {
+		s.leases.invalidate(key)
+	}
+	return s.store.DeleteMany(keys)
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+func TestMSetMGetMDelete(t *testing.T) {
+	s := NewTestServer(t)
+	ctx := context.Background()
+
+	setRes, err := s.MSet(ctx, &cachev1alpha.MSetRequest{Entries: []*cachev1alpha.SetRequest{
+		{Key: "a", Value: []byte("1")},
+		{Key: "", Value: []byte("x")},
+		{Key: "b", Value: []byte("2"), IdleTimeout: durationpb.New(-1)},
+		{Key: "c", Value: []byte("3")},
+	}})
+	require.NoError(t, err)
+	require.Len(t, setRes.Results, 4)
+	assert.True(t, setRes.Results[0].Success)
+	assert.False(t, setRes.Results[1].Success)
+	assert.False(t, setRes.Results[2].Success)
+	assert.Contains(t, setRes.Results[2].Message, "idle_timeout")
+	assert.True(t, setRes.Results[3].Success)
+
+	getRes, err := s.MGet(ctx, &cachev1alpha.MGetRequest{Keys: []string{"a", "b", "c"}})
+	require.NoError(t, err)
+	require.Len(t, getRes.Results, 3)
+	assert.Equal(t, "a", getRes.Results[0].Key)
+	assert.True(t, getRes.Results[0].Found)
+	assert.Equal(t, []byte("1"), getRes.Results[0].Value)
+	assert.False(t, getRes.Results[1].Found)
+	assert.Equal(t, []byte("3"), getRes.Results[2].Value)
+
+	delRes, err := s.MDelete(ctx, &cachev1alpha.MDeleteRequest{Keys: []string{"a", "b"}})
+	require.NoError(t, err)
+	require.Len(t, delRes.Results, 2)
+	assert.True(t, delRes.Results[0].Deleted)
+	assert.False(t, delRes.Results[1].Deleted)
+
+	assert.Equal(t, []string{"c"}, s.store.List())
+}
+
+func TestMGetClaimsRefresh(t *testing.T) {
+	s := NewTestServer(t)
+	ctx := context.Background()
+
+	_, err := s.Set(ctx, &cachev1alpha.SetRequest{
+		Key:     "hot",
+		Value:   []byte("v1"),
+		SoftTtl: durationpb.New(time.Millisecond),
+		HardTtl: durationpb.New(time.Minute),
+	})
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	res, err := s.MGet(ctx, &cachev1alpha.MGetRequest{Keys: []string{"hot"}})
+	require.NoError(t, err)
+	assert.True(t, res.Results[0].Stale)
+	assert.True(t, res.Results[0].Refresh)
+
+	res, err = s.MGet(ctx, &cachev1alpha.MGetRequest{Keys: []string{"hot"}})
+	require.NoError(t, err)
+	assert.True(t, res.Results[0].Stale)
+	assert.False(t, res.Results[0].Refresh, "only the first caller should be told to refresh")
+
+	got, err := s.Get(ctx, &cachev1alpha.GetRequest{Key: "hot"})
+	require.NoError(t, err)
+	assert.False(t, got.Refresh, "MGet and Get share the refresh claim")
+}
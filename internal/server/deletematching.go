@@ -0,0 +1,94 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/patrostkowski/protocache/internal/glob"
+	"github.com/patrostkowski/protocache/internal/logger"
+	"github.com/patrostkowski/protocache/internal/store"
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+// deleteMatchingBatchSize bounds how many keys are removed per store lock
+// acquisition, so that large deletes do not stall other requests.
+const deleteMatchingBatchSize = 1000
+
+// DeleteMatching removes every key with the given prefix or matching the
+// given pattern. The store is scanned in chunks, so it is never locked
+// for more than a chunk of keys and snapshots are not waited for, and
+// matches are deleted in batches as they are found. A key is only deleted if it still holds the
+// entry the scan saw; keys written after the call started are left alone.
+func (s *Server) DeleteMatching(ctx context.Context, req *cachev1alpha.DeleteMatchingRequest) (*cachev1alpha.DeleteMatchingResponse, error) {
+	if (req.Prefix == "") == (req.Pattern == "") {
+		return nil, status.Error(codes.InvalidArgument, "exactly one of prefix and pattern must be set")
+	}
+
+	match := func(key string) bool { return strings.HasPrefix(key, req.Prefix) }
+	if req.Pattern != "" {
+		match = func(key string) bool { return glob.Match(req.Pattern, key) }
+	}
+
+	var count uint64
+	batch := make([]store.Record, 0, deleteMatchingBatchSize)
+	flush := func() {
+		if req.DryRun {
+			count += uint64(len(batch))
+		} else {
+			for _, deleted := range s.deleteUnchanged(batch) {
+				if deleted {
+					count++
+				}
+			}
+		}
+		batch = batch[:0]
+	}
+
+	for r := range s.store.Scan() {
+		if !match(r.Key) {
+			continue
+		}
+		batch = append(batch, r)
+		if len(batch) < deleteMatchingBatchSize {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			logger.Warn("Matching delete interrupted", "prefix", req.Prefix, "pattern", req.Pattern, "deleted", count)
+			return nil, status.FromContextError(err).Err()
+		}
+		flush()
+	}
+	flush()
+
+	if req.DryRun {
+		return &cachev1alpha.DeleteMatchingResponse{Count: count, DryRun: true}, nil
+	}
+	logger.Info("Deleted matching keys", "prefix", req.Prefix, "pattern", req.Pattern, "count", count)
+	return &cachev1alpha.DeleteMatchingResponse{Count: count}, nil
+}
+
+// deleteUnchanged removes the keys of records that still hold the
+// recorded version and reports which were removed.
+func (s *Server) deleteUnchanged(records []store.Record) []bool {
+	for _, r := range records {
+		s.leases.invalidate(r.Key)
+	}
+	return s.store.DeleteUnchanged(records)
+}
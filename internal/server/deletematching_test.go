@@ -0,0 +1,63 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+func TestDeleteMatching(t *testing.T) {
+	s := NewTestServer(t)
+	ctx := context.Background()
+
+	// More keys than one batch to exercise incremental deletion.
+	for i := 0; i < deleteMatchingBatchSize+10; i++ {
+		_, err := s.Set(ctx, &cachev1alpha.SetRequest{Key: fmt.Sprintf("tenant:42:%d", i), Value: []byte("v")})
+		require.NoError(t, err)
+	}
+	_, err := s.Set(ctx, &cachev1alpha.SetRequest{Key: "tenant:7:1", Value: []byte("v")})
+	require.NoError(t, err)
+
+	res, err := s.DeleteMatching(ctx, &cachev1alpha.DeleteMatchingRequest{Pattern: "tenant:42:*", DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, res.DryRun)
+	assert.Equal(t, uint64(deleteMatchingBatchSize+10), res.Count)
+	assert.Len(t, s.store.List(), deleteMatchingBatchSize+11)
+
+	res, err = s.DeleteMatching(ctx, &cachev1alpha.DeleteMatchingRequest{Prefix: "tenant:42:"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(deleteMatchingBatchSize+10), res.Count)
+	assert.Equal(t, []string{"tenant:7:1"}, s.store.List())
+}
+
+func TestDeleteMatching_RequiresOneSelector(t *testing.T) {
+	s := NewTestServer(t)
+	ctx := context.Background()
+
+	_, err := s.DeleteMatching(ctx, &cachev1alpha.DeleteMatchingRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.DeleteMatching(ctx, &cachev1alpha.DeleteMatchingRequest{Prefix: "a", Pattern: "a*"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sync"
+
+	"github.com/patrostkowski/protocache/internal/store"
+)
+
+const eventSubscriberBuffer = 1024
+
+// eventSubscriber receives the store events accepted by its filter. When
+// its buffer is full it is dropped: lagged is closed and no further events
+// are delivered, so a slow reader never blocks store writers.
+type eventSubscriber struct {
+	events chan store.Event
+	lagged chan struct{}
+	filter func(store.Event) bool
+
+	lagOnce sync.Once
+}
+
+func (s *eventSubscriber) markLagged() {
+	s.lagOnce.Do(func() { close(s.lagged) })
+}
+
+// eventBroker fans store events out to subscribers.
+type eventBroker struct {
+	mu     sync.RWMutex
+	subs   map[*eventSubscriber]struct{}
+	closed chan struct{}
+
+	closeOnce sync.Once
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		subs:   make(map[*eventSubscriber]struct{}),
+		closed: make(chan struct{}),
+	}
+}
+
+func (b *eventBroker) subscribe(filter func(store.Event) bool, buffer int) *eventSubscriber {
+	sub := &eventSubscriber{
+		events: make(chan store.Event, buffer),
+		lagged: make(chan struct{}),
+		filter: filter,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+func (b *eventBroker) unsubscribe(sub *eventSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs, sub)
+}
+
+// publish is registered as the store event handler and runs under the
+// store lock, so it never blocks.
+func (b *eventBroker) publish(e store.Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs {
+		if !sub.filter(e) {
+			continue
+		}
+		select {
+		case <-sub.lagged:
+		case sub.events <- e:
+		default:
+			sub.markLagged()
+		}
+	}
+}
+
+// close signals all subscribers that the server is shutting down.
+func (b *eventBroker) close() {
+	b.closeOnce.Do(func() { close(b.closed) })
+}
+
+// fanOutEvents returns a store event handler that calls every handler in
+// order.
+func fanOutEvents(handlers ...store.EventHandler) store.EventHandler {
+	return func(e store.Event) {
+		for _, h := range handlers {
+			h(e)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/patrostkowski/protocache/internal/logger"
+)
+
+// runExpirationLoop periodically reaps expired keys from the store
+// until ctx is cancelled.
+func (s *Server) runExpirationLoop(ctx context.Context) {
+	interval := s.config.GetExpirationInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Info("Starting expiration loop", "interval", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.reapExpired(now)
+		}
+	}
+}
+
+func (s *Server) reapExpired(now time.Time) {
+	s.leases.deleteExpired(now)
+	if s.loader != nil {
+		s.loader.deleteExpired(now)
+	}
+	if removed := s.store.DeleteExpired(now); removed > 0 {
+		ExpiredKeys.Add(float64(removed))
+		logger.Debug("Reaped expired keys", "count", removed)
+	}
+}
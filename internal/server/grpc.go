@@ -22,9 +22,11 @@ import (
 
 	"github.com/patrostkowski/protocache/internal/logger"
 	"github.com/patrostkowski/protocache/internal/store"
+	"github.com/patrostkowski/protocache/internal/writebehind"
 	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 func (s *Server) Set(ctx context.Context, req *cachev1alpha.SetRequest) (*cachev1alpha.SetResponse, error) {
@@ -33,19 +35,140 @@ func (s *Server) Set(ctx context.Context, req *cachev1alpha.SetRequest) (*cachev
 		return nil, status.Error(codes.InvalidArgument, "key must not be empty")
 	}
 
-	if err := s.store.Set(req.Key, req.Value); err != nil {
+	if err := s.checkValueSize(len(req.Value)); err != nil {
+		logger.Error("Rejected oversized value", "key", req.Key, "size", len(req.Value))
+		return nil, err
+	}
+
+	opts, err := setOptionsFromRequest(req)
+	if err != nil {
+		logger.Error("Invalid set options", "key", req.Key, "error", err)
+		return nil, err
+	}
+
+	s.leases.invalidate(req.Key)
+	if err := s.store.SetWithOptions(req.Key, req.Value, opts); err != nil {
 		logger.Error("Failed to set key in store", "key", req.Key, "error", err)
 		return nil, status.Errorf(codes.Aborted, "could not set %q key", req.Key)
 	}
 	return &cachev1alpha.SetResponse{Success: true, Message: "OK"}, nil
 }
 
+// errLeaseKeyWritten rejects a lease commit for a key that is no longer
+// missing.
+var errLeaseKeyWritten = errors.New("key written since the lease was granted")
+
+func (s *Server) SetWithLease(ctx context.Context, req *cachev1alpha.SetWithLeaseRequest) (*cachev1alpha.SetResponse, error) {
+	if req.Set == nil || req.Set.Key == "" {
+		logger.Error("Failed to set empty key in store")
+		return nil, status.Error(codes.InvalidArgument, "key must not be empty")
+	}
+	key := req.Set.Key
+
+	if err := s.checkValueSize(len(req.Set.Value)); err != nil {
+		logger.Error("Rejected oversized value", "key", key, "size", len(req.Set.Value))
+		return nil, err
+	}
+
+	opts, err := setOptionsFromRequest(req.Set)
+	if err != nil {
+		logger.Error("Invalid set options", "key", key, "error", err)
+		return nil, err
+	}
+
+	ok, err := s.leases.commit(key, req.LeaseToken, time.Now(), func() error {
+		// Writers invalidate the lease before they write, so a write
+		// can land after a lease granted in between. Leases are only
+		// granted on misses, so the key existing means it was written.
+		if _, err := s.store.GetEntry(key); err == nil {
+			return errLeaseKeyWritten
+		}
+		return s.store.SetWithOptions(key, req.Set.Value, opts)
+	})
+	if errors.Is(err, errLeaseKeyWritten) {
+		ok, err = false, nil
+	}
+	if err != nil {
+		logger.Error("Failed to set key in store", "key", key, "error", err)
+		return nil, status.Errorf(codes.Aborted, "could not set %q key", key)
+	}
+	if !ok {
+		logger.Warn("Rejected set with invalid lease", "key", key)
+		return nil, status.Errorf(codes.FailedPrecondition, "lease for key %q is no longer valid", key)
+	}
+	return &cachev1alpha.SetResponse{Success: true, Message: "OK"}, nil
+}
+
+func setOptionsFromRequest(req *cachev1alpha.SetRequest) (store.SetOptions, error) {
+	var opts store.SetOptions
+	for _, d := range []struct {
+		name  string
+		value *durationpb.Duration
+		dst   *time.Duration
+	}{
+		{"idle_timeout", req.IdleTimeout, &opts.IdleTimeout},
+		{"soft_ttl", req.SoftTtl, &opts.SoftTTL},
+		{"hard_ttl", req.HardTtl, &opts.HardTTL},
+	} {
+		if d.value == nil {
+			continue
+		}
+		if err := d.value.CheckValid(); err != nil || d.value.AsDuration() < 0 {
+			return opts, status.Errorf(codes.InvalidArgument, "%s must be a non-negative duration", d.name)
+		}
+		*d.dst = d.value.AsDuration()
+	}
+
+	if opts.SoftTTL > 0 && opts.HardTTL > 0 && opts.SoftTTL > opts.HardTTL {
+		return opts, status.Error(codes.InvalidArgument, "soft_ttl must not be longer than hard_ttl")
+	}
+	return opts, nil
+}
+
+// forwardWrite is registered as a store event handler. It queues writes
+// for the write-behind sink from under the store lock, so the sink sees
+// them in the order they were applied. Restored entries, which include
+// loader fills, and expired or evicted keys are not forwarded. The store
+// is the source of truth for readers, so a full buffer drops the
+// mutation instead of failing the request.
+func (s *Server) forwardWrite(e store.Event) {
+	if s.writeBehind == nil {
+		return
+	}
+
+	m := writebehind.Mutation{Key: e.Key, Timestamp: e.Time.UnixNano()}
+	switch {
+	case e.Type == store.EventSet && !e.Restored:
+		m.Op = writebehind.OpSet
+		m.Value = e.Value
+	case e.Type == store.EventDelete:
+		m.Op = writebehind.OpDelete
+	default:
+		return
+	}
+
+	if err := s.writeBehind.Enqueue(m); err != nil {
+		WriteBehindDropped.Inc()
+		logger.Warn("Dropped write-behind mutation", "op", m.Op, "key", m.Key, "error", err)
+	}
+}
+
 func (s *Server) Get(ctx context.Context, req *cachev1alpha.GetRequest) (*cachev1alpha.GetResponse, error) {
-	val, err := s.store.Get(req.Key)
+	if req.TrackingId != 0 && !s.tracking.track(req.TrackingId, req.Key) {
+		return nil, status.Errorf(codes.FailedPrecondition, "unknown tracking id %d", req.TrackingId)
+	}
+
+	entry, err := s.store.GetEntry(req.Key)
 	if err != nil {
 		if errors.Is(err, store.StoreErrorKeyNotFound) {
 			CacheMisses.Inc()
 			logger.Warn("Cache miss", "key", req.Key)
+			if s.loader != nil {
+				return s.getFromLoader(req.Key, req.IfNoneMatch)
+			}
+			if req.Lease {
+				return s.leaseOnMiss(req.Key), nil
+			}
 			return nil, status.Errorf(codes.NotFound, "key %q not found", req.Key)
 		}
 		logger.Error("Failed to get key from store", "key", req.Key, "error", err)
@@ -53,10 +176,126 @@ func (s *Server) Get(ctx context.Context, req *cachev1alpha.GetRequest) (*cachev
 	}
 
 	CacheHits.Inc()
-	return &cachev1alpha.GetResponse{Found: true, Message: "found", Value: val}, nil
+	return entryResponse(entry, "found", req.IfNoneMatch), nil
+}
+
+// entryResponse describes entry in a GetResponse, claiming its refresh if
+// it is stale.
+func entryResponse(entry *store.Entry, message, ifNoneMatch string) *cachev1alpha.GetResponse {
+	res := &cachev1alpha.GetResponse{
+		Found:    true,
+		Message:  message,
+		Value:    entry.Value,
+		Version:  entry.Version,
+		Etag:     entry.ETag(),
+		Expiring: entry.Expiring(),
+	}
+	if entry.Stale(time.Now()) {
+		StaleHits.Inc()
+		res.Stale = true
+		res.Refresh = entry.ClaimRefresh()
+		res.Message = "stale"
+	}
+	if ifNoneMatch != "" && ifNoneMatch == res.Etag {
+		res.NotModified = true
+		res.Value = nil
+		if !res.Stale {
+			res.Message = "not modified"
+		}
+	}
+	return res
+}
+
+// getFromLoader loads a missing key. The fill holds a lease taken before
+// the upstream call, so it is dropped if the key is written or deleted
+// while the load is in flight.
+func (s *Server) getFromLoader(key, ifNoneMatch string) (*cachev1alpha.GetResponse, error) {
+	var token uint64
+	val, err := s.loader.load(key, func() fillFunc {
+		var ok bool
+		token, ok = s.leases.acquire(key, time.Now())
+		return func(value []byte, ttl time.Duration) error {
+			if !ok {
+				return nil
+			}
+			_, err := s.leases.commit(key, token, time.Now(), func() error {
+				// A write that landed before the lease was taken.
+				if _, err := s.store.GetEntry(key); err == nil {
+					return nil
+				}
+				// Filled through Restore so the value is not written back
+				// to the write-behind sink it most likely came from.
+				rec := store.Record{Key: key, Value: value}
+				if ttl > 0 {
+					rec.ExpiresAt = time.Now().Add(ttl)
+				}
+				return s.store.Restore([]store.Record{rec})
+			})
+			return err
+		}
+	})
+	if token != 0 {
+		s.leases.release(key, token)
+	}
+	if err != nil {
+		if errors.Is(err, errLoaderKeyNotFound) {
+			return nil, status.Errorf(codes.NotFound, "key %q not found", key)
+		}
+		return nil, status.Errorf(codes.Unavailable, "loader failed for key %q: %v", key, err)
+	}
+	// Describe the stored entry, so that the response carries the same
+	// metadata as the next read. It is missing only if the fill was
+	// dropped for a delete, or the key expired already.
+	if entry, err := s.store.GetEntry(key); err == nil {
+		return entryResponse(entry, "loaded", ifNoneMatch), nil
+	}
+	res := &cachev1alpha.GetResponse{Found: true, Message: "loaded", Value: val, Etag: store.ETag(val), Expiring: true}
+	if ifNoneMatch != "" && ifNoneMatch == res.Etag {
+		res.NotModified = true
+		res.Value = nil
+	}
+	return res, nil
+}
+
+func (s *Server) leaseOnMiss(key string) *cachev1alpha.GetResponse {
+	token, ok := s.leases.acquire(key, time.Now())
+	if !ok {
+		return &cachev1alpha.GetResponse{Found: false, Message: "lease held by another caller", LeaseWait: true}
+	}
+	LeasesGranted.Inc()
+	return &cachev1alpha.GetResponse{Found: false, Message: "lease granted", LeaseToken: token}
+}
+
+func (s *Server) Incr(ctx context.Context, req *cachev1alpha.IncrRequest) (*cachev1alpha.IncrResponse, error) {
+	if req.Key == "" {
+		return nil, status.Error(codes.InvalidArgument, "key must not be empty")
+	}
+
+	s.leases.invalidate(req.Key)
+	n, err := s.store.Increment(req.Key, req.Delta)
+	if err != nil {
+		if errors.Is(err, store.StoreErrorNotInteger) || errors.Is(err, store.StoreErrorOverflow) {
+			return nil, status.Errorf(codes.FailedPrecondition, "could not increment %q: %v", req.Key, err)
+		}
+		logger.Error("Failed to increment key in store", "key", req.Key, "error", err)
+		return nil, status.Errorf(codes.Unknown, "internal error: %v", err)
+	}
+	return &cachev1alpha.IncrResponse{Value: n}, nil
+}
+
+func (s *Server) Touch(ctx context.Context, req *cachev1alpha.TouchRequest) (*cachev1alpha.TouchResponse, error) {
+	if err := s.store.Touch(req.Key); err != nil {
+		if errors.Is(err, store.StoreErrorKeyNotFound) {
+			return nil, status.Errorf(codes.NotFound, "key %q not found", req.Key)
+		}
+		logger.Error("Failed to touch key in store", "key", req.Key, "error", err)
+		return nil, status.Errorf(codes.Unknown, "internal error: %v", err)
+	}
+	return &cachev1alpha.TouchResponse{Success: true, Message: "touched"}, nil
 }
 
 func (s *Server) Delete(ctx context.Context, req *cachev1alpha.DeleteRequest) (*cachev1alpha.DeleteResponse, error) {
+	s.leases.invalidate(req.Key)
 	if err := s.store.Delete(req.Key); err != nil {
 		logger.Error("Failed to delete key from store", "key", req.Key, "error", err)
 		return nil, status.Errorf(codes.Unknown, "internal error: %v", err)
@@ -65,6 +304,7 @@ func (s *Server) Delete(ctx context.Context, req *cachev1alpha.DeleteRequest) (*
 }
 
 func (s *Server) Clear(ctx context.Context, req *cachev1alpha.ClearRequest) (*cachev1alpha.ClearResponse, error) {
+	s.leases.reset()
 	s.store.Clear()
 	return &cachev1alpha.ClearResponse{Success: true, Message: "cleared"}, nil
 }
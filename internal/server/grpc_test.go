@@ -16,10 +16,22 @@ package server
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/patrostkowski/protocache/internal/store"
+	"github.com/patrostkowski/protocache/internal/writebehind"
 	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 func TestSetAndGet(t *testing.T) {
@@ -102,3 +114,312 @@ func TestList(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Empty(t, resp.Keys)
 }
+
+func TestTouch(t *testing.T) {
+	server := NewTestServer(t)
+	ctx := context.Background()
+
+	_, err := server.Touch(ctx, &cachev1alpha.TouchRequest{Key: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = server.Set(ctx, &cachev1alpha.SetRequest{
+		Key:         "session",
+		Value:       []byte("data"),
+		IdleTimeout: durationpb.New(50 * time.Millisecond),
+	})
+	assert.NoError(t, err)
+
+	time.Sleep(30 * time.Millisecond)
+	res, err := server.Touch(ctx, &cachev1alpha.TouchRequest{Key: "session"})
+	assert.NoError(t, err)
+	assert.True(t, res.Success)
+
+	time.Sleep(30 * time.Millisecond)
+	_, err = server.Get(ctx, &cachev1alpha.GetRequest{Key: "session"})
+	assert.NoError(t, err)
+
+	server.reapExpired(time.Now().Add(time.Second))
+	_, err = server.Get(ctx, &cachev1alpha.GetRequest{Key: "session"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestSetInvalidIdleTimeout(t *testing.T) {
+	server := NewTestServer(t)
+
+	_, err := server.Set(context.Background(), &cachev1alpha.SetRequest{
+		Key:         "foo",
+		Value:       []byte("bar"),
+		IdleTimeout: durationpb.New(-time.Second),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetStaleWhileRevalidate(t *testing.T) {
+	server := NewTestServer(t)
+	ctx := context.Background()
+
+	_, err := server.Set(ctx, &cachev1alpha.SetRequest{
+		Key:     "hot",
+		Value:   []byte("v1"),
+		SoftTtl: durationpb.New(20 * time.Millisecond),
+		HardTtl: durationpb.New(time.Minute),
+	})
+	assert.NoError(t, err)
+
+	res, err := server.Get(ctx, &cachev1alpha.GetRequest{Key: "hot"})
+	assert.NoError(t, err)
+	assert.False(t, res.Stale)
+	assert.False(t, res.Refresh)
+
+	time.Sleep(30 * time.Millisecond)
+
+	res, err = server.Get(ctx, &cachev1alpha.GetRequest{Key: "hot"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v1"), res.Value)
+	assert.True(t, res.Stale)
+	assert.True(t, res.Refresh)
+
+	res, err = server.Get(ctx, &cachev1alpha.GetRequest{Key: "hot"})
+	assert.NoError(t, err)
+	assert.True(t, res.Stale)
+	assert.False(t, res.Refresh, "only the first caller should be told to refresh")
+
+	_, err = server.Set(ctx, &cachev1alpha.SetRequest{Key: "hot", Value: []byte("v2")})
+	assert.NoError(t, err)
+
+	res, err = server.Get(ctx, &cachev1alpha.GetRequest{Key: "hot"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v2"), res.Value)
+	assert.False(t, res.Stale)
+}
+
+func TestSetSoftTTLLongerThanHardTTL(t *testing.T) {
+	server := NewTestServer(t)
+
+	_, err := server.Set(context.Background(), &cachev1alpha.SetRequest{
+		Key:     "foo",
+		Value:   []byte("bar"),
+		SoftTtl: durationpb.New(time.Minute),
+		HardTtl: durationpb.New(time.Second),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetWithLease(t *testing.T) {
+	server := NewTestServer(t)
+	ctx := context.Background()
+
+	first, err := server.Get(ctx, &cachev1alpha.GetRequest{Key: "user:1", Lease: true})
+	assert.NoError(t, err)
+	assert.False(t, first.Found)
+	assert.NotZero(t, first.LeaseToken)
+
+	second, err := server.Get(ctx, &cachev1alpha.GetRequest{Key: "user:1", Lease: true})
+	assert.NoError(t, err)
+	assert.True(t, second.LeaseWait)
+	assert.Zero(t, second.LeaseToken)
+
+	_, err = server.SetWithLease(ctx, &cachev1alpha.SetWithLeaseRequest{
+		Set:        &cachev1alpha.SetRequest{Key: "user:1", Value: []byte("alice")},
+		LeaseToken: first.LeaseToken,
+	})
+	assert.NoError(t, err)
+
+	res, err := server.Get(ctx, &cachev1alpha.GetRequest{Key: "user:1", Lease: true})
+	assert.NoError(t, err)
+	assert.True(t, res.Found)
+	assert.Equal(t, []byte("alice"), res.Value)
+
+	_, err = server.SetWithLease(ctx, &cachev1alpha.SetWithLeaseRequest{
+		Set:        &cachev1alpha.SetRequest{Key: "user:1", Value: []byte("again")},
+		LeaseToken: first.LeaseToken,
+	})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err), "a lease can only be used once")
+}
+
+func TestSetWithLeaseRejectedAfterInterleavedSet(t *testing.T) {
+	server := NewTestServer(t)
+	ctx := context.Background()
+
+	// Set invalidates the lease table and then writes the store. Replay
+	// a leased miss landing between the two steps.
+	server.leases.invalidate("user:1")
+	res, err := server.Get(ctx, &cachev1alpha.GetRequest{Key: "user:1", Lease: true})
+	require.NoError(t, err)
+	require.NotZero(t, res.LeaseToken)
+	require.NoError(t, server.store.Set("user:1", []byte("newer")))
+
+	_, err = server.SetWithLease(ctx, &cachev1alpha.SetWithLeaseRequest{
+		Set:        &cachev1alpha.SetRequest{Key: "user:1", Value: []byte("stale")},
+		LeaseToken: res.LeaseToken,
+	})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	value, err := server.store.Get("user:1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("newer"), value)
+}
+
+func TestSetWithLeaseRejectedAfterDelete(t *testing.T) {
+	server := NewTestServer(t)
+	ctx := context.Background()
+
+	res, err := server.Get(ctx, &cachev1alpha.GetRequest{Key: "user:1", Lease: true})
+	assert.NoError(t, err)
+
+	_, _ = server.Delete(ctx, &cachev1alpha.DeleteRequest{Key: "user:1"})
+
+	_, err = server.SetWithLease(ctx, &cachev1alpha.SetWithLeaseRequest{
+		Set:        &cachev1alpha.SetRequest{Key: "user:1", Value: []byte("stale")},
+		LeaseToken: res.LeaseToken,
+	})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = server.Get(ctx, &cachev1alpha.GetRequest{Key: "user:1"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestLeaseExpires(t *testing.T) {
+	server := NewTestServer(t)
+	server.leases = newLeaseTable(20 * time.Millisecond)
+	ctx := context.Background()
+
+	first, err := server.Get(ctx, &cachev1alpha.GetRequest{Key: "k", Lease: true})
+	assert.NoError(t, err)
+
+	time.Sleep(30 * time.Millisecond)
+
+	second, err := server.Get(ctx, &cachev1alpha.GetRequest{Key: "k", Lease: true})
+	assert.NoError(t, err)
+	assert.NotZero(t, second.LeaseToken)
+	assert.NotEqual(t, first.LeaseToken, second.LeaseToken)
+}
+
+func TestWriteBehindRecordsMutations(t *testing.T) {
+	server := NewTestServer(t)
+	path := filepath.Join(t.TempDir(), "mutations.ndjson")
+	server.config.StoreConfig.WriteBehind = &cachev1alpha.WriteBehindConfig{
+		Sink:     cachev1alpha.FileWriteBehindSink,
+		FilePath: path,
+	}
+	require.NoError(t, server.initWriteBehind())
+	ctx := context.Background()
+
+	_, err := server.Set(ctx, &cachev1alpha.SetRequest{Key: "a", Value: []byte("1")})
+	require.NoError(t, err)
+	_, err = server.Set(ctx, &cachev1alpha.SetRequest{Key: "b", Value: []byte("2")})
+	require.NoError(t, err)
+	_, err = server.Delete(ctx, &cachev1alpha.DeleteRequest{Key: "a"})
+	require.NoError(t, err)
+
+	require.NoError(t, server.writeBehind.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"op":"delete","key":"a"`)
+	assert.Contains(t, lines[1], `"op":"set","key":"b"`)
+}
+
+// recordingSink keeps every mutation written to it.
+type recordingSink struct {
+	mu        sync.Mutex
+	mutations []writebehind.Mutation
+}
+
+func (r *recordingSink) Write(_ context.Context, batch []writebehind.Mutation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mutations = append(r.mutations, batch...)
+	return nil
+}
+
+func (r *recordingSink) Close() error { return nil }
+
+func newRecordingPipeline(s *Server) *recordingSink {
+	sink := &recordingSink{}
+	s.writeBehind = writebehind.NewPipeline(sink, writebehind.Options{
+		BatchSize:     10,
+		BufferSize:    1000,
+		FlushInterval: time.Millisecond,
+		MaxBackoff:    time.Millisecond,
+	})
+	return sink
+}
+
+func TestWriteBehindKeepsApplyOrder(t *testing.T) {
+	server := NewTestServer(t)
+	sink := newRecordingPipeline(server)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := server.Set(ctx, &cachev1alpha.SetRequest{Key: "k", Value: []byte(strconv.Itoa(i))})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, server.writeBehind.Close())
+
+	want, err := server.store.GetEntry("k")
+	require.NoError(t, err)
+	last := sink.mutations[len(sink.mutations)-1]
+	assert.Equal(t, want.Value, last.Value)
+	for i := 1; i < len(sink.mutations); i++ {
+		assert.LessOrEqual(t, sink.mutations[i-1].Timestamp, sink.mutations[i].Timestamp)
+	}
+}
+
+func TestWriteBehindSkipsRestoredAndExpiredKeys(t *testing.T) {
+	server := NewTestServer(t)
+	sink := newRecordingPipeline(server)
+	ctx := context.Background()
+
+	require.NoError(t, server.store.Restore([]store.Record{{Key: "restored", Value: []byte("1")}}))
+	_, err := server.Set(ctx, &cachev1alpha.SetRequest{Key: "ttl", Value: []byte("2"), HardTtl: durationpb.New(time.Millisecond)})
+	require.NoError(t, err)
+	server.store.DeleteExpired(time.Now().Add(time.Second))
+	require.NoError(t, server.writeBehind.Close())
+
+	require.Len(t, sink.mutations, 1)
+	assert.Equal(t, writebehind.OpSet, sink.mutations[0].Op)
+	assert.Equal(t, "ttl", sink.mutations[0].Key)
+}
+
+func TestGetIfNoneMatch(t *testing.T) {
+	server := NewTestServer(t)
+	ctx := context.Background()
+
+	_, err := server.Set(ctx, &cachev1alpha.SetRequest{Key: "config", Value: []byte("v1")})
+	require.NoError(t, err)
+
+	res, err := server.Get(ctx, &cachev1alpha.GetRequest{Key: "config"})
+	require.NoError(t, err)
+	require.NotEmpty(t, res.Etag)
+	etag := res.Etag
+
+	res, err = server.Get(ctx, &cachev1alpha.GetRequest{Key: "config", IfNoneMatch: etag})
+	require.NoError(t, err)
+	assert.True(t, res.NotModified)
+	assert.Nil(t, res.Value)
+	assert.Equal(t, etag, res.Etag)
+
+	// Rewriting the same content keeps the etag.
+	_, err = server.Set(ctx, &cachev1alpha.SetRequest{Key: "config", Value: []byte("v1")})
+	require.NoError(t, err)
+	res, err = server.Get(ctx, &cachev1alpha.GetRequest{Key: "config", IfNoneMatch: etag})
+	require.NoError(t, err)
+	assert.True(t, res.NotModified)
+
+	_, err = server.Set(ctx, &cachev1alpha.SetRequest{Key: "config", Value: []byte("v2")})
+	require.NoError(t, err)
+	res, err = server.Get(ctx, &cachev1alpha.GetRequest{Key: "config", IfNoneMatch: etag})
+	require.NoError(t, err)
+	assert.False(t, res.NotModified)
+	assert.Equal(t, []byte("v2"), res.Value)
+	assert.NotEqual(t, etag, res.Etag)
+}
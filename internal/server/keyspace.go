@@ -0,0 +1,90 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/patrostkowski/protocache/internal/glob"
+	"github.com/patrostkowski/protocache/internal/logger"
+	"github.com/patrostkowski/protocache/internal/store"
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+var keyspaceEventTypes = map[store.EventType]cachev1alpha.KeyspaceEventType{
+	store.EventSet:    cachev1alpha.KeyspaceEventType_KEYSPACE_EVENT_TYPE_SET,
+	store.EventDelete: cachev1alpha.KeyspaceEventType_KEYSPACE_EVENT_TYPE_DELETE,
+	store.EventExpire: cachev1alpha.KeyspaceEventType_KEYSPACE_EVENT_TYPE_EXPIRE,
+	store.EventEvict:  cachev1alpha.KeyspaceEventType_KEYSPACE_EVENT_TYPE_EVICT,
+	store.EventClear:  cachev1alpha.KeyspaceEventType_KEYSPACE_EVENT_TYPE_CLEAR,
+}
+
+func keyspaceEventFilter(req *cachev1alpha.SubscribeKeyspaceEventsRequest) func(store.Event) bool {
+	types := make(map[cachev1alpha.KeyspaceEventType]bool, len(req.Types))
+	for _, t := range req.Types {
+		types[t] = true
+	}
+
+	return func(e store.Event) bool {
+		if len(types) > 0 && !types[keyspaceEventTypes[e.Type]] {
+			return false
+		}
+		if len(req.Patterns) == 0 || e.Type == store.EventClear {
+			return true
+		}
+		for _, pattern := range req.Patterns {
+			if glob.Match(pattern, e.Key) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (s *Server) SubscribeKeyspaceEvents(req *cachev1alpha.SubscribeKeyspaceEventsRequest, stream cachev1alpha.CacheService_SubscribeKeyspaceEventsServer) error {
+	for _, t := range req.Types {
+		if t == cachev1alpha.KeyspaceEventType_KEYSPACE_EVENT_TYPE_UNSPECIFIED {
+			return status.Error(codes.InvalidArgument, "event type must not be unspecified")
+		}
+	}
+
+	sub := s.events.subscribe(keyspaceEventFilter(req), eventSubscriberBuffer)
+	defer s.events.unsubscribe(sub)
+
+	KeyspaceSubscribers.Inc()
+	defer KeyspaceSubscribers.Dec()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.events.closed:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-sub.lagged:
+			logger.Warn("Dropping keyspace event subscriber that fell behind")
+			return status.Error(codes.ResourceExhausted, "subscriber fell behind, resubscribe")
+		case e := <-sub.events:
+			err := stream.Send(&cachev1alpha.KeyspaceEvent{
+				Type:      keyspaceEventTypes[e.Type],
+				Key:       e.Key,
+				Timestamp: e.Time.UnixNano(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
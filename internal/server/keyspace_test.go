@@ -0,0 +1,114 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/patrostkowski/protocache/internal/store"
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+// waitForSubscribers blocks until n subscribers are registered, so that
+// events published by the test are not missed.
+func waitForSubscribers(t *testing.T, b *eventBroker, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		b.mu.RLock()
+		defer b.mu.RUnlock()
+		return len(b.subs) == n
+	}, time.Second, time.Millisecond)
+}
+
+func TestSubscribeKeyspaceEvents(t *testing.T) {
+	s := NewTestServer(t)
+	client := NewTestClient(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.SubscribeKeyspaceEvents(ctx, &cachev1alpha.SubscribeKeyspaceEventsRequest{
+		Patterns: []string{"session:*"},
+		Types: []cachev1alpha.KeyspaceEventType{
+			cachev1alpha.KeyspaceEventType_KEYSPACE_EVENT_TYPE_DELETE,
+			cachev1alpha.KeyspaceEventType_KEYSPACE_EVENT_TYPE_EXPIRE,
+		},
+	})
+	require.NoError(t, err)
+	waitForSubscribers(t, s.events, 1)
+
+	_, err = s.Set(ctx, &cachev1alpha.SetRequest{Key: "session:1", Value: []byte("a")})
+	require.NoError(t, err)
+	_, err = s.Set(ctx, &cachev1alpha.SetRequest{
+		Key:     "session:2",
+		Value:   []byte("b"),
+		HardTtl: durationpb.New(time.Millisecond),
+	})
+	require.NoError(t, err)
+	_, err = s.Set(ctx, &cachev1alpha.SetRequest{Key: "user:1", Value: []byte("c")})
+	require.NoError(t, err)
+	_, err = s.Delete(ctx, &cachev1alpha.DeleteRequest{Key: "user:1"})
+	require.NoError(t, err)
+	_, err = s.Delete(ctx, &cachev1alpha.DeleteRequest{Key: "session:1"})
+	require.NoError(t, err)
+	s.reapExpired(time.Now().Add(time.Second))
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, cachev1alpha.KeyspaceEventType_KEYSPACE_EVENT_TYPE_DELETE, event.Type)
+	assert.Equal(t, "session:1", event.Key)
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, cachev1alpha.KeyspaceEventType_KEYSPACE_EVENT_TYPE_EXPIRE, event.Type)
+	assert.Equal(t, "session:2", event.Key)
+}
+
+func TestSubscribeKeyspaceEvents_InvalidType(t *testing.T) {
+	s := NewTestServer(t)
+	client := NewTestClient(t, s)
+
+	stream, err := client.SubscribeKeyspaceEvents(context.Background(), &cachev1alpha.SubscribeKeyspaceEventsRequest{
+		Types: []cachev1alpha.KeyspaceEventType{cachev1alpha.KeyspaceEventType_KEYSPACE_EVENT_TYPE_UNSPECIFIED},
+	})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestEventBroker_DropsSlowSubscriber(t *testing.T) {
+	b := newEventBroker()
+	sub := b.subscribe(func(store.Event) bool { return true }, 2)
+
+	for i := 0; i < 3; i++ {
+		b.publish(store.Event{Type: store.EventSet, Key: "k"})
+	}
+
+	select {
+	case <-sub.lagged:
+	default:
+		t.Fatal("subscriber should be marked as lagged")
+	}
+	assert.Len(t, sub.events, 2)
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+type lease struct {
+	token     uint64
+	expiresAt time.Time
+}
+
+// leaseTable hands out at most one lease per missing key. A lease is
+// invalidated by any write or delete of its key, so a lease holder that
+// raced with an invalidation cannot store a stale value.
+type leaseTable struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	next   uint64
+	leases map[string]lease
+}
+
+func newLeaseTable(ttl time.Duration) *leaseTable {
+	return &leaseTable{
+		ttl:    ttl,
+		leases: make(map[string]lease),
+	}
+}
+
+// acquire grants a new lease for key unless a valid one is already held.
+func (t *leaseTable) acquire(key string, now time.Time) (uint64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if l, ok := t.leases[key]; ok && now.Before(l.expiresAt) {
+		return 0, false
+	}
+
+	t.next++
+	t.leases[key] = lease{token: t.next, expiresAt: now.Add(t.ttl)}
+	return t.next, true
+}
+
+// commit runs write if token is the valid lease for key and consumes the
+// lease. The write runs under the table lock so it cannot interleave with
+// an invalidation of the same key.
+func (t *leaseTable) commit(key string, token uint64, now time.Time, write func() error) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	l, ok := t.leases[key]
+	if !ok || l.token != token || !now.Before(l.expiresAt) {
+		return false, nil
+	}
+
+	delete(t.leases, key)
+	return true, write()
+}
+
+// release drops the lease for key if token still holds it.
+func (t *leaseTable) release(key string, token uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if l, ok := t.leases[key]; ok && l.token == token {
+		delete(t.leases, key)
+	}
+}
+
+func (t *leaseTable) invalidate(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.leases, key)
+}
+
+func (t *leaseTable) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.leases = make(map[string]lease)
+}
+
+func (t *leaseTable) deleteExpired(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, l := range t.leases {
+		if !now.Before(l.expiresAt) {
+			delete(t.leases, key)
+		}
+	}
+}
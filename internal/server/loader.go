@@ -0,0 +1,147 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/patrostkowski/protocache/internal/logger"
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+var errLoaderKeyNotFound = errors.New("key not found by loader")
+
+type loadCall struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+// fillFunc stores a loaded value with the TTL it should be cached for.
+type fillFunc func(value []byte, ttl time.Duration) error
+
+type negativeResult struct {
+	err       error
+	expiresAt time.Time
+}
+
+// readThroughLoader fills cache misses from an upstream Loader service.
+// Concurrent loads of the same key share a single upstream call, and
+// failed or empty loads are remembered for a short negative TTL.
+type readThroughLoader struct {
+	conn   *grpc.ClientConn
+	client cachev1alpha.LoaderClient
+	config *cachev1alpha.LoaderConfig
+
+	mu       sync.Mutex
+	inflight map[string]*loadCall
+	negative map[string]negativeResult
+}
+
+func newReadThroughLoader(cfg *cachev1alpha.LoaderConfig) (*readThroughLoader, error) {
+	conn, err := grpc.NewClient(cfg.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &readThroughLoader{
+		conn:     conn,
+		client:   cachev1alpha.NewLoaderClient(conn),
+		config:   cfg,
+		inflight: make(map[string]*loadCall),
+		negative: make(map[string]negativeResult),
+	}, nil
+}
+
+// load returns the upstream value of key. The caller that performs the
+// upstream call runs begin before it, and stores the value through the
+// returned fill before waking the others.
+func (l *readThroughLoader) load(key string, begin func() fillFunc) ([]byte, error) {
+	l.mu.Lock()
+	if neg, ok := l.negative[key]; ok {
+		if time.Now().Before(neg.expiresAt) {
+			l.mu.Unlock()
+			return nil, neg.err
+		}
+		delete(l.negative, key)
+	}
+	if call, ok := l.inflight[key]; ok {
+		l.mu.Unlock()
+		<-call.done
+		return call.value, call.err
+	}
+	call := &loadCall{done: make(chan struct{})}
+	l.inflight[key] = call
+	l.mu.Unlock()
+
+	call.value, call.err = l.fetch(key, begin())
+
+	l.mu.Lock()
+	delete(l.inflight, key)
+	if call.err != nil {
+		l.negative[key] = negativeResult{err: call.err, expiresAt: time.Now().Add(l.config.NegativeTTL)}
+	}
+	l.mu.Unlock()
+	close(call.done)
+
+	return call.value, call.err
+}
+
+func (l *readThroughLoader) fetch(key string, fill fillFunc) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.config.Timeout)
+	defer cancel()
+
+	res, err := l.client.Load(ctx, &cachev1alpha.LoadRequest{Key: key})
+	if err != nil {
+		LoaderRequests.WithLabelValues("error").Inc()
+		logger.Error("Loader request failed", "key", key, "error", err)
+		return nil, err
+	}
+	if !res.Found {
+		LoaderRequests.WithLabelValues("not_found").Inc()
+		return nil, errLoaderKeyNotFound
+	}
+	LoaderRequests.WithLabelValues("found").Inc()
+
+	ttl := l.config.TTL
+	if res.Ttl != nil && res.Ttl.AsDuration() > 0 {
+		ttl = res.Ttl.AsDuration()
+	}
+	if err := fill(res.Value, ttl); err != nil {
+		return nil, err
+	}
+	return res.Value, nil
+}
+
+func (l *readThroughLoader) deleteExpired(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, neg := range l.negative {
+		if !now.Before(neg.expiresAt) {
+			delete(l.negative, key)
+		}
+	}
+}
+
+func (l *readThroughLoader) close() error {
+	return l.conn.Close()
+}
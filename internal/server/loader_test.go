@@ -0,0 +1,212 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+type fakeLoader struct {
+	cachev1alpha.UnimplementedLoaderServer
+
+	calls   atomic.Int32
+	release chan struct{}
+	values  map[string][]byte
+	fail    bool
+}
+
+func (f *fakeLoader) Load(ctx context.Context, req *cachev1alpha.LoadRequest) (*cachev1alpha.LoadResponse, error) {
+	f.calls.Add(1)
+	if f.release != nil {
+		<-f.release
+	}
+	if f.fail {
+		return nil, status.Error(codes.Internal, "database is down")
+	}
+	val, ok := f.values[req.Key]
+	return &cachev1alpha.LoadResponse{Found: ok, Value: val}, nil
+}
+
+func newLoaderTestServer(t *testing.T, loader *fakeLoader) *Server {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	grpcServer := grpc.NewServer()
+	cachev1alpha.RegisterLoaderServer(grpcServer, loader)
+	go func() {
+		_ = grpcServer.Serve(lis)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	s := NewTestServer(t)
+	s.config.StoreConfig.Loader = &cachev1alpha.LoaderConfig{
+		Address:     lis.Addr().String(),
+		NegativeTTL: 50 * time.Millisecond,
+	}
+	require.NoError(t, s.initLoader())
+	t.Cleanup(func() { _ = s.loader.close() })
+
+	return s
+}
+
+func TestGetReadThrough(t *testing.T) {
+	loader := &fakeLoader{values: map[string][]byte{"user:1": []byte("alice")}}
+	s := newLoaderTestServer(t, loader)
+	ctx := context.Background()
+
+	res, err := s.Get(ctx, &cachev1alpha.GetRequest{Key: "user:1"})
+	require.NoError(t, err)
+	assert.True(t, res.Found)
+	assert.Equal(t, []byte("alice"), res.Value)
+
+	loaded := res
+	res, err = s.Get(ctx, &cachev1alpha.GetRequest{Key: "user:1"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("alice"), res.Value)
+	assert.Equal(t, int32(1), loader.calls.Load(), "second get should be served from the cache")
+	assert.NotZero(t, loaded.Version)
+	assert.Equal(t, loaded.Version, res.Version)
+	assert.Equal(t, loaded.Etag, res.Etag)
+	assert.True(t, res.Expiring)
+
+	entry, err := s.store.GetEntry("user:1")
+	require.NoError(t, err)
+	assert.False(t, entry.ExpiresAt.IsZero(), "loaded values should be cached with a TTL")
+}
+
+func TestGetReadThrough_CollapsesConcurrentLoads(t *testing.T) {
+	loader := &fakeLoader{
+		values:  map[string][]byte{"hot": []byte("v")},
+		release: make(chan struct{}),
+	}
+	s := newLoaderTestServer(t, loader)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := s.Get(context.Background(), &cachev1alpha.GetRequest{Key: "hot"})
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("v"), res.GetValue())
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(loader.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), loader.calls.Load())
+}
+
+func TestGetReadThrough_NegativeCache(t *testing.T) {
+	loader := &fakeLoader{fail: true}
+	s := newLoaderTestServer(t, loader)
+	ctx := context.Background()
+
+	_, err := s.Get(ctx, &cachev1alpha.GetRequest{Key: "k"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	_, err = s.Get(ctx, &cachev1alpha.GetRequest{Key: "k"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(1), loader.calls.Load(), "failure should be negatively cached")
+
+	time.Sleep(60 * time.Millisecond)
+
+	_, err = s.Get(ctx, &cachev1alpha.GetRequest{Key: "k"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(2), loader.calls.Load())
+}
+
+func TestGetReadThrough_NotFound(t *testing.T) {
+	loader := &fakeLoader{values: map[string][]byte{}}
+	s := newLoaderTestServer(t, loader)
+
+	_, err := s.Get(context.Background(), &cachev1alpha.GetRequest{Key: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGetReadThrough_DropsFillAfterWrite(t *testing.T) {
+	for name, tc := range map[string]struct {
+		write func(s *Server) error
+		want  []byte
+	}{
+		"set": {
+			write: func(s *Server) error {
+				_, err := s.Set(context.Background(), &cachev1alpha.SetRequest{Key: "user:1", Value: []byte("fresh")})
+				return err
+			},
+			want: []byte("fresh"),
+		},
+		"delete": {
+			write: func(s *Server) error {
+				// The key is still missing, so Delete reports an error,
+				// but it must drop the pending fill all the same.
+				_, _ = s.Delete(context.Background(), &cachev1alpha.DeleteRequest{Key: "user:1"})
+				return nil
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			loader := &fakeLoader{
+				values:  map[string][]byte{"user:1": []byte("loaded")},
+				release: make(chan struct{}),
+			}
+			s := newLoaderTestServer(t, loader)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				res, err := s.Get(context.Background(), &cachev1alpha.GetRequest{Key: "user:1"})
+				assert.NoError(t, err)
+				// The response reflects the store, or the loaded value
+				// if the key is gone.
+				want := tc.want
+				if want == nil {
+					want = []byte("loaded")
+				}
+				assert.Equal(t, want, res.GetValue())
+			}()
+
+			require.Eventually(t, func() bool { return loader.calls.Load() == 1 }, time.Second, time.Millisecond)
+			require.NoError(t, tc.write(s))
+			close(loader.release)
+			<-done
+
+			value, err := s.store.Get("user:1")
+			if tc.want != nil {
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, value)
+			} else {
+				assert.Error(t, err, "the load must not resurrect a deleted key")
+			}
+		})
+	}
+}
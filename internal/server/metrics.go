@@ -35,12 +35,199 @@ var (
 			Help: "Total number of cache misses",
 		},
 	)
+
+	StaleHits = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "protocache_cache_stale_hits_total",
+			Help: "Total number of cache hits served after the soft TTL elapsed",
+		},
+	)
+
+	LeasesGranted = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "protocache_leases_granted_total",
+			Help: "Total number of miss leases granted",
+		},
+	)
+
+	LoaderRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "protocache_loader_requests_total",
+			Help: "Total number of upstream loader requests by result",
+		},
+		[]string{"result"},
+	)
+
+	WriteBehindDropped = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "protocache_write_behind_dropped_total",
+			Help: "Total number of mutations dropped because the write-behind buffer was full",
+		},
+	)
+
+	KeyspaceSubscribers = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "protocache_keyspace_event_subscribers",
+			Help: "Number of active keyspace event subscribers",
+		},
+	)
+
+	Watchers = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "protocache_watchers",
+			Help: "Number of active Watch streams",
+		},
+	)
+
+	WatchersDropped = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "protocache_watchers_dropped_total",
+			Help: "Total number of Watch streams dropped for falling behind",
+		},
+	)
+
+	PubSubSubscribers = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "protocache_pubsub_subscribers",
+			Help: "Number of active Subscribe streams",
+		},
+	)
+
+	PubSubSubscriptions = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "protocache_pubsub_subscriptions",
+			Help: "Number of active channel and pattern subscriptions",
+		},
+		[]string{"type"},
+	)
+
+	PubSubPublished = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "protocache_pubsub_published_total",
+			Help: "Total number of published messages",
+		},
+	)
+
+	PubSubDropped = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "protocache_pubsub_dropped_total",
+			Help: "Total number of messages dropped for slow subscribers",
+		},
+	)
+
+	TrackingClients = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "protocache_tracking_clients",
+			Help: "Number of connected near-cache tracking clients",
+		},
+	)
+
+	InvalidationsSent = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "protocache_invalidations_sent_total",
+			Help: "Total number of key invalidations queued for tracking clients",
+		},
+	)
+
+	SnapshotLastSuccess = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "protocache_snapshot_last_success_timestamp_seconds",
+			Help: "Unix time of the last successful snapshot",
+		},
+	)
+
+	SnapshotDuration = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "protocache_snapshot_last_duration_seconds",
+			Help: "Duration of the last snapshot",
+		},
+	)
+
+	SnapshotSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "protocache_snapshot_last_size_bytes",
+			Help: "Size of the last successful snapshot",
+		},
+	)
+
+	SnapshotLastError = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "protocache_snapshot_last_error",
+			Help: "1 if the last snapshot failed, 0 otherwise",
+		},
+	)
+
+	SnapshotErrors = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "protocache_snapshot_errors_total",
+			Help: "Total number of failed snapshots",
+		},
+	)
+
+	SnapshotSinkErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "protocache_snapshot_sink_errors_total",
+			Help: "Total number of snapshots that could not be shipped to a sink",
+		},
+		[]string{"sink"},
+	)
+
+	AOFSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "protocache_aof_size_bytes",
+			Help: "Size of the append-only file in bytes",
+		},
+	)
+
+	AOFWriteErrors = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "protocache_aof_write_errors_total",
+			Help: "Total number of failed append-only file writes and syncs",
+		},
+	)
+
+	AOFRewrites = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "protocache_aof_rewrites_total",
+			Help: "Total number of completed append-only file rewrites",
+		},
+	)
+
+	ExpiredKeys = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "protocache_expired_keys_total",
+			Help: "Total number of keys removed by the expiration loop",
+		},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(
 		CacheHits,
 		CacheMisses,
+		StaleHits,
+		LeasesGranted,
+		LoaderRequests,
+		WriteBehindDropped,
+		KeyspaceSubscribers,
+		Watchers,
+		WatchersDropped,
+		PubSubSubscribers,
+		PubSubSubscriptions,
+		PubSubPublished,
+		PubSubDropped,
+		TrackingClients,
+		InvalidationsSent,
+		SnapshotLastSuccess,
+		SnapshotDuration,
+		SnapshotSize,
+		SnapshotLastError,
+		SnapshotErrors,
+		SnapshotSinkErrors,
+		AOFSize,
+		AOFWriteErrors,
+		AOFRewrites,
+		ExpiredKeys,
 	)
 }
 
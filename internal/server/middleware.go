@@ -49,3 +49,29 @@ func LoggingUnaryInterceptor() grpc.UnaryServerInterceptor {
 		return resp, err
 	}
 }
+
+func LoggingStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		err := handler(srv, ss)
+
+		var remoteAddr string
+		if p, ok := peer.FromContext(ss.Context()); ok {
+			remoteAddr = p.Addr.String()
+		}
+
+		st, _ := status.FromError(err)
+
+		logger.Info("gRPC stream",
+			slog.String("method", info.FullMethod),
+			slog.String("remote", remoteAddr),
+			slog.String("code", st.Code().String()),
+		)
+
+		return err
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+// Pipeline executes commands in the order they are received and answers
+// each one before reading the next. A client that stops reading results
+// therefore stalls on gRPC flow control instead of growing server buffers.
+// Command failures are reported per response and do not end the stream.
+func (s *Server) Pipeline(stream cachev1alpha.CacheService_PipelineServer) error {
+	ctx := stream.Context()
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(s.execPipelineCommand(ctx, req)); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) execPipelineCommand(ctx context.Context, req *cachev1alpha.PipelineRequest) *cachev1alpha.PipelineResponse {
+	res := &cachev1alpha.PipelineResponse{Tag: req.Tag}
+
+	var err error
+	switch cmd := req.Command.(type) {
+	case *cachev1alpha.PipelineRequest_Get:
+		var r *cachev1alpha.GetResponse
+		if r, err = s.Get(ctx, cmd.Get); err == nil {
+			res.Result = &cachev1alpha.PipelineResponse_Get{Get: r}
+		}
+	case *cachev1alpha.PipelineRequest_Set:
+		var r *cachev1alpha.SetResponse
+		if r, err = s.Set(ctx, cmd.Set); err == nil {
+			res.Result = &cachev1alpha.PipelineResponse_Set{Set: r}
+		}
+	case *cachev1alpha.PipelineRequest_Delete:
+		var r *cachev1alpha.DeleteResponse
+		if r, err = s.Delete(ctx, cmd.Delete); err == nil {
+			res.Result = &cachev1alpha.PipelineResponse_Delete{Delete: r}
+		}
+	case *cachev1alpha.PipelineRequest_Incr:
+		var r *cachev1alpha.IncrResponse
+		if r, err = s.Incr(ctx, cmd.Incr); err == nil {
+			res.Result = &cachev1alpha.PipelineResponse_Incr{Incr: r}
+		}
+	default:
+		err = status.Error(codes.InvalidArgument, "pipeline command must not be empty")
+	}
+
+	if err != nil {
+		st := status.Convert(err)
+		res.Code = int32(st.Code())
+		res.Error = st.Message()
+	}
+	return res
+}
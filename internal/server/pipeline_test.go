@@ -0,0 +1,85 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+func TestPipeline(t *testing.T) {
+	s := NewTestServer(t)
+	client := NewTestClient(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Pipeline(ctx)
+	require.NoError(t, err)
+
+	cmds := []*cachev1alpha.PipelineRequest{
+		{Tag: 1, Command: &cachev1alpha.PipelineRequest_Set{Set: &cachev1alpha.SetRequest{Key: "a", Value: []byte("1")}}},
+		{Tag: 2, Command: &cachev1alpha.PipelineRequest_Incr{Incr: &cachev1alpha.IncrRequest{Key: "a", Delta: 41}}},
+		{Tag: 3, Command: &cachev1alpha.PipelineRequest_Get{Get: &cachev1alpha.GetRequest{Key: "a"}}},
+		{Tag: 4, Command: &cachev1alpha.PipelineRequest_Get{Get: &cachev1alpha.GetRequest{Key: "missing"}}},
+		{Tag: 5, Command: &cachev1alpha.PipelineRequest_Delete{Delete: &cachev1alpha.DeleteRequest{Key: "a"}}},
+		{Tag: 6},
+	}
+	for _, cmd := range cmds {
+		require.NoError(t, stream.Send(cmd))
+	}
+	require.NoError(t, stream.CloseSend())
+
+	var results []*cachev1alpha.PipelineResponse
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		results = append(results, res)
+	}
+	require.Len(t, results, len(cmds))
+
+	for i, res := range results {
+		assert.Equal(t, cmds[i].Tag, res.Tag)
+	}
+	assert.True(t, results[0].GetSet().Success)
+	assert.Equal(t, int64(42), results[1].GetIncr().Value)
+	assert.Equal(t, []byte("42"), results[2].GetGet().Value)
+	assert.Equal(t, int32(codes.NotFound), results[3].Code)
+	assert.True(t, results[4].GetDelete().Success)
+	assert.Equal(t, int32(codes.InvalidArgument), results[5].Code)
+}
+
+func TestIncrNotInteger(t *testing.T) {
+	s := NewTestServer(t)
+	ctx := context.Background()
+
+	_, err := s.Set(ctx, &cachev1alpha.SetRequest{Key: "name", Value: []byte("abc")})
+	require.NoError(t, err)
+
+	_, err = s.Incr(ctx, &cachev1alpha.IncrRequest{Key: "name", Delta: 1})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/patrostkowski/protocache/internal/glob"
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+const pubsubSubscriberBuffer = 256
+
+type pubsubSubscriber struct {
+	messages chan *cachev1alpha.PubSubMessage
+	channels []string
+	patterns []string
+}
+
+// pubsub routes published messages to channel and pattern subscribers.
+// Delivery is fire-and-forget: a message is dropped for a subscriber
+// whose buffer is full.
+type pubsub struct {
+	mu       sync.RWMutex
+	channels map[string]map[*pubsubSubscriber]struct{}
+	patterns map[*pubsubSubscriber]struct{}
+	closed   chan struct{}
+
+	closeOnce sync.Once
+}
+
+func newPubSub() *pubsub {
+	return &pubsub{
+		channels: make(map[string]map[*pubsubSubscriber]struct{}),
+		patterns: make(map[*pubsubSubscriber]struct{}),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (ps *pubsub) subscribe(channels, patterns []string) *pubsubSubscriber {
+	sub := &pubsubSubscriber{
+		messages: make(chan *cachev1alpha.PubSubMessage, pubsubSubscriberBuffer),
+		channels: channels,
+		patterns: patterns,
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for _, channel := range channels {
+		if ps.channels[channel] == nil {
+			ps.channels[channel] = make(map[*pubsubSubscriber]struct{})
+		}
+		ps.channels[channel][sub] = struct{}{}
+	}
+	if len(patterns) > 0 {
+		ps.patterns[sub] = struct{}{}
+	}
+
+	PubSubSubscriptions.WithLabelValues("channel").Add(float64(len(channels)))
+	PubSubSubscriptions.WithLabelValues("pattern").Add(float64(len(patterns)))
+	return sub
+}
+
+func (ps *pubsub) unsubscribe(sub *pubsubSubscriber) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for _, channel := range sub.channels {
+		delete(ps.channels[channel], sub)
+		if len(ps.channels[channel]) == 0 {
+			delete(ps.channels, channel)
+		}
+	}
+	delete(ps.patterns, sub)
+
+	PubSubSubscriptions.WithLabelValues("channel").Sub(float64(len(sub.channels)))
+	PubSubSubscriptions.WithLabelValues("pattern").Sub(float64(len(sub.patterns)))
+}
+
+// publish delivers payload to every matching subscription and returns
+// the number of subscriptions it was delivered to.
+func (ps *pubsub) publish(channel string, payload []byte) int64 {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	var receivers int64
+	deliver := func(sub *pubsubSubscriber, msg *cachev1alpha.PubSubMessage) {
+		select {
+		case sub.messages <- msg:
+			receivers++
+		default:
+			PubSubDropped.Inc()
+		}
+	}
+
+	for sub := range ps.channels[channel] {
+		deliver(sub, &cachev1alpha.PubSubMessage{Channel: channel, Payload: payload})
+	}
+	for sub := range ps.patterns {
+		for _, pattern := range sub.patterns {
+			if glob.Match(pattern, channel) {
+				deliver(sub, &cachev1alpha.PubSubMessage{Channel: channel, Pattern: pattern, Payload: payload})
+			}
+		}
+	}
+	return receivers
+}
+
+func (ps *pubsub) close() {
+	ps.closeOnce.Do(func() { close(ps.closed) })
+}
+
+func (s *Server) Publish(ctx context.Context, req *cachev1alpha.PublishRequest) (*cachev1alpha.PublishResponse, error) {
+	if req.Channel == "" {
+		return nil, status.Error(codes.InvalidArgument, "channel must not be empty")
+	}
+
+	PubSubPublished.Inc()
+	return &cachev1alpha.PublishResponse{Receivers: s.pubsub.publish(req.Channel, req.Payload)}, nil
+}
+
+func (s *Server) Subscribe(req *cachev1alpha.SubscribeRequest, stream cachev1alpha.CacheService_SubscribeServer) error {
+	if len(req.Channels) == 0 && len(req.Patterns) == 0 {
+		return status.Error(codes.InvalidArgument, "at least one channel or pattern must be subscribed")
+	}
+
+	sub := s.pubsub.subscribe(req.Channels, req.Patterns)
+	defer s.pubsub.unsubscribe(sub)
+
+	PubSubSubscribers.Inc()
+	defer PubSubSubscribers.Dec()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.pubsub.closed:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case msg := <-sub.messages:
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
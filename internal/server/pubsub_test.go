@@ -0,0 +1,109 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+func waitForPubSubSubscriptions(t *testing.T, ps *pubsub, channels, patterns int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		ps.mu.RLock()
+		defer ps.mu.RUnlock()
+		return len(ps.channels) == channels && len(ps.patterns) == patterns
+	}, time.Second, time.Millisecond)
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	s := NewTestServer(t)
+	client := NewTestClient(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Subscribe(ctx, &cachev1alpha.SubscribeRequest{
+		Channels: []string{"invalidate"},
+		Patterns: []string{"presence:*"},
+	})
+	require.NoError(t, err)
+	waitForPubSubSubscriptions(t, s.pubsub, 1, 1)
+
+	res, err := client.Publish(ctx, &cachev1alpha.PublishRequest{Channel: "other", Payload: []byte("x")})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), res.Receivers)
+
+	res, err = client.Publish(ctx, &cachev1alpha.PublishRequest{Channel: "invalidate", Payload: []byte("user:1")})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), res.Receivers)
+
+	res, err = client.Publish(ctx, &cachev1alpha.PublishRequest{Channel: "presence:42", Payload: []byte("online")})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), res.Receivers)
+
+	msg, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "invalidate", msg.Channel)
+	assert.Empty(t, msg.Pattern)
+	assert.Equal(t, []byte("user:1"), msg.Payload)
+
+	msg, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "presence:42", msg.Channel)
+	assert.Equal(t, "presence:*", msg.Pattern)
+	assert.Equal(t, []byte("online"), msg.Payload)
+
+	keys := s.store.List()
+	assert.Empty(t, keys)
+}
+
+func TestPublish_EmptyChannel(t *testing.T) {
+	s := NewTestServer(t)
+
+	_, err := s.Publish(context.Background(), &cachev1alpha.PublishRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestSubscribe_RequiresChannels(t *testing.T) {
+	s := NewTestServer(t)
+	client := NewTestClient(t, s)
+
+	stream, err := client.Subscribe(context.Background(), &cachev1alpha.SubscribeRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestPubSub_DropsForSlowSubscriber(t *testing.T) {
+	ps := newPubSub()
+	sub := ps.subscribe([]string{"ch"}, nil)
+
+	for i := 0; i < pubsubSubscriberBuffer; i++ {
+		assert.Equal(t, int64(1), ps.publish("ch", []byte("m")))
+	}
+	assert.Equal(t, int64(0), ps.publish("ch", []byte("m")))
+
+	ps.unsubscribe(sub)
+	assert.Empty(t, ps.channels)
+}
@@ -29,21 +29,35 @@ import (
 	"google.golang.org/grpc/reflection"
 
 	"github.com/patrostkowski/protocache/internal/config"
+	"github.com/patrostkowski/protocache/internal/keyring"
 	"github.com/patrostkowski/protocache/internal/logger"
+	"github.com/patrostkowski/protocache/internal/snapshot"
 	"github.com/patrostkowski/protocache/internal/store"
+	"github.com/patrostkowski/protocache/internal/writebehind"
 	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
 )
 
 type Server struct {
 	cachev1alpha.UnimplementedCacheServiceServer
 
-	store      store.Store
-	config     *config.Config
-	listener   *net.Listener
-	grpcServer *grpc.Server
-	httpServer *http.Server
-	metrics    *grpcprom.ServerMetrics
-	registry   prometheus.Registerer
+	store       store.Store
+	leases      *leaseTable
+	loader      *readThroughLoader
+	writeBehind *writebehind.Pipeline
+	events      *eventBroker
+	pubsub      *pubsub
+	tracking    *trackingTable
+	snapshots   *snapshotter
+	aof         *appendOnlyFile
+	keys        *keyring.Keyring
+	retained    *snapshot.DirSink
+	sinks       []namedSink
+	config      *config.Config
+	listener    *net.Listener
+	grpcServer  *grpc.Server
+	httpServer  *http.Server
+	metrics     *grpcprom.ServerMetrics
+	registry    prometheus.Registerer
 }
 
 func NewServer(config *config.Config, reg prometheus.Registerer) *Server {
@@ -51,12 +65,25 @@ func NewServer(config *config.Config, reg prometheus.Registerer) *Server {
 		reg = prometheus.DefaultRegisterer
 	}
 	store := store.NewStore(config.GetStoreEngine(), config.GetEvictionPolicy())
-	return &Server{
-		store:    store,
-		config:   config,
-		registry: reg,
-		metrics:  grpcprom.NewServerMetrics(),
+	events := newEventBroker()
+	tracking := newTrackingTable()
+	snapshots := newSnapshotter(config.GetSnapshotAfterWrites())
+	aof := newAppendOnlyFile()
+	s := &Server{
+		store:     store,
+		events:    events,
+		pubsub:    newPubSub(),
+		tracking:  tracking,
+		snapshots: snapshots,
+		aof:       aof,
+		leases:    newLeaseTable(config.GetLeaseTTL()),
+		config:    config,
+		registry:  reg,
+		metrics:   grpcprom.NewServerMetrics(),
 	}
+	store.SetEventHandler(fanOutEvents(events.publish, tracking.invalidate, snapshots.recordWrite, aof.record, s.forwardWrite))
+	store.SetCommitHandler(aof.commit)
+	return s
 }
 
 func (s *Server) initGRPCServer() error {
@@ -78,14 +105,20 @@ func (s *Server) initGRPCServer() error {
 	}
 
 	opts = append(opts,
+		grpc.MaxRecvMsgSize(s.config.GetMaxRecvMsgSize()),
 		grpc.ChainUnaryInterceptor(
 			s.metrics.UnaryServerInterceptor(),
 			LoggingUnaryInterceptor(),
 		),
+		grpc.ChainStreamInterceptor(
+			s.metrics.StreamServerInterceptor(),
+			LoggingStreamInterceptor(),
+		),
 	)
 
 	s.grpcServer = grpc.NewServer(opts...)
 	cachev1alpha.RegisterCacheServiceServer(s.grpcServer, s)
+	cachev1alpha.RegisterAdminServiceServer(s.grpcServer, &adminService{s: s})
 	s.metrics.InitializeMetrics(s.grpcServer)
 	reflection.Register(s.grpcServer)
 
@@ -110,6 +143,36 @@ func (s *Server) initHTTPServer() error {
 	return nil
 }
 
+func (s *Server) initLoader() error {
+	cfg := s.config.GetLoaderConfig()
+	if cfg == nil {
+		return nil
+	}
+
+	loader, err := newReadThroughLoader(cfg)
+	if err != nil {
+		return err
+	}
+	s.loader = loader
+	logger.Info("Read-through loader initialized", "address", cfg.Address, "ttl", cfg.TTL)
+	return nil
+}
+
+func (s *Server) initWriteBehind() error {
+	cfg := s.config.GetWriteBehindConfig()
+	if cfg == nil {
+		return nil
+	}
+
+	pipeline, err := writebehind.NewPipelineFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	s.writeBehind = pipeline
+	logger.Info("Write-behind pipeline initialized", "sink", cfg.Sink, "batch_size", cfg.BatchSize)
+	return nil
+}
+
 func (s *Server) Init() error {
 	s.metrics = grpcprom.NewServerMetrics()
 	if err := s.registry.Register(s.metrics); err != nil {
@@ -128,6 +191,21 @@ func (s *Server) Init() error {
 	}
 	logger.Debug("HTTP server initialized successfully")
 
+	if err := s.initLoader(); err != nil {
+		logger.Error("Loader initialization failed", "error", err)
+		return err
+	}
+
+	if err := s.initEncryption(); err != nil {
+		logger.Error("Encryption initialization failed", "error", err)
+		return err
+	}
+
+	if err := s.initSnapshotSinks(); err != nil {
+		logger.Error("Snapshot sink initialization failed", "error", err)
+		return err
+	}
+
 	if s.config.IsMemoryStoreDumpEnabled() {
 		logger.Info("Memory store dump is enabled. Attempting to restore from disk")
 		if err := s.ReadPersistedMemoryStore(); err != nil {
@@ -137,6 +215,21 @@ func (s *Server) Init() error {
 		logger.Info("Successfully read memory store dump into memory")
 	}
 
+	if err := s.initAppendOnly(); err != nil {
+		logger.Error("Append-only file initialization failed", "error", err)
+		return err
+	}
+
+	// Started last, so that restoring the snapshot and replaying the
+	// append-only file is not written back to the sink.
+	if err := s.initWriteBehind(); err != nil {
+		logger.Error("Write-behind initialization failed", "error", err)
+		return err
+	}
+
+	// Keys loaded from disk are already persisted and must not count
+	// towards snapshot_after_writes.
+	s.snapshots.reset()
 	return nil
 }
 
@@ -171,6 +264,14 @@ func (s *Server) Start(ctx context.Context) error {
 		errCh <- s.startGRPCServer()
 	}()
 
+	go s.runExpirationLoop(ctx)
+	if s.config.IsMemoryStoreDumpEnabled() {
+		go s.runSnapshotLoop(ctx)
+	}
+	if s.config.IsAppendOnlyEnabled() {
+		go s.runAOFLoop(ctx)
+	}
+
 	select {
 	case <-ctx.Done():
 		logger.Info("Shutdown signal received")
@@ -191,14 +292,32 @@ func (s *Server) Shutdown() error {
 	})
 	defer shutdownTimer.Stop()
 
+	s.events.close()
+	s.pubsub.close()
 	s.grpcServer.GracefulStop()
 
 	if err := s.httpServer.Shutdown(context.Background()); err != nil {
 		logger.Error("Error shutting down HTTP server", "error", err)
 	}
 
+	if s.loader != nil {
+		if err := s.loader.close(); err != nil {
+			logger.Error("Error closing loader connection", "error", err)
+		}
+	}
+
+	if s.writeBehind != nil {
+		if err := s.writeBehind.Close(); err != nil {
+			logger.Error("Error closing write-behind pipeline", "error", err)
+		}
+	}
+
+	if err := s.aof.close(); err != nil {
+		logger.Error("Error closing append-only file", "error", err)
+	}
+
 	if s.config.IsMemoryStoreDumpEnabled() {
-		if err := s.PersistMemoryStore(); err != nil {
+		if err := s.Snapshot("shutdown"); err != nil {
 			logger.Error("Failed to persist memory store", "error", err)
 			return err
 		}
@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/patrostkowski/protocache/internal/config"
+	"github.com/patrostkowski/protocache/internal/snapshot"
 	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -168,6 +169,53 @@ func TestServerInit_ReadPersistedMemoryStoreFails(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestReadPersistedMemoryStore_FallsBackToPrevious(t *testing.T) {
+	cfg := defaultConfig(t.TempDir())
+	ctx := context.Background()
+
+	s1 := NewServer(cfg, DefaultPrometheusRegistry())
+	_, err := s1.Set(ctx, &v1alpha.SetRequest{Key: "foo", Value: []byte("old")})
+	require.NoError(t, err)
+	require.NoError(t, s1.PersistMemoryStore())
+	_, err = s1.Set(ctx, &v1alpha.SetRequest{Key: "foo", Value: []byte("new")})
+	require.NoError(t, err)
+	require.NoError(t, s1.PersistMemoryStore())
+
+	// Simulate a torn write of the current snapshot.
+	path := cfg.MemoryDumpFileFullPath()
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, raw[:len(raw)/2], 0o600))
+
+	s2 := NewServer(cfg, DefaultPrometheusRegistry())
+	require.NoError(t, s2.ReadPersistedMemoryStore())
+
+	resp, err := s2.Get(ctx, &v1alpha.GetRequest{Key: "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("old"), resp.Value)
+}
+
+func TestReadPersistedMemoryStore_OnlyPrevious(t *testing.T) {
+	cfg := defaultConfig(t.TempDir())
+	ctx := context.Background()
+
+	s1 := NewServer(cfg, DefaultPrometheusRegistry())
+	_, err := s1.Set(ctx, &v1alpha.SetRequest{Key: "foo", Value: []byte("bar")})
+	require.NoError(t, err)
+	require.NoError(t, s1.PersistMemoryStore())
+
+	// A crash between the two renames leaves only the previous snapshot.
+	path := cfg.MemoryDumpFileFullPath()
+	require.NoError(t, os.Rename(path, path+snapshot.PreviousSuffix))
+
+	s2 := NewServer(cfg, DefaultPrometheusRegistry())
+	require.NoError(t, s2.ReadPersistedMemoryStore())
+
+	resp, err := s2.Get(ctx, &v1alpha.GetRequest{Key: "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("bar"), resp.Value)
+}
+
 func generateTestCertAndKey(certPath, keyPath string) error {
 	// Generate private key
 	priv, err := rsa.GenerateKey(rand.Reader, 2048)
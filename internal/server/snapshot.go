@@ -0,0 +1,160 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/patrostkowski/protocache/internal/logger"
+	"github.com/patrostkowski/protocache/internal/store"
+)
+
+// snapshotInfo describes the outcome of the most recent snapshot.
+type snapshotInfo struct {
+	Time     time.Time
+	Duration time.Duration
+	Size     int64
+	Err      error
+}
+
+// snapshotter serializes memory store dumps and counts writes for the
+// after-N-writes trigger.
+type snapshotter struct {
+	mu        sync.Mutex
+	writes    atomic.Int64
+	threshold int64
+	trigger   chan struct{}
+
+	lastMu sync.RWMutex
+	last   snapshotInfo
+}
+
+func newSnapshotter(afterWrites int) *snapshotter {
+	return &snapshotter{
+		threshold: int64(afterWrites),
+		trigger:   make(chan struct{}, 1),
+	}
+}
+
+// recordWrite is registered as a store event handler. It must not block,
+// so it only queues a snapshot request.
+func (sn *snapshotter) recordWrite(e store.Event) {
+	switch e.Type {
+	case store.EventSet, store.EventDelete, store.EventClear:
+	default:
+		return
+	}
+	if n := sn.writes.Add(1); sn.threshold > 0 && n >= sn.threshold {
+		sn.request()
+	}
+}
+
+// reset forgets the writes counted so far and any queued request.
+func (sn *snapshotter) reset() {
+	sn.writes.Store(0)
+	select {
+	case <-sn.trigger:
+	default:
+	}
+}
+
+// request queues a background snapshot unless one is already queued.
+func (sn *snapshotter) request() {
+	select {
+	case sn.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (sn *snapshotter) lastSnapshot() snapshotInfo {
+	sn.lastMu.RLock()
+	defer sn.lastMu.RUnlock()
+	return sn.last
+}
+
+// Snapshot writes the memory store dump, waiting for a snapshot already
+// in progress to finish first.
+func (s *Server) Snapshot(reason string) error {
+	sn := s.snapshots
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+
+	sn.writes.Store(0)
+	start := time.Now()
+	path, err := s.persistMemoryStore(start)
+	info := snapshotInfo{Time: start, Duration: time.Since(start), Err: err}
+	if err == nil {
+		if fi, statErr := os.Stat(path); statErr == nil {
+			info.Size = fi.Size()
+		}
+	}
+
+	sn.lastMu.Lock()
+	sn.last = info
+	sn.lastMu.Unlock()
+
+	SnapshotDuration.Set(info.Duration.Seconds())
+	if err != nil {
+		SnapshotLastError.Set(1)
+		SnapshotErrors.Inc()
+		logger.Error("Snapshot failed", "reason", reason, "error", err)
+		return err
+	}
+	SnapshotLastError.Set(0)
+	SnapshotLastSuccess.Set(float64(info.Time.Unix()))
+	SnapshotSize.Set(float64(info.Size))
+	logger.Info("Snapshot written", "reason", reason, "duration", info.Duration, "size", info.Size)
+
+	s.shipSnapshot(path, s.snapshotName(start))
+	return nil
+}
+
+// runSnapshotLoop takes snapshots on the configured interval, after the
+// configured number of writes and on SIGUSR1, until ctx is cancelled.
+func (s *Server) runSnapshotLoop(ctx context.Context) {
+	var tick <-chan time.Time
+	if interval := s.config.GetSnapshotInterval(); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	signals, stopSignals := notifySnapshotSignal()
+	defer stopSignals()
+
+	logger.Info("Starting snapshot loop",
+		"interval", s.config.GetSnapshotInterval(),
+		"after_writes", s.config.GetSnapshotAfterWrites(),
+	)
+
+	for {
+		var reason string
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			reason = "interval"
+		case <-s.snapshots.trigger:
+			reason = "writes"
+		case <-signals:
+			reason = "signal"
+		}
+		_ = s.Snapshot(reason)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+
+package server
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot_Signal(t *testing.T) {
+	// Keep SIGUSR1 from terminating the test binary before the snapshot
+	// loop has installed its own handler.
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGUSR1)
+	defer signal.Stop(guard)
+
+	cfg := defaultConfig(t.TempDir())
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.runSnapshotLoop(ctx)
+
+	require.Eventually(t, func() bool {
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+		_, err := os.Stat(cfg.MemoryDumpFileFullPath())
+		return err == nil
+	}, 2*time.Second, 20*time.Millisecond)
+}
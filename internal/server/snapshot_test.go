@@ -0,0 +1,108 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+func TestSnapshot_AfterWrites(t *testing.T) {
+	cfg := defaultConfig(t.TempDir())
+	cfg.StoreConfig.SnapshotAfterWrites = 3
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.runSnapshotLoop(ctx)
+
+	for i := 0; i < 3; i++ {
+		_, err := s.Set(ctx, &v1alpha.SetRequest{Key: fmt.Sprintf("k%d", i), Value: []byte("v")})
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		return s.snapshots.lastSnapshot().Size > 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	info := s.snapshots.lastSnapshot()
+	assert.NoError(t, info.Err)
+	assert.Equal(t, float64(0), testutil.ToFloat64(SnapshotLastError))
+	assert.Equal(t, float64(info.Size), testutil.ToFloat64(SnapshotSize))
+
+	s2 := NewServer(cfg, DefaultPrometheusRegistry())
+	require.NoError(t, s2.ReadPersistedMemoryStore())
+	assert.Len(t, s2.store.List(), 3)
+}
+
+func TestSnapshot_AfterWritesIgnoresStartupRestore(t *testing.T) {
+	cfg := defaultConfig(t.TempDir())
+	cfg.StoreConfig.SnapshotAfterWrites = 3
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+	for i := 0; i < 3; i++ {
+		_, err := s.Set(context.Background(), &v1alpha.SetRequest{Key: fmt.Sprintf("k%d", i), Value: []byte("v")})
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.PersistMemoryStore())
+
+	s2 := NewServer(cfg, DefaultPrometheusRegistry())
+	require.NoError(t, s2.Init())
+	t.Cleanup(func() { _ = s2.Shutdown() })
+
+	assert.Len(t, s2.store.List(), 3)
+	assert.Zero(t, s2.snapshots.writes.Load())
+	assert.Empty(t, s2.snapshots.trigger, "restored keys must not trigger a snapshot")
+}
+
+func TestSnapshot_Interval(t *testing.T) {
+	cfg := defaultConfig(t.TempDir())
+	cfg.StoreConfig.SnapshotInterval = 20 * time.Millisecond
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.runSnapshotLoop(ctx)
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(cfg.MemoryDumpFileFullPath())
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestSnapshot_Error(t *testing.T) {
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(blocker, nil, 0o600))
+
+	// The dump directory cannot be created below a regular file.
+	cfg := defaultConfig(filepath.Join(blocker, "dump.gob.gz"))
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+
+	errorsBefore := testutil.ToFloat64(SnapshotErrors)
+	assert.Error(t, s.Snapshot("test"))
+	assert.Error(t, s.snapshots.lastSnapshot().Err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(SnapshotLastError))
+	assert.Equal(t, errorsBefore+1, testutil.ToFloat64(SnapshotErrors))
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/patrostkowski/protocache/internal/logger"
+	"github.com/patrostkowski/protocache/internal/snapshot"
+)
+
+type namedSink struct {
+	name string
+	sink snapshot.Sink
+}
+
+// initSnapshotSinks sets up the sinks every snapshot is shipped to and,
+// when snapshot retention is configured, s.retained, which keeps the
+// timestamped snapshots next to where the single dump file would be.
+func (s *Server) initSnapshotSinks() error {
+	if n := s.config.GetSnapshotRetention(); n > 0 {
+		s.retained = snapshot.NewDirSink(filepath.Dir(s.config.MemoryDumpFileFullPath()), n)
+		logger.Info("Keeping timestamped snapshots", "retention", n)
+	}
+
+	seen := make(map[string]bool)
+	for _, cfg := range s.config.GetSnapshotSinks() {
+		if seen[cfg.Name] {
+			return fmt.Errorf("duplicate snapshot sink %q", cfg.Name)
+		}
+		seen[cfg.Name] = true
+
+		sink, err := snapshot.NewSink(&cfg)
+		if err != nil {
+			return fmt.Errorf("snapshot sink %q: %w", cfg.Name, err)
+		}
+		s.sinks = append(s.sinks, namedSink{name: cfg.Name, sink: sink})
+		logger.Info("Snapshot sink initialized", "name", cfg.Name, "type", cfg.Type)
+	}
+	return nil
+}
+
+// snapshotSink returns the sink called name, or the local snapshots if
+// name is empty. It returns nil if there is no such sink.
+func (s *Server) snapshotSink(name string) snapshot.Sink {
+	if name == "" {
+		if s.retained == nil {
+			return nil
+		}
+		return s.retained
+	}
+	for _, ns := range s.sinks {
+		if ns.name == name {
+			return ns.sink
+		}
+	}
+	return nil
+}
+
+// snapshotName is the name the snapshot taken at t is stored under,
+// locally and in every sink.
+func (s *Server) snapshotName(t time.Time) string {
+	return snapshot.Name(filepath.Base(s.config.MemoryDumpFileFullPath()), t)
+}
+
+// shipSnapshot copies the snapshot at path to every sink. A sink that
+// fails is logged and counted but does not fail the snapshot, which is
+// already safe on local disk.
+func (s *Server) shipSnapshot(path, name string) {
+	for _, ns := range s.sinks {
+		if err := putSnapshot(ns.sink, path, name); err != nil {
+			SnapshotSinkErrors.WithLabelValues(ns.name).Inc()
+			logger.Error("Failed to ship snapshot", "sink", ns.name, "name", name, "error", err)
+			continue
+		}
+		logger.Info("Snapshot shipped", "sink", ns.name, "name", name)
+	}
+}
+
+func putSnapshot(sink snapshot.Sink, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return sink.Put(context.Background(), name, f)
+}
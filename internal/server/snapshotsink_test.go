@@ -0,0 +1,269 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/patrostkowski/protocache/internal/snapshot"
+	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+// snapshotBucket stands in for an HTTP object store snapshots are PUT to.
+func snapshotBucket(t *testing.T) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			objects[filepath.Base(r.URL.Path)] = data
+		case http.MethodGet:
+			data, ok := objects[filepath.Base(r.URL.Path)]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(data)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	names := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		var names []string
+		for name := range objects {
+			names = append(names, name)
+		}
+		return names
+	}
+	return srv, names
+}
+
+func newSnapshotSinkServer(t *testing.T, cfg func(*v1alpha.StoreConfig)) *Server {
+	t.Helper()
+	c := defaultConfig(t.TempDir())
+	cfg(c.StoreConfig)
+	s := NewServer(c, DefaultPrometheusRegistry())
+	require.NoError(t, s.initSnapshotSinks())
+	return s
+}
+
+func TestSnapshot_Retention(t *testing.T) {
+	ctx := context.Background()
+	s := newSnapshotSinkServer(t, func(c *v1alpha.StoreConfig) { c.SnapshotRetention = 2 })
+
+	for _, value := range []string{"1", "2", "3"} {
+		_, err := s.Set(ctx, &v1alpha.SetRequest{Key: "a", Value: []byte(value)})
+		require.NoError(t, err)
+		require.NoError(t, s.Snapshot("test"))
+	}
+
+	entries, err := s.retained.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.True(t, entries[0].Time.Equal(s.snapshots.lastSnapshot().Time))
+	assert.Equal(t, s.snapshots.lastSnapshot().Size, entries[0].Size)
+	assert.NoFileExists(t, s.config.MemoryDumpFileFullPath())
+
+	restarted := NewServer(s.config, DefaultPrometheusRegistry())
+	require.NoError(t, restarted.initSnapshotSinks())
+	require.NoError(t, restarted.ReadPersistedMemoryStore())
+	value, err := restarted.store.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("3"), value)
+}
+
+func TestSnapshot_RetentionSkipsCorruptSnapshot(t *testing.T) {
+	ctx := context.Background()
+	s := newSnapshotSinkServer(t, func(c *v1alpha.StoreConfig) { c.SnapshotRetention = 3 })
+
+	for _, value := range []string{"1", "2"} {
+		_, err := s.Set(ctx, &v1alpha.SetRequest{Key: "a", Value: []byte(value)})
+		require.NoError(t, err)
+		require.NoError(t, s.Snapshot("test"))
+	}
+	entries, err := s.retained.List(ctx)
+	require.NoError(t, err)
+	newest := filepath.Join(filepath.Dir(s.config.MemoryDumpFileFullPath()), entries[0].Name)
+	require.NoError(t, os.WriteFile(newest, []byte("garbage"), 0o600))
+
+	restarted := NewServer(s.config, DefaultPrometheusRegistry())
+	require.NoError(t, restarted.initSnapshotSinks())
+	require.NoError(t, restarted.ReadPersistedMemoryStore())
+	value, _ := restarted.store.Get("a")
+	assert.Equal(t, []byte("1"), value)
+}
+
+func TestSnapshot_RetentionReadsSingleDumpFile(t *testing.T) {
+	ctx := context.Background()
+	cfg := defaultConfig(t.TempDir())
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+	_, err := s.Set(ctx, &v1alpha.SetRequest{Key: "a", Value: []byte("1")})
+	require.NoError(t, err)
+	require.NoError(t, s.PersistMemoryStore())
+
+	cfg.StoreConfig.SnapshotRetention = 2
+	restarted := NewServer(cfg, DefaultPrometheusRegistry())
+	require.NoError(t, restarted.initSnapshotSinks())
+	require.NoError(t, restarted.ReadPersistedMemoryStore())
+	value, _ := restarted.store.Get("a")
+	assert.Equal(t, []byte("1"), value)
+}
+
+func TestSnapshot_ShipsToSinks(t *testing.T) {
+	bucket, shipped := snapshotBucket(t)
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(failing.Close)
+	backups := t.TempDir()
+
+	s := newSnapshotSinkServer(t, func(c *v1alpha.StoreConfig) {
+		c.SnapshotSinks = []v1alpha.SnapshotSinkConfig{
+			{Type: v1alpha.HTTPSnapshotSink, URL: bucket.URL + "/backups"},
+			{Name: "nas", Type: v1alpha.DirSnapshotSink, Path: backups, Retention: 1},
+			{Name: "down", Type: v1alpha.HTTPSnapshotSink, URL: failing.URL},
+		}
+	})
+	before := testutil.ToFloat64(SnapshotSinkErrors.WithLabelValues("down"))
+
+	for range 2 {
+		require.NoError(t, s.Snapshot("test"))
+	}
+
+	last := s.snapshots.lastSnapshot()
+	name := snapshot.Name(filepath.Base(s.config.MemoryDumpFileFullPath()), last.Time)
+	assert.Len(t, shipped(), 2)
+	assert.Contains(t, shipped(), name)
+
+	entries, err := snapshot.NewDirSink(backups, 0).List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, name, entries[0].Name)
+	assert.Equal(t, last.Size, entries[0].Size)
+
+	assert.Equal(t, before+2, testutil.ToFloat64(SnapshotSinkErrors.WithLabelValues("down")))
+	assert.FileExists(t, s.config.MemoryDumpFileFullPath())
+}
+
+func TestSnapshotSinks_Duplicate(t *testing.T) {
+	cfg := defaultConfig(t.TempDir())
+	cfg.StoreConfig.SnapshotSinks = []v1alpha.SnapshotSinkConfig{
+		{Type: v1alpha.DirSnapshotSink, Path: t.TempDir()},
+		{Type: v1alpha.DirSnapshotSink, Path: t.TempDir()},
+	}
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+	assert.ErrorContains(t, s.initSnapshotSinks(), "duplicate")
+}
+
+func TestAdmin_ListAndRestoreSnapshot(t *testing.T) {
+	ctx := context.Background()
+	bucket, _ := snapshotBucket(t)
+	s := newSnapshotSinkServer(t, func(c *v1alpha.StoreConfig) {
+		c.SnapshotRetention = 5
+		c.SnapshotSinks = []v1alpha.SnapshotSinkConfig{
+			{Name: "offsite", Type: v1alpha.HTTPSnapshotSink, URL: bucket.URL},
+		}
+	})
+	admin := NewTestAdminClient(t, s)
+
+	var taken []int64
+	for _, value := range []string{"1", "2"} {
+		_, err := s.Set(ctx, &v1alpha.SetRequest{Key: "a", Value: []byte(value)})
+		require.NoError(t, err)
+		info, err := admin.Snapshot(ctx, &v1alpha.SnapshotRequest{})
+		require.NoError(t, err)
+		taken = append(taken, info.Timestamp)
+	}
+	_, err := s.Set(ctx, &v1alpha.SetRequest{Key: "b", Value: []byte("new")})
+	require.NoError(t, err)
+
+	list, err := admin.ListSnapshots(ctx, &v1alpha.ListSnapshotsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Snapshots, 2)
+	assert.Equal(t, taken[1], list.Snapshots[0].Timestamp)
+	assert.Equal(t, taken[0], list.Snapshots[1].Timestamp)
+
+	res, err := admin.RestoreSnapshot(ctx, &v1alpha.RestoreSnapshotRequest{
+		Timestamp: taken[0],
+		Mode:      v1alpha.RestoreMode_RESTORE_MODE_REPLACE,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), res.Keys)
+	value, _ := s.store.Get("a")
+	assert.Equal(t, []byte("1"), value)
+	_, err = s.store.Get("b")
+	assert.Error(t, err)
+
+	_, err = admin.RestoreSnapshot(ctx, &v1alpha.RestoreSnapshotRequest{
+		Timestamp: taken[1],
+		Mode:      v1alpha.RestoreMode_RESTORE_MODE_MERGE,
+		Sink:      "offsite",
+	})
+	require.NoError(t, err)
+	value, _ = s.store.Get("a")
+	assert.Equal(t, []byte("2"), value)
+
+	_, err = admin.ListSnapshots(ctx, &v1alpha.ListSnapshotsRequest{Sink: "offsite"})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	for _, req := range []*v1alpha.RestoreSnapshotRequest{
+		{Timestamp: taken[0] + 1, Mode: v1alpha.RestoreMode_RESTORE_MODE_REPLACE},
+		{Timestamp: taken[0] + 1, Mode: v1alpha.RestoreMode_RESTORE_MODE_REPLACE, Sink: "offsite"},
+		{Timestamp: taken[0], Mode: v1alpha.RestoreMode_RESTORE_MODE_REPLACE, Sink: "missing"},
+	} {
+		_, err := admin.RestoreSnapshot(ctx, req)
+		assert.Equal(t, codes.NotFound, status.Code(err), req.String())
+	}
+	_, err = admin.RestoreSnapshot(ctx, &v1alpha.RestoreSnapshotRequest{Timestamp: taken[0]})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	s.config.StoreConfig.MaxRestoreSize = 1
+	for _, sink := range []string{"", "offsite"} {
+		_, err = admin.RestoreSnapshot(ctx, &v1alpha.RestoreSnapshotRequest{
+			Timestamp: taken[0],
+			Mode:      v1alpha.RestoreMode_RESTORE_MODE_REPLACE,
+			Sink:      sink,
+		})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err), sink)
+	}
+	value, _ = s.store.Get("a")
+	assert.Equal(t, []byte("2"), value)
+}
+
+func TestAdmin_ListSnapshotsWithoutRetention(t *testing.T) {
+	s := newSnapshotSinkServer(t, func(*v1alpha.StoreConfig) {})
+	admin := NewTestAdminClient(t, s)
+
+	_, err := admin.ListSnapshots(context.Background(), &v1alpha.ListSnapshotsRequest{})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
@@ -15,95 +15,202 @@
 package server
 
 import (
-	"compress/gzip"
-	"encoding/gob"
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/patrostkowski/protocache/internal/config"
+	"github.com/patrostkowski/protocache/internal/keyring"
 	"github.com/patrostkowski/protocache/internal/logger"
+	"github.com/patrostkowski/protocache/internal/snapshot"
+	"github.com/patrostkowski/protocache/internal/store"
 )
 
-func encodeAndCompress(w io.Writer, store map[string][]byte) error {
-	gz := gzip.NewWriter(w)
-	defer gz.Close()
-	return gob.NewEncoder(gz).Encode(store)
-}
-
-func decodeAndDecompress(r io.Reader, store *map[string][]byte) error {
-	gz, err := gzip.NewReader(r)
+// initEncryption loads the keys that encrypt snapshots and the
+// append-only file at rest.
+func (s *Server) initEncryption() error {
+	keys, err := s.config.CreateKeyring()
 	if err != nil {
 		return err
 	}
-	defer gz.Close()
-	return gob.NewDecoder(gz).Decode(store)
+	s.keys = keys
+	if keys != nil {
+		logger.Info("Encryption at rest is enabled", "key_id", fmt.Sprintf("%08x", keys.Primary().ID))
+	}
+	return nil
 }
 
-func openStoreFileForWrite(path string) (io.WriteCloser, error) {
-	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+func (s *Server) snapshotOptions() snapshot.Options {
+	return snapshot.Options{Compression: s.config.GetSnapshotCompression(), Keys: s.keys}
 }
 
-func openStoreFileForRead(path string) (io.ReadCloser, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	info, err := f.Stat()
-	if err != nil {
-		f.Close()
-		return nil, err
+// snapshotOutdated reports whether a snapshot read with info should be
+// rewritten: it is in an older format, or not encrypted with the current
+// key.
+func (s *Server) snapshotOutdated(info snapshot.Info) bool {
+	if info.Format != snapshot.FormatCurrent {
+		return true
 	}
-	if info.Size() == 0 {
-		f.Close()
-		return nil, io.EOF
+	if s.keys == nil {
+		return false
 	}
-	return f, nil
+	return !info.Encrypted || info.KeyID != s.keys.Primary().ID
+}
+
+// isKeyError reports whether err means a snapshot cannot be decrypted
+// with the configured keys, as opposed to being damaged.
+func isKeyError(err error) bool {
+	return errors.Is(err, snapshot.ErrNoKey) || errors.Is(err, keyring.ErrUnknownKey)
 }
 
 func (s *Server) PersistMemoryStore() error {
+	_, err := s.persistMemoryStore(time.Now())
+	return err
+}
+
+// persistMemoryStore writes a snapshot taken at t and returns its path.
+// With snapshot retention each snapshot goes to its own timestamped file
+// and the oldest ones are removed; otherwise the dump file is replaced.
+func (s *Server) persistMemoryStore(t time.Time) (string, error) {
 	path := s.config.MemoryDumpFileFullPath()
+	if s.retained != nil {
+		path = filepath.Join(filepath.Dir(path), s.snapshotName(t))
+	}
 	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
 		logger.Error("Failed to create directory for memory store dump", "error", err.Error())
-		return err
+		return "", err
 	}
 
-	f, err := openStoreFileForWrite(path)
-	if err != nil {
-		logger.Error("Failed to open memory store dump file", "error", err.Error())
-		return err
+	if err := snapshot.WriteFile(path, s.store.Snapshot(), s.snapshotOptions()); err != nil {
+		logger.Error("Failed to write memory store dump", "error", err.Error())
+		return "", err
 	}
-	defer f.Close()
-
-	if err := encodeAndCompress(f, s.store.This()); err != nil {
-		logger.Error("Failed to encode and compress the memory store", "error", err.Error())
-		return err
+	if s.retained != nil {
+		if err := s.retained.Prune(); err != nil {
+			logger.Warn("Failed to remove old snapshots", "error", err.Error())
+		}
 	}
 
 	logger.Info("Successfully written memory store dump to file", "path", path)
-	return nil
+	return path, nil
 }
 
 func (s *Server) ReadPersistedMemoryStore() error {
-	thisStore := s.store.This()
-
-	path := s.config.MemoryDumpFileFullPath()
-	f, err := openStoreFileForRead(path)
+	records, info, path, err := s.readRetainedSnapshot()
+	if errors.Is(err, os.ErrNotExist) {
+		// Also covers enabling retention on a node that so far kept a
+		// single dump file.
+		records, info, path, err = s.readSnapshotFile()
+	}
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) || errors.Is(err, io.EOF) {
 			logger.Warn("Memory store dump file does not exist or is empty, starting with empty store")
 			return nil
 		}
-		logger.Error("Failed to open memory store dump file", "error", err.Error())
 		return err
 	}
-	defer f.Close()
 
-	if err := decodeAndDecompress(f, &thisStore); err != nil {
-		logger.Error("Failed to decode and decompress memory store dump file", "error", err.Error())
+	if err := s.store.Restore(records); err != nil {
+		logger.Error("Failed to restore memory store dump", "error", err.Error())
 		return err
 	}
+	logger.Info("Successfully read memory store dump into memory", "path", path, "size", len(records), "format", info.Format.String(), "encrypted", info.Encrypted)
 
-	logger.Info("Successfully read memory store dump into memory", "size", len(thisStore))
+	// Rewrite older formats and snapshots that are not encrypted with the
+	// current key right away, so the next restart depends on neither the
+	// legacy readers nor a retired key. The old file is left in place.
+	if s.snapshotOutdated(info) {
+		if err := s.Snapshot("migration"); err != nil {
+			logger.Error("Failed to migrate memory store dump", "format", info.Format.String(), "error", err.Error())
+			return err
+		}
+		logger.Info("Migrated memory store dump", "from", info.Format.String(), "encrypted", s.keys != nil, "path", path)
+	}
 	return nil
 }
+
+// readRetainedSnapshot reads the newest readable timestamped snapshot. It
+// returns os.ErrNotExist if retention is disabled or there is none.
+func (s *Server) readRetainedSnapshot() ([]store.Record, snapshot.Info, string, error) {
+	if s.retained == nil {
+		return nil, snapshot.Info{}, "", os.ErrNotExist
+	}
+	entries, err := s.retained.List(context.Background())
+	if err != nil {
+		logger.Error("Failed to list snapshots", "error", err.Error())
+		return nil, snapshot.Info{}, "", err
+	}
+
+	dir := filepath.Dir(s.config.MemoryDumpFileFullPath())
+	var firstErr error
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name)
+		records, info, err := snapshot.ReadFile(path, s.keys)
+		if err == nil {
+			if firstErr != nil {
+				logger.Warn("Restoring an older snapshot", "path", path)
+			}
+			return records, info, path, nil
+		}
+		if isKeyError(err) {
+			// Falling back to an older snapshot would silently lose data.
+			logger.Error("Memory store dump cannot be decrypted with the configured keys", "path", path, "error", err.Error())
+			return nil, snapshot.Info{}, "", err
+		}
+		logger.Warn("Snapshot is unreadable, trying the previous one", "path", path, "error", err.Error())
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		logger.Error("Failed to read memory store dump", "error", firstErr.Error())
+		return nil, snapshot.Info{}, "", firstErr
+	}
+	return nil, snapshot.Info{}, "", os.ErrNotExist
+}
+
+// readSnapshotFile reads the single dump file, falling back to the
+// previous snapshot and to the legacy file name.
+func (s *Server) readSnapshotFile() ([]store.Record, snapshot.Info, string, error) {
+	path := s.config.MemoryDumpFileFullPath()
+	records, info, err := snapshot.ReadFile(path, s.keys)
+	if isKeyError(err) {
+		// Falling back to an older snapshot would silently lose data.
+		logger.Error("Memory store dump cannot be decrypted with the configured keys", "path", path, "error", err.Error())
+		return nil, info, path, err
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) && !errors.Is(err, io.EOF) {
+		logger.Warn("Memory store dump is unreadable, trying the previous snapshot", "path", path, "error", err.Error())
+		prev, prevInfo, prevErr := snapshot.ReadFile(path+snapshot.PreviousSuffix, s.keys)
+		if prevErr != nil {
+			logger.Error("Failed to read memory store dump", "error", err.Error(), "previous_error", prevErr.Error())
+			return nil, info, path, err
+		}
+		return prev, prevInfo, path + snapshot.PreviousSuffix, nil
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		// A crash between the two renames in snapshot.WriteFile leaves only
+		// the previous snapshot.
+		records, info, err = snapshot.ReadFile(path+snapshot.PreviousSuffix, s.keys)
+		if err == nil {
+			return records, info, path + snapshot.PreviousSuffix, nil
+		}
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		if legacy := filepath.Join(filepath.Dir(path), config.LegacyMemoryDumpFileName); legacy != path {
+			records, info, err = snapshot.ReadFile(legacy, s.keys)
+			if err == nil {
+				logger.Info("Found memory store dump under its legacy name", "path", legacy)
+				return records, info, legacy, nil
+			}
+		}
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) && !errors.Is(err, io.EOF) {
+		logger.Error("Failed to read memory store dump", "error", err.Error())
+	}
+	return records, info, path, err
+}
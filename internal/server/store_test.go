@@ -16,69 +16,208 @@ package server
 
 import (
 	"bytes"
-	"io"
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
-)
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
 
-func TestEncodeAndDecodeStore(t *testing.T) {
-	original := map[string][]byte{
-		"foo": []byte("bar"),
-		"baz": []byte("qux"),
-	}
+	"github.com/patrostkowski/protocache/internal/config"
+	"github.com/patrostkowski/protocache/internal/keyring"
+	"github.com/patrostkowski/protocache/internal/snapshot"
+	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
 
-	var buf bytes.Buffer
+func TestPersistMemoryStore_KeepsMetadata(t *testing.T) {
+	cfg := defaultConfig(t.TempDir())
+	ctx := context.Background()
 
-	err := encodeAndCompress(&buf, original)
-	assert.NoError(t, err)
-	assert.Greater(t, buf.Len(), 0)
+	s1 := NewServer(cfg, DefaultPrometheusRegistry())
+	_, err := s1.Set(ctx, &v1alpha.SetRequest{Key: "ttl", Value: []byte("v"), HardTtl: durationpb.New(time.Hour)})
+	require.NoError(t, err)
+	_, err = s1.Set(ctx, &v1alpha.SetRequest{Key: "idle", Value: []byte("v"), IdleTimeout: durationpb.New(time.Minute)})
+	require.NoError(t, err)
+	require.NoError(t, s1.PersistMemoryStore())
 
-	var decoded map[string][]byte
-	err = decodeAndDecompress(&buf, &decoded)
-	assert.NoError(t, err)
+	s2 := NewServer(cfg, DefaultPrometheusRegistry())
+	require.NoError(t, s2.ReadPersistedMemoryStore())
 
-	assert.Equal(t, original, decoded)
+	got := make(map[string]int)
+	records := slices.Collect(s2.store.Snapshot())
+	for i, r := range records {
+		got[r.Key] = i
+	}
+	require.Len(t, records, 2)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), records[got["ttl"]].ExpiresAt, time.Minute)
+	assert.Equal(t, time.Minute, records[got["idle"]].IdleTimeout)
+	assert.NotZero(t, records[got["ttl"]].Version)
 }
 
-func TestOpenStoreFileForWriteAndRead(t *testing.T) {
+func TestReadPersistedMemoryStore_MigratesLegacyDump(t *testing.T) {
 	dir := t.TempDir()
-	path := filepath.Join(dir, "store.gob.gz")
+	cfg := defaultConfig(dir)
+	cfg.StoreConfig.MemoryDumpPath = dir
+	cfg.StoreConfig.MemoryDumpFileName = config.MemoryDumpFileName
+
+	legacy := filepath.Join(dir, config.LegacyMemoryDumpFileName)
+	f, err := os.Create(legacy)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	require.NoError(t, gob.NewEncoder(gz).Encode(map[string][]byte{"foo": []byte("bar")}))
+	require.NoError(t, gz.Close())
+	require.NoError(t, f.Close())
+
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+	require.NoError(t, s.ReadPersistedMemoryStore())
+
+	resp, err := s.Get(context.Background(), &v1alpha.GetRequest{Key: "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("bar"), resp.Value)
+
+	records, info, err := snapshot.ReadFile(cfg.MemoryDumpFileFullPath(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot.FormatCurrent, info.Format)
+	require.Len(t, records, 1)
+	assert.Equal(t, "foo", records[0].Key)
+
+	_, err = os.Stat(legacy)
+	assert.NoError(t, err, "legacy dump must be left in place")
+}
+
+func TestReadPersistedMemoryStore_MigratesOlderFormat(t *testing.T) {
+	cfg := defaultConfig(t.TempDir())
+	path := cfg.MemoryDumpFileFullPath()
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	require.NoError(t, gob.NewEncoder(gz).Encode(map[string][]byte{"foo": []byte("bar")}))
+	require.NoError(t, gz.Close())
+	require.NoError(t, f.Close())
 
-	w, err := openStoreFileForWrite(path)
-	assert.NoError(t, err)
-	defer w.Close()
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+	require.NoError(t, s.ReadPersistedMemoryStore())
 
-	testData := map[string][]byte{"k": []byte("v")}
-	err = encodeAndCompress(w, testData)
-	assert.NoError(t, err)
+	_, info, err := snapshot.ReadFile(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot.FormatCurrent, info.Format)
 
-	r, err := openStoreFileForRead(path)
-	assert.NoError(t, err)
-	defer r.Close()
+	_, info, err = snapshot.ReadFile(path+snapshot.PreviousSuffix, nil)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot.FormatLegacy, info.Format)
+}
 
-	var decoded map[string][]byte
-	err = decodeAndDecompress(r, &decoded)
-	assert.NoError(t, err)
+// withEncryption enables encryption with key files holding primary and
+// the decryption keys, each a key of repeated bytes.
+func withEncryption(t *testing.T, cfg *config.Config, primary byte, decryption ...byte) {
+	t.Helper()
+	dir := t.TempDir()
+	source := func(b byte) v1alpha.EncryptionKeySource {
+		path := filepath.Join(dir, fmt.Sprintf("key-%d", b))
+		key := hex.EncodeToString(bytes.Repeat([]byte{b}, keyring.KeySize))
+		require.NoError(t, os.WriteFile(path, []byte(key), 0o600))
+		return v1alpha.EncryptionKeySource{File: path}
+	}
 
-	assert.Equal(t, testData, decoded)
+	enc := &v1alpha.EncryptionConfig{Key: source(primary)}
+	for _, b := range decryption {
+		enc.DecryptionKeys = append(enc.DecryptionKeys, source(b))
+	}
+	cfg.StoreConfig.Encryption = enc
 }
 
-func TestOpenStoreFileForRead_FileNotExist(t *testing.T) {
-	_, err := openStoreFileForRead("nonexistent.gob.gz")
-	assert.Error(t, err)
-	assert.True(t, os.IsNotExist(err))
+func newEncryptedServer(t *testing.T, cfg *config.Config) *Server {
+	t.Helper()
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+	require.NoError(t, s.initEncryption())
+	return s
 }
 
-func TestOpenStoreFileForRead_EmptyFile(t *testing.T) {
-	f, err := os.CreateTemp("", "empty-*.gob.gz")
-	assert.NoError(t, err)
-	defer os.Remove(f.Name())
-	defer f.Close()
+func TestPersistMemoryStore_Encrypted(t *testing.T) {
+	cfg := defaultConfig(t.TempDir())
+	withEncryption(t, cfg, 1)
+	ctx := context.Background()
+
+	s1 := newEncryptedServer(t, cfg)
+	_, err := s1.Set(ctx, &v1alpha.SetRequest{Key: "ssn", Value: []byte("078-05-1120")})
+	require.NoError(t, err)
+	require.NoError(t, s1.PersistMemoryStore())
+
+	raw, err := os.ReadFile(cfg.MemoryDumpFileFullPath())
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "ssn")
+
+	s2 := newEncryptedServer(t, cfg)
+	require.NoError(t, s2.ReadPersistedMemoryStore())
+	resp, err := s2.Get(ctx, &v1alpha.GetRequest{Key: "ssn"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("078-05-1120"), resp.Value)
+}
+
+func TestReadPersistedMemoryStore_WrongKey(t *testing.T) {
+	cfg := defaultConfig(t.TempDir())
+	ctx := context.Background()
+
+	// The plaintext snapshot becomes the previous one and must not be
+	// used in place of the one that cannot be decrypted.
+	s1 := NewServer(cfg, DefaultPrometheusRegistry())
+	_, err := s1.Set(ctx, &v1alpha.SetRequest{Key: "foo", Value: []byte("bar")})
+	require.NoError(t, err)
+	require.NoError(t, s1.PersistMemoryStore())
+	withEncryption(t, cfg, 1)
+	s1 = newEncryptedServer(t, cfg)
+	require.NoError(t, s1.PersistMemoryStore())
+
+	withEncryption(t, cfg, 2)
+	err = newEncryptedServer(t, cfg).ReadPersistedMemoryStore()
+	assert.ErrorIs(t, err, keyring.ErrUnknownKey)
+	assert.ErrorContains(t, err, "encrypted with a key that is not configured")
+
+	cfg.StoreConfig.Encryption = nil
+	err = NewServer(cfg, DefaultPrometheusRegistry()).ReadPersistedMemoryStore()
+	assert.ErrorIs(t, err, snapshot.ErrNoKey)
+}
 
-	r, err := openStoreFileForRead(f.Name())
-	assert.ErrorIs(t, err, io.EOF)
-	assert.Nil(t, r)
+func TestReadPersistedMemoryStore_KeyRotation(t *testing.T) {
+	cfg := defaultConfig(t.TempDir())
+	path := cfg.MemoryDumpFileFullPath()
+	ctx := context.Background()
+
+	// An unencrypted snapshot is encrypted on the first start with a key.
+	s := NewServer(cfg, DefaultPrometheusRegistry())
+	_, err := s.Set(ctx, &v1alpha.SetRequest{Key: "foo", Value: []byte("bar")})
+	require.NoError(t, err)
+	require.NoError(t, s.PersistMemoryStore())
+
+	withEncryption(t, cfg, 1)
+	s = newEncryptedServer(t, cfg)
+	require.NoError(t, s.ReadPersistedMemoryStore())
+	old := s.keys.Primary().ID
+	_, info, err := snapshot.ReadFile(path, s.keys)
+	require.NoError(t, err)
+	assert.True(t, info.Encrypted)
+	assert.Equal(t, old, info.KeyID)
+
+	// After a rotation the old key still opens it, and it is rewritten
+	// with the new one.
+	withEncryption(t, cfg, 2, 1)
+	s = newEncryptedServer(t, cfg)
+	require.NoError(t, s.ReadPersistedMemoryStore())
+	resp, err := s.Get(ctx, &v1alpha.GetRequest{Key: "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("bar"), resp.Value)
+
+	_, info, err = snapshot.ReadFile(path, s.keys)
+	require.NoError(t, err)
+	assert.Equal(t, s.keys.Primary().ID, info.KeyID)
+	assert.NotEqual(t, old, info.KeyID)
 }
@@ -0,0 +1,130 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+// streamChunkSize is the size of the value chunks sent by GetStream. It
+// stays well below the default 4MB gRPC message limit.
+const streamChunkSize = 256 << 10
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// checkValueSize rejects values larger than the configured maximum.
+func (s *Server) checkValueSize(size int) error {
+	if limit := s.config.GetMaxValueSize(); size > limit {
+		return status.Errorf(codes.InvalidArgument, "value of %d bytes exceeds the maximum of %d bytes", size, limit)
+	}
+	return nil
+}
+
+func (s *Server) SetStream(stream cachev1alpha.CacheService_SetStreamServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	header := first.GetHeader()
+	if header == nil {
+		return status.Error(codes.InvalidArgument, "first message must be a header")
+	}
+
+	var value bytes.Buffer
+	value.Write(header.Value)
+	if err := s.checkValueSize(value.Len()); err != nil {
+		return err
+	}
+
+	var checksum *cachev1alpha.ValueChecksum
+	for checksum == nil {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return status.Error(codes.InvalidArgument, "stream ended without a checksum")
+		}
+		if err != nil {
+			return err
+		}
+
+		switch part := msg.Part.(type) {
+		case *cachev1alpha.SetStreamRequest_Chunk:
+			if err := s.checkValueSize(value.Len() + len(part.Chunk)); err != nil {
+				return err
+			}
+			value.Write(part.Chunk)
+		case *cachev1alpha.SetStreamRequest_Checksum:
+			checksum = part.Checksum
+		default:
+			return status.Error(codes.InvalidArgument, "unexpected message after header")
+		}
+	}
+
+	if sum := crc32.Checksum(value.Bytes(), crc32cTable); sum != checksum.Crc32C {
+		return status.Errorf(codes.DataLoss, "checksum mismatch: got %08x, want %08x", sum, checksum.Crc32C)
+	}
+
+	res, err := s.Set(stream.Context(), &cachev1alpha.SetRequest{
+		Key:         header.Key,
+		Value:       value.Bytes(),
+		IdleTimeout: header.IdleTimeout,
+		SoftTtl:     header.SoftTtl,
+		HardTtl:     header.HardTtl,
+	})
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(res)
+}
+
+func (s *Server) GetStream(req *cachev1alpha.GetStreamRequest, stream cachev1alpha.CacheService_GetStreamServer) error {
+	res, err := s.Get(stream.Context(), &cachev1alpha.GetRequest{Key: req.Key})
+	if err != nil {
+		return err
+	}
+
+	err = stream.Send(&cachev1alpha.GetStreamResponse{Part: &cachev1alpha.GetStreamResponse_Header{
+		Header: &cachev1alpha.ValueHeader{
+			Size:    uint64(len(res.Value)),
+			Version: res.Version,
+			Stale:   res.Stale,
+			Refresh: res.Refresh,
+			Etag:    res.Etag,
+		},
+	}})
+	if err != nil {
+		return err
+	}
+
+	for value := res.Value; len(value) > 0; {
+		n := min(len(value), streamChunkSize)
+		err := stream.Send(&cachev1alpha.GetStreamResponse{Part: &cachev1alpha.GetStreamResponse_Chunk{Chunk: value[:n]}})
+		if err != nil {
+			return err
+		}
+		value = value[n:]
+	}
+
+	return stream.Send(&cachev1alpha.GetStreamResponse{Part: &cachev1alpha.GetStreamResponse_Checksum{
+		Checksum: &cachev1alpha.ValueChecksum{Crc32C: crc32.Checksum(res.Value, crc32cTable)},
+	}})
+}
@@ -0,0 +1,172 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"hash/crc32"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+func sendValueStream(t *testing.T, client cachev1alpha.CacheServiceClient, key string, value []byte, sum uint32) error {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.SetStream(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&cachev1alpha.SetStreamRequest{Part: &cachev1alpha.SetStreamRequest_Header{
+		Header: &cachev1alpha.SetRequest{Key: key},
+	}}))
+	for rest := value; len(rest) > 0; {
+		n := min(len(rest), streamChunkSize)
+		if err := stream.Send(&cachev1alpha.SetStreamRequest{Part: &cachev1alpha.SetStreamRequest_Chunk{Chunk: rest[:n]}}); err != nil {
+			break
+		}
+		rest = rest[n:]
+	}
+	_ = stream.Send(&cachev1alpha.SetStreamRequest{Part: &cachev1alpha.SetStreamRequest_Checksum{
+		Checksum: &cachev1alpha.ValueChecksum{Crc32C: sum},
+	}})
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+func TestSetStreamAndGetStream(t *testing.T) {
+	s := NewTestServer(t)
+	client := NewTestClient(t, s)
+
+	// Larger than the default 4MB gRPC message limit.
+	value := bytes.Repeat([]byte("0123456789abcdef"), 5<<18)
+	err := sendValueStream(t, client, "blob", value, crc32.Checksum(value, crc32cTable))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.GetStream(ctx, &cachev1alpha.GetStreamRequest{Key: "blob"})
+	require.NoError(t, err)
+
+	msg, err := stream.Recv()
+	require.NoError(t, err)
+	require.NotNil(t, msg.GetHeader())
+	assert.Equal(t, uint64(len(value)), msg.GetHeader().Size)
+
+	var got bytes.Buffer
+	var sum *cachev1alpha.ValueChecksum
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if chunk := msg.GetChunk(); chunk != nil {
+			assert.LessOrEqual(t, len(chunk), streamChunkSize)
+			got.Write(chunk)
+		}
+		if msg.GetChecksum() != nil {
+			sum = msg.GetChecksum()
+		}
+	}
+	assert.Equal(t, value, got.Bytes())
+	require.NotNil(t, sum)
+	assert.Equal(t, crc32.Checksum(value, crc32cTable), sum.Crc32C)
+}
+
+func TestGetStream_StaleHeader(t *testing.T) {
+	s := NewTestServer(t)
+	client := NewTestClient(t, s)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.Set(ctx, &cachev1alpha.SetRequest{
+		Key:     "hot",
+		Value:   []byte("v1"),
+		SoftTtl: durationpb.New(time.Millisecond),
+		HardTtl: durationpb.New(time.Minute),
+	})
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	header := func() *cachev1alpha.ValueHeader {
+		stream, err := client.GetStream(ctx, &cachev1alpha.GetStreamRequest{Key: "hot"})
+		require.NoError(t, err)
+		msg, err := stream.Recv()
+		require.NoError(t, err)
+		require.NotNil(t, msg.GetHeader())
+		return msg.GetHeader()
+	}
+
+	first := header()
+	assert.True(t, first.Stale)
+	assert.True(t, first.Refresh)
+	assert.NotEmpty(t, first.Etag)
+
+	second := header()
+	assert.True(t, second.Stale)
+	assert.False(t, second.Refresh, "only the first caller should be told to refresh")
+	assert.Equal(t, first.Etag, second.Etag)
+}
+
+func TestSetStream_ChecksumMismatch(t *testing.T) {
+	s := NewTestServer(t)
+	client := NewTestClient(t, s)
+
+	err := sendValueStream(t, client, "blob", []byte("payload"), 1)
+	assert.Equal(t, codes.DataLoss, status.Code(err))
+
+	_, err = s.store.Get("blob")
+	assert.Error(t, err)
+}
+
+func TestSetStream_MaxValueSize(t *testing.T) {
+	s := NewTestServer(t)
+	s.config.StoreConfig.MaxValueSize = 1024
+	client := NewTestClient(t, s)
+
+	value := make([]byte, 2048)
+	err := sendValueStream(t, client, "blob", value, crc32.Checksum(value, crc32cTable))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.Set(context.Background(), &cachev1alpha.SetRequest{Key: "blob", Value: value})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestSet_MaxValueSizeAboveMessageLimit(t *testing.T) {
+	s := NewTestServer(t)
+	s.config.StoreConfig.MaxValueSize = 6 << 20
+	client := NewTestClient(t, s)
+	ctx := context.Background()
+
+	value := make([]byte, 5<<20)
+	_, err := client.Set(ctx, &cachev1alpha.SetRequest{Key: "blob", Value: value})
+	require.NoError(t, err)
+
+	value = make([]byte, 6<<20+1)
+	_, err = client.Set(ctx, &cachev1alpha.SetRequest{Key: "blob", Value: value})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
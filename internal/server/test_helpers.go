@@ -15,12 +15,15 @@
 package server
 
 import (
+	"net"
 	"path/filepath"
 	"testing"
 
 	"github.com/patrostkowski/protocache/internal/config"
 	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
 	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func DefaultPrometheusRegistry() *prometheus.Registry {
@@ -44,3 +47,41 @@ func NewTestServer(t *testing.T) *Server {
 
 	return NewServer(cfg, reg)
 }
+
+// NewTestClient serves s on a loopback listener and returns a client
+// connected to it. Both are stopped when the test finishes.
+func NewTestClient(t *testing.T, s *Server) v1alpha.CacheServiceClient {
+	t.Helper()
+	return v1alpha.NewCacheServiceClient(newTestConn(t, s))
+}
+
+// NewTestAdminClient is NewTestClient for the admin service.
+func NewTestAdminClient(t *testing.T, s *Server) v1alpha.AdminServiceClient {
+	t.Helper()
+	return v1alpha.NewAdminServiceClient(newTestConn(t, s))
+}
+
+func newTestConn(t *testing.T, s *Server) *grpc.ClientConn {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grpcServer := grpc.NewServer(grpc.MaxRecvMsgSize(s.config.GetMaxRecvMsgSize()))
+	v1alpha.RegisterCacheServiceServer(grpcServer, s)
+	v1alpha.RegisterAdminServiceServer(grpcServer, &adminService{s: s})
+	go func() {
+		_ = grpcServer.Serve(lis)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
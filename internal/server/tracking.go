@@ -0,0 +1,200 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/patrostkowski/protocache/internal/logger"
+	"github.com/patrostkowski/protocache/internal/store"
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+const (
+	trackingBuffer = 1024
+	// maxInvalidationKeys bounds how many queued keys are sent in one
+	// Invalidation message.
+	maxInvalidationKeys = 256
+)
+
+// trackingMessage is a single queued invalidation; flush drops every key.
+type trackingMessage struct {
+	key   string
+	flush bool
+}
+
+type trackingClient struct {
+	id       uint64
+	keys     map[string]struct{}
+	messages chan trackingMessage
+	lagged   chan struct{}
+	lagOnce  sync.Once
+}
+
+// trackingTable remembers which keys each near-cache client has read and
+// queues an invalidation for them when those keys change.
+type trackingTable struct {
+	mu      sync.Mutex
+	next    uint64
+	clients map[uint64]*trackingClient
+	keys    map[string]map[uint64]struct{}
+}
+
+func newTrackingTable() *trackingTable {
+	return &trackingTable{
+		clients: make(map[uint64]*trackingClient),
+		keys:    make(map[string]map[uint64]struct{}),
+	}
+}
+
+func (t *trackingTable) register() *trackingClient {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.next++
+	c := &trackingClient{
+		id:       t.next,
+		keys:     make(map[string]struct{}),
+		messages: make(chan trackingMessage, trackingBuffer),
+		lagged:   make(chan struct{}),
+	}
+	t.clients[c.id] = c
+	return c
+}
+
+func (t *trackingTable) unregister(c *trackingClient) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key := range c.keys {
+		t.untrackLocked(key, c.id)
+	}
+	delete(t.clients, c.id)
+}
+
+// track records that the client with id is about to read key. It must be
+// called before the store is read, so that a write racing with the read
+// still produces an invalidation.
+func (t *trackingTable) track(id uint64, key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.clients[id]
+	if !ok {
+		return false
+	}
+	if t.keys[key] == nil {
+		t.keys[key] = make(map[uint64]struct{})
+	}
+	t.keys[key][id] = struct{}{}
+	c.keys[key] = struct{}{}
+	return true
+}
+
+func (t *trackingTable) untrackLocked(key string, id uint64) {
+	delete(t.keys[key], id)
+	if len(t.keys[key]) == 0 {
+		delete(t.keys, key)
+	}
+}
+
+// invalidate is registered as a store event handler. Like eventBroker it
+// never blocks; a client whose queue is full is marked lagged and must
+// reconnect with an empty cache.
+func (t *trackingTable) invalidate(e store.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if e.Type == store.EventClear {
+		t.keys = make(map[string]map[uint64]struct{})
+		for _, c := range t.clients {
+			c.keys = make(map[string]struct{})
+			t.deliver(c, trackingMessage{flush: true})
+		}
+		return
+	}
+
+	for id := range t.keys[e.Key] {
+		c := t.clients[id]
+		delete(c.keys, e.Key)
+		t.deliver(c, trackingMessage{key: e.Key})
+	}
+	delete(t.keys, e.Key)
+}
+
+func (t *trackingTable) deliver(c *trackingClient, msg trackingMessage) {
+	select {
+	case c.messages <- msg:
+		InvalidationsSent.Inc()
+	default:
+		c.lagOnce.Do(func() { close(c.lagged) })
+	}
+}
+
+// Track registers a near-cache client and streams invalidations for the
+// keys it reads with Get while passing the returned tracking id.
+func (s *Server) Track(_ *cachev1alpha.TrackRequest, stream cachev1alpha.CacheService_TrackServer) error {
+	c := s.tracking.register()
+	defer s.tracking.unregister(c)
+
+	TrackingClients.Inc()
+	defer TrackingClients.Dec()
+
+	if err := stream.Send(&cachev1alpha.Invalidation{TrackingId: c.id}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.events.closed:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-c.lagged:
+			logger.Warn("Dropping tracking client that fell behind", "tracking_id", c.id)
+			return status.Error(codes.ResourceExhausted, "tracking client fell behind, flush and reconnect")
+		case msg := <-c.messages:
+			if err := stream.Send(collectInvalidations(msg, c.messages)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// collectInvalidations merges msg with any further queued messages into
+// one Invalidation.
+func collectInvalidations(msg trackingMessage, queued <-chan trackingMessage) *cachev1alpha.Invalidation {
+	inv := &cachev1alpha.Invalidation{}
+	for {
+		if msg.flush {
+			inv.Flush = true
+			inv.Keys = nil
+		} else if !inv.Flush {
+			inv.Keys = append(inv.Keys, msg.key)
+		}
+		if len(inv.Keys) >= maxInvalidationKeys {
+			return inv
+		}
+
+		select {
+		case msg = <-queued:
+		default:
+			return inv
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/patrostkowski/protocache/internal/store"
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+func TestTrack(t *testing.T) {
+	s := NewTestServer(t)
+	client := NewTestClient(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.Set(ctx, &cachev1alpha.SetRequest{Key: "hot", Value: []byte("1")})
+	require.NoError(t, err)
+	_, err = s.Set(ctx, &cachev1alpha.SetRequest{Key: "cold", Value: []byte("1")})
+	require.NoError(t, err)
+
+	stream, err := client.Track(ctx, &cachev1alpha.TrackRequest{})
+	require.NoError(t, err)
+	hello, err := stream.Recv()
+	require.NoError(t, err)
+	require.NotZero(t, hello.TrackingId)
+
+	_, err = client.Get(ctx, &cachev1alpha.GetRequest{Key: "hot", TrackingId: hello.TrackingId})
+	require.NoError(t, err)
+
+	// Only keys read with the tracking id are invalidated, once per read.
+	_, err = s.Set(ctx, &cachev1alpha.SetRequest{Key: "cold", Value: []byte("2")})
+	require.NoError(t, err)
+	_, err = s.Set(ctx, &cachev1alpha.SetRequest{Key: "hot", Value: []byte("2")})
+	require.NoError(t, err)
+	_, err = s.Set(ctx, &cachev1alpha.SetRequest{Key: "hot", Value: []byte("3")})
+	require.NoError(t, err)
+
+	inv, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hot"}, inv.Keys)
+	assert.False(t, inv.Flush)
+
+	_, err = s.Clear(ctx, &cachev1alpha.ClearRequest{})
+	require.NoError(t, err)
+
+	inv, err = stream.Recv()
+	require.NoError(t, err)
+	assert.True(t, inv.Flush)
+}
+
+func TestGet_UnknownTrackingID(t *testing.T) {
+	s := NewTestServer(t)
+
+	_, err := s.Get(context.Background(), &cachev1alpha.GetRequest{Key: "a", TrackingId: 42})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestTrackingTable_Lagged(t *testing.T) {
+	tt := newTrackingTable()
+	c := tt.register()
+
+	for i := 0; i <= trackingBuffer; i++ {
+		require.True(t, tt.track(c.id, "k"))
+		tt.invalidate(store.Event{Type: store.EventSet, Key: "k"})
+	}
+
+	select {
+	case <-c.lagged:
+	default:
+		t.Fatal("expected tracking client to be marked lagged")
+	}
+
+	tt.unregister(c)
+	assert.Empty(t, tt.keys)
+	assert.Empty(t, tt.clients)
+}
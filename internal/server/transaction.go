@@ -0,0 +1,125 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/patrostkowski/protocache/internal/logger"
+	"github.com/patrostkowski/protocache/internal/store"
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+var txnConditionTypes = map[cachev1alpha.TxnConditionType]store.ConditionType{
+	cachev1alpha.TxnConditionType_TXN_CONDITION_TYPE_EXISTS:         store.ConditionExists,
+	cachev1alpha.TxnConditionType_TXN_CONDITION_TYPE_NOT_EXISTS:     store.ConditionNotExists,
+	cachev1alpha.TxnConditionType_TXN_CONDITION_TYPE_VALUE_EQUALS:   store.ConditionValueEquals,
+	cachev1alpha.TxnConditionType_TXN_CONDITION_TYPE_VERSION_EQUALS: store.ConditionVersionEquals,
+}
+
+var txnOpTypes = map[cachev1alpha.TxnOpType]store.OpType{
+	cachev1alpha.TxnOpType_TXN_OP_TYPE_GET:    store.OpGet,
+	cachev1alpha.TxnOpType_TXN_OP_TYPE_SET:    store.OpSet,
+	cachev1alpha.TxnOpType_TXN_OP_TYPE_DELETE: store.OpDelete,
+	cachev1alpha.TxnOpType_TXN_OP_TYPE_INCR:   store.OpIncrement,
+}
+
+// Transaction applies all ops atomically once every condition holds. A
+// failed condition is reported in the response; an op that cannot be
+// applied fails the whole request and leaves the store unchanged.
+func (s *Server) Transaction(ctx context.Context, req *cachev1alpha.TransactionRequest) (*cachev1alpha.TransactionResponse, error) {
+	txnStore, ok := s.store.(store.Transactional)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "store engine does not support transactions")
+	}
+
+	conds, ops, err := txnFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		if err := s.checkValueSize(len(op.Value)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, op := range ops {
+		if op.Type != store.OpGet {
+			s.leases.invalidate(op.Key)
+		}
+	}
+
+	results, err := txnStore.Transaction(conds, ops)
+	if err != nil {
+		var condErr *store.ConditionFailedError
+		if errors.As(err, &condErr) {
+			return &cachev1alpha.TransactionResponse{FailedCondition: int32(condErr.Index)}, nil
+		}
+		if errors.Is(err, store.StoreErrorNotInteger) || errors.Is(err, store.StoreErrorOverflow) {
+			return nil, status.Errorf(codes.FailedPrecondition, "transaction aborted: %v", err)
+		}
+		logger.Error("Failed to apply transaction", "error", err)
+		return nil, status.Errorf(codes.Unknown, "internal error: %v", err)
+	}
+
+	res := &cachev1alpha.TransactionResponse{
+		Committed:       true,
+		FailedCondition: -1,
+		Results:         make([]*cachev1alpha.TxnOpResult, len(results)),
+	}
+	for i, r := range results {
+		res.Results[i] = &cachev1alpha.TxnOpResult{Found: r.Found, Value: r.Value, Version: r.Version}
+	}
+	return res, nil
+}
+
+func txnFromRequest(req *cachev1alpha.TransactionRequest) ([]store.Condition, []store.Op, error) {
+	conds := make([]store.Condition, len(req.Conditions))
+	for i, c := range req.Conditions {
+		t, ok := txnConditionTypes[c.Type]
+		if !ok {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "condition %d: unsupported type %s", i, c.Type)
+		}
+		if c.Key == "" {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "condition %d: key must not be empty", i)
+		}
+		conds[i] = store.Condition{Type: t, Key: c.Key, Value: c.Value, Version: c.Version}
+	}
+
+	ops := make([]store.Op, len(req.Ops))
+	for i, o := range req.Ops {
+		t, ok := txnOpTypes[o.Type]
+		if !ok {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "op %d: unsupported type %s", i, o.Type)
+		}
+		if o.Key == "" {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "op %d: key must not be empty", i)
+		}
+		opts, err := setOptionsFromRequest(&cachev1alpha.SetRequest{
+			IdleTimeout: o.IdleTimeout,
+			SoftTtl:     o.SoftTtl,
+			HardTtl:     o.HardTtl,
+		})
+		if err != nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "op %d: %s", i, status.Convert(err).Message())
+		}
+		ops[i] = store.Op{Type: t, Key: o.Key, Value: o.Value, Options: opts, Delta: o.Delta}
+	}
+	return conds, ops, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+func TestTransaction(t *testing.T) {
+	s := NewTestServer(t)
+	ctx := context.Background()
+
+	_, err := s.Set(ctx, &cachev1alpha.SetRequest{Key: "balance:alice", Value: []byte("10")})
+	require.NoError(t, err)
+	_, err = s.Set(ctx, &cachev1alpha.SetRequest{Key: "balance:bob", Value: []byte("0")})
+	require.NoError(t, err)
+
+	alice, err := s.Get(ctx, &cachev1alpha.GetRequest{Key: "balance:alice"})
+	require.NoError(t, err)
+	require.NotZero(t, alice.Version)
+
+	move := &cachev1alpha.TransactionRequest{
+		Conditions: []*cachev1alpha.TxnCondition{
+			{Type: cachev1alpha.TxnConditionType_TXN_CONDITION_TYPE_VERSION_EQUALS, Key: "balance:alice", Version: alice.Version},
+		},
+		Ops: []*cachev1alpha.TxnOp{
+			{Type: cachev1alpha.TxnOpType_TXN_OP_TYPE_INCR, Key: "balance:alice", Delta: -4},
+			{Type: cachev1alpha.TxnOpType_TXN_OP_TYPE_INCR, Key: "balance:bob", Delta: 4},
+		},
+	}
+
+	res, err := s.Transaction(ctx, move)
+	require.NoError(t, err)
+	assert.True(t, res.Committed)
+	assert.Equal(t, int32(-1), res.FailedCondition)
+	require.Len(t, res.Results, 2)
+	assert.Equal(t, []byte("6"), res.Results[0].Value)
+	assert.Equal(t, []byte("4"), res.Results[1].Value)
+
+	// Replaying with the stale version is rejected without applying.
+	res, err = s.Transaction(ctx, move)
+	require.NoError(t, err)
+	assert.False(t, res.Committed)
+	assert.Equal(t, int32(0), res.FailedCondition)
+	assert.Empty(t, res.Results)
+
+	bob, err := s.Get(ctx, &cachev1alpha.GetRequest{Key: "balance:bob"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("4"), bob.Value)
+}
+
+func TestTransaction_InvalidOp(t *testing.T) {
+	s := NewTestServer(t)
+
+	_, err := s.Transaction(context.Background(), &cachev1alpha.TransactionRequest{
+		Ops: []*cachev1alpha.TxnOp{{Key: "a"}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestTransactionInvalidatesLeases(t *testing.T) {
+	s := NewTestServer(t)
+	ctx := context.Background()
+
+	lease, err := s.Get(ctx, &cachev1alpha.GetRequest{Key: "user:1", Lease: true})
+	require.NoError(t, err)
+	require.NotZero(t, lease.LeaseToken)
+
+	_, err = s.Transaction(ctx, &cachev1alpha.TransactionRequest{
+		Ops: []*cachev1alpha.TxnOp{{Type: cachev1alpha.TxnOpType_TXN_OP_TYPE_SET, Key: "user:1", Value: []byte("fresh")}},
+	})
+	require.NoError(t, err)
+
+	_, err = s.SetWithLease(ctx, &cachev1alpha.SetWithLeaseRequest{
+		Set:        &cachev1alpha.SetRequest{Key: "user:1", Value: []byte("stale")},
+		LeaseToken: lease.LeaseToken,
+	})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	res, err := s.Get(ctx, &cachev1alpha.GetRequest{Key: "user:1"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("fresh"), res.Value)
+}
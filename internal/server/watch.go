@@ -0,0 +1,143 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/patrostkowski/protocache/internal/logger"
+	"github.com/patrostkowski/protocache/internal/store"
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+const watchBuffer = 256
+
+type watchFilter struct {
+	keys     map[string]bool
+	prefixes []string
+}
+
+func newWatchFilter(req *cachev1alpha.WatchRequest) *watchFilter {
+	f := &watchFilter{
+		keys:     make(map[string]bool, len(req.Keys)),
+		prefixes: req.Prefixes,
+	}
+	for _, key := range req.Keys {
+		f.keys[key] = true
+	}
+	return f
+}
+
+func (f *watchFilter) matchKey(key string) bool {
+	if f.keys[key] {
+		return true
+	}
+	for _, prefix := range f.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *watchFilter) matchEvent(e store.Event) bool {
+	return e.Type == store.EventClear || f.matchKey(e.Key)
+}
+
+func watchEvent(e store.Event) *cachev1alpha.WatchEvent {
+	res := &cachev1alpha.WatchEvent{Key: e.Key, Timestamp: e.Time.UnixNano()}
+	switch e.Type {
+	case store.EventSet:
+		res.Type = cachev1alpha.WatchEventType_WATCH_EVENT_TYPE_PUT
+		res.Value = e.Value
+	case store.EventClear:
+		res.Type = cachev1alpha.WatchEventType_WATCH_EVENT_TYPE_CLEAR
+	default:
+		res.Type = cachev1alpha.WatchEventType_WATCH_EVENT_TYPE_DELETE
+	}
+	return res
+}
+
+func (s *Server) Watch(req *cachev1alpha.WatchRequest, stream cachev1alpha.CacheService_WatchServer) error {
+	if len(req.Keys) == 0 && len(req.Prefixes) == 0 {
+		return status.Error(codes.InvalidArgument, "at least one key or prefix must be watched")
+	}
+
+	filter := newWatchFilter(req)
+
+	// Subscribe before taking the snapshot so no change is lost in between.
+	sub := s.events.subscribe(filter.matchEvent, watchBuffer)
+	defer s.events.unsubscribe(sub)
+
+	Watchers.Inc()
+	defer Watchers.Dec()
+
+	if req.InitialSnapshot {
+		if err := s.sendWatchSnapshot(filter, stream); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.events.closed:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-sub.lagged:
+			logger.Warn("Dropping watcher that fell behind")
+			WatchersDropped.Inc()
+			return stream.Send(&cachev1alpha.WatchEvent{
+				Type:      cachev1alpha.WatchEventType_WATCH_EVENT_TYPE_RESYNC,
+				Timestamp: time.Now().UnixNano(),
+			})
+		case e := <-sub.events:
+			if err := stream.Send(watchEvent(e)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) sendWatchSnapshot(filter *watchFilter, stream cachev1alpha.CacheService_WatchServer) error {
+	snapshot := s.store.This()
+
+	keys := make([]string, 0, len(snapshot))
+	for key := range snapshot {
+		if filter.matchKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	now := time.Now().UnixNano()
+	for _, key := range keys {
+		err := stream.Send(&cachev1alpha.WatchEvent{
+			Type:      cachev1alpha.WatchEventType_WATCH_EVENT_TYPE_PUT,
+			Key:       key,
+			Value:     snapshot[key],
+			Timestamp: now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
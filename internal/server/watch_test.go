@@ -0,0 +1,119 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+func TestWatch(t *testing.T) {
+	s := NewTestServer(t)
+	client := NewTestClient(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.Set(ctx, &cachev1alpha.SetRequest{Key: "config/app/a", Value: []byte("1")})
+	require.NoError(t, err)
+	_, err = s.Set(ctx, &cachev1alpha.SetRequest{Key: "other", Value: []byte("x")})
+	require.NoError(t, err)
+
+	stream, err := client.Watch(ctx, &cachev1alpha.WatchRequest{
+		Keys:            []string{"flag"},
+		Prefixes:        []string{"config/app/"},
+		InitialSnapshot: true,
+	})
+	require.NoError(t, err)
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, cachev1alpha.WatchEventType_WATCH_EVENT_TYPE_PUT, event.Type)
+	assert.Equal(t, "config/app/a", event.Key)
+	assert.Equal(t, []byte("1"), event.Value)
+
+	waitForSubscribers(t, s.events, 1)
+
+	_, err = s.Set(ctx, &cachev1alpha.SetRequest{Key: "other", Value: []byte("y")})
+	require.NoError(t, err)
+	_, err = s.Set(ctx, &cachev1alpha.SetRequest{Key: "flag", Value: []byte("on")})
+	require.NoError(t, err)
+	_, err = s.Delete(ctx, &cachev1alpha.DeleteRequest{Key: "config/app/a"})
+	require.NoError(t, err)
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, cachev1alpha.WatchEventType_WATCH_EVENT_TYPE_PUT, event.Type)
+	assert.Equal(t, "flag", event.Key)
+	assert.Equal(t, []byte("on"), event.Value)
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, cachev1alpha.WatchEventType_WATCH_EVENT_TYPE_DELETE, event.Type)
+	assert.Equal(t, "config/app/a", event.Key)
+}
+
+func TestWatch_RequiresKeys(t *testing.T) {
+	s := NewTestServer(t)
+	client := NewTestClient(t, s)
+
+	stream, err := client.Watch(context.Background(), &cachev1alpha.WatchRequest{})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestWatch_SlowWatcherGetsResync(t *testing.T) {
+	s := NewTestServer(t)
+	client := NewTestClient(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &cachev1alpha.WatchRequest{Prefixes: []string{"k"}})
+	require.NoError(t, err)
+	waitForSubscribers(t, s.events, 1)
+
+	// Writers must not block while the watcher is not reading.
+	value := bytes.Repeat([]byte("v"), 1024)
+	for i := 0; i < 2000; i++ {
+		_, err := s.Set(ctx, &cachev1alpha.SetRequest{Key: fmt.Sprintf("k%d", i), Value: value})
+		require.NoError(t, err)
+	}
+
+	var last *cachev1alpha.WatchEvent
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		last = event
+	}
+	require.NotNil(t, last)
+	assert.Equal(t, cachev1alpha.WatchEventType_WATCH_EVENT_TYPE_RESYNC, last.Type)
+}
@@ -0,0 +1,195 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/patrostkowski/protocache/internal/keyring"
+)
+
+// Encrypted record sections are split into segments of segmentSize
+// plaintext bytes, each sealed with AES-GCM. The nonce of a segment is
+// the nonce prefix from the header followed by the segment index, and
+// its additional data is the header followed by a byte that is 1 for
+// the last segment. Segments can thus be neither reordered, nor dropped,
+// nor moved to another snapshot.
+const (
+	segmentSize      = 64 << 10
+	noncePrefixSize  = 8
+	tagSize          = 16
+	encryptionAES256 = 1
+
+	// Offsets into the version 2 header.
+	keyIDOffset = 12
+	nonceOffset = 16
+)
+
+// ErrNoKey is returned for an encrypted snapshot when no keys are
+// configured.
+var ErrNoKey = errors.New("snapshot is encrypted but no encryption key is configured")
+
+// encryptHeader marks header as encrypted with the primary key of keys.
+func encryptHeader(header []byte, keys *keyring.Keyring) error {
+	header[9] = encryptionAES256
+	binary.BigEndian.PutUint32(header[keyIDOffset:], keys.Primary().ID)
+	_, err := rand.Read(header[nonceOffset : nonceOffset+noncePrefixSize])
+	return err
+}
+
+// headerKey returns the key a version 2 header says the record section
+// is encrypted with, or nil if it is not encrypted.
+func headerKey(header []byte, keys *keyring.Keyring) (*keyring.Key, error) {
+	switch header[9] {
+	case 0:
+		return nil, nil
+	case encryptionAES256:
+	default:
+		return nil, fmt.Errorf("%w: unknown encryption %d", ErrCorrupt, header[9])
+	}
+	if keys == nil {
+		return nil, ErrNoKey
+	}
+	key, err := keys.Lookup(binary.BigEndian.Uint32(header[keyIDOffset:]))
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: %w", err)
+	}
+	return key, nil
+}
+
+func segmentNonce(header []byte, index uint32) []byte {
+	nonce := make([]byte, noncePrefixSize+4)
+	copy(nonce, header[nonceOffset:nonceOffset+noncePrefixSize])
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], index)
+	return nonce
+}
+
+func segmentAAD(header []byte, last bool) []byte {
+	aad := append(make([]byte, 0, len(header)+1), header...)
+	if last {
+		return append(aad, 1)
+	}
+	return append(aad, 0)
+}
+
+// encryptWriter seals everything written to it into segments.
+type encryptWriter struct {
+	w      io.Writer
+	key    *keyring.Key
+	header []byte
+	buf    []byte
+	out    []byte
+	index  uint32
+}
+
+func newEncryptWriter(w io.Writer, key *keyring.Key, header []byte) *encryptWriter {
+	return &encryptWriter{w: w, key: key, header: header, buf: make([]byte, 0, segmentSize)}
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		// A full segment is only sealed once more data arrives, because
+		// the last one must be marked as such.
+		if len(e.buf) == segmentSize {
+			if err := e.seal(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(e.buf[len(e.buf):segmentSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close seals the last segment. It does not close the underlying writer.
+func (e *encryptWriter) Close() error {
+	return e.seal(true)
+}
+
+func (e *encryptWriter) seal(last bool) error {
+	if e.index == ^uint32(0) {
+		return errors.New("snapshot is too large to encrypt")
+	}
+	e.out = e.key.AEAD.Seal(e.out[:0], segmentNonce(e.header, e.index), e.buf, segmentAAD(e.header, last))
+	e.index++
+	e.buf = e.buf[:0]
+	_, err := e.w.Write(e.out)
+	return err
+}
+
+// decryptReader opens the segments written by encryptWriter.
+type decryptReader struct {
+	r      *bufio.Reader
+	key    *keyring.Key
+	header []byte
+	in     []byte
+	out    []byte
+	buf    []byte
+	index  uint32
+	done   bool
+}
+
+func newDecryptReader(r io.Reader, key *keyring.Key, header []byte) *decryptReader {
+	return &decryptReader{
+		r:      bufio.NewReader(r),
+		key:    key,
+		header: header,
+		in:     make([]byte, segmentSize+tagSize),
+	}
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+func (d *decryptReader) open() error {
+	n, err := io.ReadFull(d.r, d.in)
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		d.done = true
+	case err != nil:
+		return err
+	default:
+		_, err := d.r.Peek(1)
+		d.done = errors.Is(err, io.EOF)
+	}
+
+	plaintext, err := d.key.AEAD.Open(d.out[:0], segmentNonce(d.header, d.index), d.in[:n], segmentAAD(d.header, d.done))
+	if err != nil {
+		return fmt.Errorf("%w: segment %d: %v", ErrCorrupt, d.index, keyring.ErrDecrypt)
+	}
+	d.index++
+	d.out = plaintext
+	d.buf = plaintext
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"bufio"
+	"errors"
+	"iter"
+	"os"
+	"path/filepath"
+
+	"github.com/patrostkowski/protocache/internal/keyring"
+	"github.com/patrostkowski/protocache/internal/store"
+)
+
+// PreviousSuffix names the last good snapshot kept next to the current
+// one by WriteFile.
+const PreviousSuffix = ".prev"
+
+// WriteFile atomically replaces path with a snapshot of records. The
+// snapshot goes to a temporary file in the same directory, which is
+// fsynced and renamed over path; the replaced file is kept as
+// path+PreviousSuffix. A crash at any point leaves either the old or the
+// new snapshot in place.
+func WriteFile(path string, records iter.Seq[store.Record], opts Options) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	buf := bufio.NewWriter(tmp)
+	if err := Write(buf, records, opts); err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(path, path+PreviousSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return SyncDir(dir)
+}
+
+// ReadFile reads the snapshot at path, like Read. It returns io.EOF for
+// an empty file.
+func ReadFile(path string, keys *keyring.Keyring) ([]store.Record, Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	defer f.Close()
+	return Read(f, keys)
+}
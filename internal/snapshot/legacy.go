@@ -0,0 +1,70 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+
+	"github.com/patrostkowski/protocache/internal/store"
+)
+
+// readGob decodes a gzip-compressed gob of map[string][]byte, the
+// payload of legacy and version 1 snapshots. Records are sorted by key,
+// since the gob carries no order.
+func readGob(r io.Reader) ([]store.Record, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var dump map[string][]byte
+	if err := gob.NewDecoder(gz).Decode(&dump); err != nil {
+		return nil, err
+	}
+
+	records := make([]store.Record, 0, len(dump))
+	for key, value := range dump {
+		records = append(records, store.Record{Key: key, Value: value})
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Key < records[j].Key })
+	return records, nil
+}
+
+// readV1 reads a version 1 snapshot, whose header holds the payload
+// length at offset 8 and its CRC-32C at offset 16.
+func readV1(r io.Reader, header []byte, size int64) ([]store.Record, error) {
+	length := binary.BigEndian.Uint64(header[8:])
+	checksum := binary.BigEndian.Uint32(header[16:])
+	if want := uint64(size - headerSize); length != want {
+		return nil, fmt.Errorf("%w: payload is %d bytes, header says %d", ErrCorrupt, want, length)
+	}
+
+	var payload bytes.Buffer
+	if _, err := io.Copy(&payload, r); err != nil {
+		return nil, err
+	}
+	if sum := crc32.Checksum(payload.Bytes(), crc32cTable); sum != checksum {
+		return nil, fmt.Errorf("%w: checksum %08x, header says %08x", ErrCorrupt, sum, checksum)
+	}
+	return readGob(&payload)
+}
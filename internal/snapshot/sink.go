@@ -0,0 +1,243 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+// Timestamped snapshots are named after the snapshot file they were
+// taken for, followed by a dot and the UTC time the snapshot started.
+const timestampLayout = "20060102T150405.000000000Z"
+
+// Name returns the name of the snapshot of base taken at t.
+func Name(base string, t time.Time) string {
+	return base + "." + t.UTC().Format(timestampLayout)
+}
+
+// ParseName returns the time encoded in a name returned by Name.
+func ParseName(name string) (time.Time, bool) {
+	i := len(name) - len(timestampLayout)
+	if i < 1 || name[i-1] != '.' {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(timestampLayout, name[i:])
+	return t, err == nil
+}
+
+// Entry describes a stored snapshot.
+type Entry struct {
+	Name string
+	Time time.Time
+	Size int64
+}
+
+// Sink is a destination snapshots are shipped to.
+type Sink interface {
+	// Put stores the snapshot read from r under name.
+	Put(ctx context.Context, name string, r io.Reader) error
+	// Open returns the snapshot stored under name. It returns an error
+	// wrapping os.ErrNotExist if there is none.
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// Lister is implemented by sinks that can enumerate their snapshots.
+type Lister interface {
+	// List returns the stored snapshots, newest first.
+	List(ctx context.Context) ([]Entry, error)
+}
+
+// NewSink creates the sink described by cfg.
+func NewSink(cfg *v1alpha.SnapshotSinkConfig) (Sink, error) {
+	switch cfg.Type {
+	case v1alpha.DirSnapshotSink:
+		if cfg.Path == "" {
+			return nil, errors.New("dir snapshot sink requires a path")
+		}
+		return NewDirSink(cfg.Path, cfg.Retention), nil
+	case v1alpha.HTTPSnapshotSink:
+		if cfg.URL == "" {
+			return nil, errors.New("http snapshot sink requires a url")
+		}
+		return NewHTTPSink(cfg.URL, cfg.Timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown snapshot sink %q", cfg.Type)
+	}
+}
+
+// DirSink keeps timestamped snapshots in a local directory.
+type DirSink struct {
+	dir       string
+	retention int
+}
+
+// NewDirSink returns a sink that stores snapshots in dir and, if
+// retention is positive, removes all but the newest retention of them
+// after every Put.
+func NewDirSink(dir string, retention int) *DirSink {
+	return &DirSink{dir: dir, retention: retention}
+}
+
+// Put writes the snapshot atomically, like WriteFile.
+func (d *DirSink) Put(_ context.Context, name string, r io.Reader) (err error) {
+	if err := os.MkdirAll(d.dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(d.dir, name+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	buf := bufio.NewWriter(tmp)
+	if _, err := io.Copy(buf, r); err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(d.dir, name)); err != nil {
+		return err
+	}
+	if err := SyncDir(d.dir); err != nil {
+		return err
+	}
+	return d.Prune()
+}
+
+func (d *DirSink) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(d.dir, name))
+}
+
+func (d *DirSink) List(_ context.Context) ([]Entry, error) {
+	files, err := os.ReadDir(d.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, f := range files {
+		t, ok := ParseName(f.Name())
+		if !ok || !f.Type().IsRegular() {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, Entry{Name: f.Name(), Time: t, Size: info.Size()})
+	}
+	slices.SortFunc(entries, func(a, b Entry) int { return b.Time.Compare(a.Time) })
+	return entries, nil
+}
+
+// Prune removes all but the newest retention snapshots.
+func (d *DirSink) Prune() error {
+	if d.retention <= 0 {
+		return nil
+	}
+	entries, err := d.List(context.Background())
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, e := range entries[min(d.retention, len(entries)):] {
+		if err := os.Remove(filepath.Join(d.dir, e.Name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HTTPSink PUTs snapshots to a base URL and GETs them back from it.
+type HTTPSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPSink(baseURL string, timeout time.Duration) *HTTPSink {
+	return &HTTPSink{
+		url:    strings.TrimSuffix(baseURL, "/"),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *HTTPSink) Put(ctx context.Context, name string, r io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.url+"/"+url.PathEscape(name), r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("snapshot sink responded with %s", res.Status)
+	}
+	return nil
+}
+
+func (s *HTTPSink) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url+"/"+url.PathEscape(name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, fmt.Errorf("snapshot %s: %w", name, os.ErrNotExist)
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		res.Body.Close()
+		return nil, fmt.Errorf("snapshot sink responded with %s", res.Status)
+	}
+	return res.Body, nil
+}
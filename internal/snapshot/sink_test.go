@@ -0,0 +1,172 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+// objectStore is a stand-in for an HTTP object store.
+type objectStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newObjectStore(t *testing.T) (*objectStore, *httptest.Server) {
+	o := &objectStore{objects: make(map[string][]byte)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			o.objects[r.URL.Path] = data
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet:
+			data, ok := o.objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(data)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return o, srv
+}
+
+func TestName(t *testing.T) {
+	at := time.Date(2025, 6, 1, 12, 30, 45, 123456789, time.FixedZone("CEST", 2*3600))
+	name := Name("protocache.snap", at)
+	assert.Equal(t, "protocache.snap.20250601T103045.123456789Z", name)
+
+	parsed, ok := ParseName(name)
+	require.True(t, ok)
+	assert.True(t, parsed.Equal(at))
+
+	for _, name := range []string{"protocache.snap", "protocache.snap.prev", "20250601T103045.123456789Z", "x.20250601T103045.123456789"} {
+		_, ok := ParseName(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestDirSink(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	sink := NewDirSink(dir, 2)
+
+	base := time.Unix(1700000000, 0)
+	for i := range 3 {
+		name := Name("protocache.snap", base.Add(time.Duration(i)*time.Second))
+		require.NoError(t, sink.Put(ctx, name, strings.NewReader(strings.Repeat("x", i+1))))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "protocache.snap"), []byte("other"), 0o600))
+
+	entries, err := sink.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, Name("protocache.snap", base.Add(2*time.Second)), entries[0].Name)
+	assert.Equal(t, int64(3), entries[0].Size)
+	assert.Equal(t, Name("protocache.snap", base.Add(time.Second)), entries[1].Name)
+	assert.FileExists(t, filepath.Join(dir, "protocache.snap"))
+
+	r, err := sink.Open(ctx, entries[1].Name)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	r.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "xx", string(data))
+
+	_, err = sink.Open(ctx, Name("protocache.snap", base))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestDirSink_ListMissingDir(t *testing.T) {
+	entries, err := NewDirSink(filepath.Join(t.TempDir(), "missing"), 0).List(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestHTTPSink(t *testing.T) {
+	ctx := context.Background()
+	objects, srv := newObjectStore(t)
+	sink := NewHTTPSink(srv.URL+"/backups/", time.Second)
+
+	name := Name("protocache.snap", time.Unix(1700000000, 0))
+	require.NoError(t, sink.Put(ctx, name, strings.NewReader("snapshot")))
+	assert.Equal(t, []byte("snapshot"), objects.objects["/backups/"+name])
+
+	r, err := sink.Open(ctx, name)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	r.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "snapshot", string(data))
+
+	_, err = sink.Open(ctx, "missing")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestHTTPSink_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(srv.Close)
+	sink := NewHTTPSink(srv.URL, time.Second)
+
+	err := sink.Put(context.Background(), "a", strings.NewReader("snapshot"))
+	assert.ErrorContains(t, err, "403")
+	_, err = sink.Open(context.Background(), "a")
+	assert.ErrorContains(t, err, "403")
+}
+
+func TestNewSink(t *testing.T) {
+	sink, err := NewSink(&v1alpha.SnapshotSinkConfig{Type: v1alpha.DirSnapshotSink, Path: t.TempDir()})
+	require.NoError(t, err)
+	assert.IsType(t, &DirSink{}, sink)
+
+	sink, err = NewSink(&v1alpha.SnapshotSinkConfig{Type: v1alpha.HTTPSnapshotSink, URL: "http://localhost"})
+	require.NoError(t, err)
+	assert.IsType(t, &HTTPSink{}, sink)
+
+	for _, cfg := range []v1alpha.SnapshotSinkConfig{
+		{Type: v1alpha.DirSnapshotSink},
+		{Type: v1alpha.HTTPSnapshotSink},
+		{Type: "s3"},
+	} {
+		_, err := NewSink(&cfg)
+		assert.Error(t, err, cfg.Type)
+	}
+}
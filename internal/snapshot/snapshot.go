@@ -0,0 +1,422 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package snapshot reads and writes protocache snapshot files.
+//
+// A snapshot file starts with a 24-byte header:
+//
+//	magic   [4]byte "PCSN"
+//	version uint32
+//	...     16 bytes whose meaning depends on the version
+//
+// Version 2 headers continue with:
+//
+//	compression byte     0 none, 1 gzip, 2 flate
+//	encryption  byte     0 none, 1 AES-256-GCM
+//	reserved    [2]byte
+//	key id      uint32   the key the records are encrypted with
+//	nonce       [8]byte  nonce prefix of the encrypted segments
+//
+// followed by the records and a 24-byte footer:
+//
+//	count    uint64  number of records
+//	length   uint64  length of the record section in bytes
+//	checksum uint32  CRC-32C of the record section
+//	magic    [4]byte "PCSE"
+//
+// The record section is the compressed stream of SnapshotRecord
+// messages, each prefixed with its length as an unsigned varint. If the
+// snapshot is encrypted, the compressed stream is then sealed in
+// segments, see encryptWriter.
+//
+// Version 1 files and headerless files hold a gzip-compressed gob of
+// map[string][]byte. They are still read, but never written.
+package snapshot
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"iter"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/patrostkowski/protocache/internal/keyring"
+	"github.com/patrostkowski/protocache/internal/store"
+	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+// Format identifies the layout of a snapshot file.
+type Format int
+
+const (
+	// FormatLegacy is a headerless gzip-compressed gob.
+	FormatLegacy Format = iota
+	// FormatV1 is a gzip-compressed gob behind a checksummed header.
+	FormatV1
+	// FormatV2 is the record-oriented format.
+	FormatV2
+
+	// FormatCurrent is the format written by Write.
+	FormatCurrent = FormatV2
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatLegacy:
+		return "legacy"
+	case FormatV1:
+		return "v1"
+	case FormatV2:
+		return "v2"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+const (
+	headerMagic = "PCSN"
+	footerMagic = "PCSE"
+	headerSize  = 24
+	footerSize  = 24
+
+	maxRecordSize = 1 << 31
+)
+
+var compressionCodes = map[v1alpha.SnapshotCompression]byte{
+	v1alpha.SnapshotCompressionNone:  0,
+	v1alpha.SnapshotCompressionGzip:  1,
+	v1alpha.SnapshotCompressionFlate: 2,
+}
+
+// ErrCorrupt is returned when a snapshot fails its integrity checks.
+var ErrCorrupt = errors.New("snapshot is corrupt")
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// countingWriter counts and checksums everything written through it.
+type countingWriter struct {
+	w    io.Writer
+	n    uint64
+	hash uint32
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += uint64(n)
+	c.hash = crc32.Update(c.hash, crc32cTable, p[:n])
+	return n, err
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func compressor(w io.Writer, compression v1alpha.SnapshotCompression) (io.WriteCloser, error) {
+	switch compression {
+	case v1alpha.SnapshotCompressionNone:
+		return nopWriteCloser{w}, nil
+	case v1alpha.SnapshotCompressionGzip:
+		return gzip.NewWriter(w), nil
+	case v1alpha.SnapshotCompressionFlate:
+		return flate.NewWriter(w, flate.DefaultCompression)
+	default:
+		return nil, fmt.Errorf("unknown snapshot compression %q", compression)
+	}
+}
+
+func decompressor(r io.Reader, code byte) (io.Reader, error) {
+	switch code {
+	case compressionCodes[v1alpha.SnapshotCompressionNone]:
+		return r, nil
+	case compressionCodes[v1alpha.SnapshotCompressionGzip]:
+		return gzip.NewReader(r)
+	case compressionCodes[v1alpha.SnapshotCompressionFlate]:
+		return flate.NewReader(r), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown compression %d", ErrCorrupt, code)
+	}
+}
+
+func recordProto(r store.Record) *v1alpha.SnapshotRecord {
+	msg := &v1alpha.SnapshotRecord{
+		Key:     r.Key,
+		Value:   r.Value,
+		Version: r.Version,
+	}
+	if r.IdleTimeout > 0 {
+		msg.IdleTimeout = durationpb.New(r.IdleTimeout)
+	}
+	if !r.StaleAt.IsZero() {
+		msg.StaleAt = r.StaleAt.UnixNano()
+	}
+	if !r.ExpiresAt.IsZero() {
+		msg.ExpiresAt = r.ExpiresAt.UnixNano()
+	}
+	return msg
+}
+
+func recordFromProto(msg *v1alpha.SnapshotRecord) store.Record {
+	r := store.Record{
+		Key:         msg.Key,
+		Value:       msg.Value,
+		Version:     msg.Version,
+		IdleTimeout: msg.IdleTimeout.AsDuration(),
+	}
+	if r.Value == nil {
+		// Proto does not tell empty from unset bytes.
+		r.Value = []byte{}
+	}
+	if msg.StaleAt != 0 {
+		r.StaleAt = time.Unix(0, msg.StaleAt)
+	}
+	if msg.ExpiresAt != 0 {
+		r.ExpiresAt = time.Unix(0, msg.ExpiresAt)
+	}
+	return r
+}
+
+// Options configure how Write encodes a snapshot.
+type Options struct {
+	Compression v1alpha.SnapshotCompression
+	// Keys, if set, encrypts the snapshot with its primary key.
+	Keys *keyring.Keyring
+}
+
+// Info describes a snapshot returned by Read.
+type Info struct {
+	Format Format
+	// Encrypted reports whether the snapshot is encrypted, and KeyID
+	// with which key.
+	Encrypted bool
+	KeyID     uint32
+}
+
+// Write writes records to w as a snapshot in the current format.
+func Write(w io.Writer, records iter.Seq[store.Record], opts Options) error {
+	code, ok := compressionCodes[opts.Compression]
+	if !ok {
+		return fmt.Errorf("unknown snapshot compression %q", opts.Compression)
+	}
+
+	header := make([]byte, headerSize)
+	copy(header, headerMagic)
+	binary.BigEndian.PutUint32(header[4:], uint32(FormatV2))
+	header[8] = code
+	if opts.Keys != nil {
+		if err := encryptHeader(header, opts.Keys); err != nil {
+			return err
+		}
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	body := &countingWriter{w: w}
+	buffered := bufio.NewWriter(body)
+	var sealed io.WriteCloser = nopWriteCloser{buffered}
+	if opts.Keys != nil {
+		sealed = newEncryptWriter(buffered, opts.Keys.Primary(), header)
+	}
+	cw, err := compressor(sealed, opts.Compression)
+	if err != nil {
+		return err
+	}
+
+	var buf []byte
+	var count uint64
+	for r := range records {
+		msg, err := proto.Marshal(recordProto(r))
+		if err != nil {
+			return fmt.Errorf("marshal record %q: %w", r.Key, err)
+		}
+		buf = binary.AppendUvarint(buf[:0], uint64(len(msg)))
+		buf = append(buf, msg...)
+		if _, err := cw.Write(buf); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := cw.Close(); err != nil {
+		return err
+	}
+	if err := sealed.Close(); err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+
+	footer := make([]byte, footerSize)
+	binary.BigEndian.PutUint64(footer[0:], count)
+	binary.BigEndian.PutUint64(footer[8:], body.n)
+	binary.BigEndian.PutUint32(footer[16:], body.hash)
+	copy(footer[20:], footerMagic)
+	_, err = w.Write(footer)
+	return err
+}
+
+// Read reads a snapshot in any supported format, decrypting it with keys
+// if it is encrypted. Records are only returned once the whole snapshot
+// has been verified, so a torn or corrupted snapshot is never partially
+// applied.
+func Read(r io.ReadSeeker, keys *keyring.Keyring) ([]store.Record, Info, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	if size == 0 {
+		return nil, Info{}, io.EOF
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, Info{}, err
+	}
+
+	header := make([]byte, headerSize)
+	n, err := io.ReadFull(r, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, Info{}, err
+	}
+	if n < headerSize || string(header[:4]) != headerMagic {
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return nil, Info{}, err
+		}
+		records, err := readGob(r)
+		return records, Info{Format: FormatLegacy}, err
+	}
+
+	switch version := Format(binary.BigEndian.Uint32(header[4:])); version {
+	case FormatV1:
+		records, err := readV1(r, header, size)
+		return records, Info{Format: FormatV1}, err
+	case FormatV2:
+		info := Info{
+			Format:    FormatV2,
+			Encrypted: header[9] != 0,
+			KeyID:     binary.BigEndian.Uint32(header[keyIDOffset:]),
+		}
+		key, err := headerKey(header, keys)
+		if err != nil {
+			return nil, info, err
+		}
+		records, err := readV2(r, header, size, key)
+		return records, info, err
+	default:
+		return nil, Info{}, fmt.Errorf("unsupported snapshot format version %d", version)
+	}
+}
+
+func readV2(r io.ReadSeeker, header []byte, size int64, key *keyring.Key) ([]store.Record, error) {
+	if size < headerSize+footerSize {
+		return nil, fmt.Errorf("%w: file is %d bytes", ErrCorrupt, size)
+	}
+	if _, err := r.Seek(size-footerSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+	footer := make([]byte, footerSize)
+	if _, err := io.ReadFull(r, footer); err != nil {
+		return nil, err
+	}
+	if string(footer[20:]) != footerMagic {
+		return nil, fmt.Errorf("%w: missing footer", ErrCorrupt)
+	}
+	count := binary.BigEndian.Uint64(footer[0:])
+	length := binary.BigEndian.Uint64(footer[8:])
+	checksum := binary.BigEndian.Uint32(footer[16:])
+	if want := uint64(size - headerSize - footerSize); length != want {
+		return nil, fmt.Errorf("%w: record section is %d bytes, footer says %d", ErrCorrupt, want, length)
+	}
+
+	// Verify the checksum in a first pass, so that decoding never sees
+	// corrupted input.
+	section := io.NewSectionReader(asReaderAt(r), headerSize, int64(length))
+	hash := crc32.New(crc32cTable)
+	if _, err := io.Copy(hash, section); err != nil {
+		return nil, err
+	}
+	if sum := hash.Sum32(); sum != checksum {
+		return nil, fmt.Errorf("%w: checksum %08x, footer says %08x", ErrCorrupt, sum, checksum)
+	}
+
+	if _, err := section.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var opened io.Reader = section
+	if key != nil {
+		opened = newDecryptReader(section, key, header)
+	}
+	dr, err := decompressor(bufio.NewReader(opened), header[8])
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(dr)
+
+	records := make([]store.Record, 0, min(count, 1<<20))
+	var buf []byte
+	for {
+		n, err := binary.ReadUvarint(br)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		if n > maxRecordSize {
+			return nil, fmt.Errorf("%w: record of %d bytes", ErrCorrupt, n)
+		}
+		if uint64(cap(buf)) < n {
+			buf = make([]byte, n)
+		}
+		buf = buf[:n]
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		msg := &v1alpha.SnapshotRecord{}
+		if err := proto.Unmarshal(buf, msg); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		records = append(records, recordFromProto(msg))
+	}
+	if uint64(len(records)) != count {
+		return nil, fmt.Errorf("%w: %d records, footer says %d", ErrCorrupt, len(records), count)
+	}
+	return records, nil
+}
+
+// readerAt adapts an io.ReadSeeker for io.SectionReader. It is not safe
+// for concurrent use, which Read does not need.
+type readerAt struct {
+	r io.ReadSeeker
+}
+
+func (ra readerAt) ReadAt(p []byte, off int64) (int, error) {
+	if _, err := ra.r.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.ReadFull(ra.r, p)
+}
+
+func asReaderAt(r io.ReadSeeker) io.ReaderAt {
+	if ra, ok := r.(io.ReaderAt); ok {
+		return ra
+	}
+	return readerAt{r}
+}
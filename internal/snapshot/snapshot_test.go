@@ -0,0 +1,300 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/gob"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrostkowski/protocache/internal/keyring"
+	"github.com/patrostkowski/protocache/internal/store"
+	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+func testRecords() []store.Record {
+	now := time.Unix(1700000000, 123)
+	return []store.Record{
+		{Key: "plain", Value: []byte("v"), Version: 1},
+		{Key: "empty", Value: []byte{}, Version: 2},
+		{Key: "ttl", Value: []byte("t"), Version: 7, ExpiresAt: now.Add(time.Hour)},
+		{Key: "swr", Value: []byte("s"), Version: 9, StaleAt: now, ExpiresAt: now.Add(time.Minute)},
+		{Key: "idle", Value: bytes.Repeat([]byte("x"), 4096), Version: 10, IdleTimeout: 30 * time.Second},
+	}
+}
+
+func assertRecordsEqual(t *testing.T, want, got []store.Record) {
+	t.Helper()
+	require.Len(t, got, len(want))
+	for i := range want {
+		assert.Equal(t, want[i].Key, got[i].Key)
+		assert.Equal(t, want[i].Value, got[i].Value)
+		assert.Equal(t, want[i].Version, got[i].Version)
+		assert.Equal(t, want[i].IdleTimeout, got[i].IdleTimeout)
+		assert.True(t, want[i].StaleAt.Equal(got[i].StaleAt), "stale_at of %q", want[i].Key)
+		assert.True(t, want[i].ExpiresAt.Equal(got[i].ExpiresAt), "expires_at of %q", want[i].Key)
+	}
+}
+
+// writeGob writes dump the way legacy snapshots were written.
+func writeGob(t *testing.T, w io.Writer, dump map[string][]byte) {
+	t.Helper()
+	gz := gzip.NewWriter(w)
+	require.NoError(t, gob.NewEncoder(gz).Encode(dump))
+	require.NoError(t, gz.Close())
+}
+
+func TestWriteAndRead(t *testing.T) {
+	for _, compression := range []v1alpha.SnapshotCompression{
+		v1alpha.SnapshotCompressionNone,
+		v1alpha.SnapshotCompressionGzip,
+		v1alpha.SnapshotCompressionFlate,
+	} {
+		t.Run(string(compression), func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Write(&buf, slices.Values(testRecords()), Options{Compression: compression}))
+
+			records, info, err := Read(bytes.NewReader(buf.Bytes()), nil)
+			require.NoError(t, err)
+			assert.Equal(t, FormatV2, info.Format)
+			assertRecordsEqual(t, testRecords(), records)
+		})
+	}
+}
+
+func TestWrite_NoRecords(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, slices.Values([]store.Record(nil)), Options{Compression: v1alpha.SnapshotCompressionGzip}))
+
+	records, _, err := Read(bytes.NewReader(buf.Bytes()), nil)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestWrite_UnknownCompression(t *testing.T) {
+	err := Write(io.Discard, slices.Values(testRecords()), Options{Compression: "zstd"})
+	assert.Error(t, err)
+}
+
+func TestRead_Corrupt(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, slices.Values(testRecords()), Options{Compression: v1alpha.SnapshotCompressionNone}))
+	raw := buf.Bytes()
+
+	flipped := bytes.Clone(raw)
+	flipped[headerSize+3] ^= 0xff
+	_, _, err := Read(bytes.NewReader(flipped), nil)
+	assert.ErrorIs(t, err, ErrCorrupt)
+
+	_, _, err = Read(bytes.NewReader(raw[:len(raw)-3]), nil)
+	assert.ErrorIs(t, err, ErrCorrupt)
+
+	_, _, err = Read(bytes.NewReader(raw[:headerSize+10]), nil)
+	assert.ErrorIs(t, err, ErrCorrupt)
+}
+
+func TestRead_Legacy(t *testing.T) {
+	var buf bytes.Buffer
+	writeGob(t, &buf, map[string][]byte{"b": []byte("2"), "a": []byte("1")})
+
+	records, info, err := Read(bytes.NewReader(buf.Bytes()), nil)
+	require.NoError(t, err)
+	assert.Equal(t, FormatLegacy, info.Format)
+	assert.Equal(t, []store.Record{
+		{Key: "a", Value: []byte("1")},
+		{Key: "b", Value: []byte("2")},
+	}, records)
+}
+
+func TestRead_V1(t *testing.T) {
+	var payload bytes.Buffer
+	writeGob(t, &payload, map[string][]byte{"k": []byte("v")})
+
+	header := make([]byte, headerSize)
+	copy(header, headerMagic)
+	binary.BigEndian.PutUint32(header[4:], uint32(FormatV1))
+	binary.BigEndian.PutUint64(header[8:], uint64(payload.Len()))
+	binary.BigEndian.PutUint32(header[16:], crc32.Checksum(payload.Bytes(), crc32cTable))
+	raw := append(header, payload.Bytes()...)
+
+	records, info, err := Read(bytes.NewReader(raw), nil)
+	require.NoError(t, err)
+	assert.Equal(t, FormatV1, info.Format)
+	assert.Equal(t, []store.Record{{Key: "k", Value: []byte("v")}}, records)
+
+	raw[len(raw)-1] ^= 0xff
+	_, _, err = Read(bytes.NewReader(raw), nil)
+	assert.ErrorIs(t, err, ErrCorrupt)
+}
+
+func TestRead_UnsupportedVersion(t *testing.T) {
+	header := make([]byte, headerSize)
+	copy(header, headerMagic)
+	binary.BigEndian.PutUint32(header[4:], 99)
+
+	_, _, err := Read(bytes.NewReader(header), nil)
+	assert.ErrorContains(t, err, "unsupported snapshot format version 99")
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "protocache.snap")
+
+	require.NoError(t, WriteFile(path, slices.Values(testRecords()), Options{Compression: v1alpha.SnapshotCompressionGzip}))
+
+	records, info, err := ReadFile(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, FormatCurrent, info.Format)
+	assertRecordsEqual(t, testRecords(), records)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary file must not be left behind")
+}
+
+func TestWriteFile_KeepsPrevious(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "protocache.snap")
+
+	for _, v := range []string{"old", "new"} {
+		records := []store.Record{{Key: "k", Value: []byte(v)}}
+		require.NoError(t, WriteFile(path, slices.Values(records), Options{Compression: v1alpha.SnapshotCompressionGzip}))
+	}
+
+	current, _, err := ReadFile(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new"), current[0].Value)
+
+	prev, _, err := ReadFile(path+PreviousSuffix, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("old"), prev[0].Value)
+}
+
+func TestWriteFile_ErrorKeepsCurrent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "protocache.snap")
+
+	require.NoError(t, WriteFile(path, slices.Values(testRecords()), Options{Compression: v1alpha.SnapshotCompressionGzip}))
+	assert.Error(t, WriteFile(path, slices.Values(testRecords()), Options{Compression: "zstd"}))
+
+	records, _, err := ReadFile(path, nil)
+	require.NoError(t, err)
+	assertRecordsEqual(t, testRecords(), records)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestReadFile_FileNotExist(t *testing.T) {
+	_, _, err := ReadFile(filepath.Join(t.TempDir(), "nonexistent.snap"), nil)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestReadFile_EmptyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.snap")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	_, _, err := ReadFile(path, nil)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func testKeyring(t *testing.T, primary byte, decryption ...byte) *keyring.Keyring {
+	t.Helper()
+	var old [][]byte
+	for _, b := range decryption {
+		old = append(old, bytes.Repeat([]byte{b}, keyring.KeySize))
+	}
+	keys, err := keyring.New(bytes.Repeat([]byte{primary}, keyring.KeySize), old...)
+	require.NoError(t, err)
+	return keys
+}
+
+func TestWriteAndRead_Encrypted(t *testing.T) {
+	keys := testKeyring(t, 1)
+	records := append(testRecords(), store.Record{Key: "big", Value: bytes.Repeat([]byte("secret"), 3*segmentSize)})
+
+	for _, compression := range []v1alpha.SnapshotCompression{
+		v1alpha.SnapshotCompressionNone,
+		v1alpha.SnapshotCompressionGzip,
+	} {
+		t.Run(string(compression), func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Write(&buf, slices.Values(records), Options{Compression: compression, Keys: keys}))
+			assert.NotContains(t, buf.String(), "secret")
+
+			got, info, err := Read(bytes.NewReader(buf.Bytes()), keys)
+			require.NoError(t, err)
+			assert.True(t, info.Encrypted)
+			assert.Equal(t, keys.Primary().ID, info.KeyID)
+			assertRecordsEqual(t, records, got)
+		})
+	}
+}
+
+func TestRead_EncryptedKeyErrors(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, slices.Values(testRecords()), Options{
+		Compression: v1alpha.SnapshotCompressionGzip,
+		Keys:        testKeyring(t, 1),
+	}))
+
+	_, _, err := Read(bytes.NewReader(buf.Bytes()), nil)
+	assert.ErrorIs(t, err, ErrNoKey)
+
+	_, _, err = Read(bytes.NewReader(buf.Bytes()), testKeyring(t, 2))
+	assert.ErrorIs(t, err, keyring.ErrUnknownKey)
+
+	// After a rotation the old key still opens existing snapshots.
+	records, _, err := Read(bytes.NewReader(buf.Bytes()), testKeyring(t, 2, 1))
+	require.NoError(t, err)
+	assertRecordsEqual(t, testRecords(), records)
+}
+
+func TestEncryptedSegments(t *testing.T) {
+	key := testKeyring(t, 1).Primary()
+	header := make([]byte, headerSize)
+	copy(header[nonceOffset:], "prefix!!")
+
+	for _, size := range []int{0, 1, segmentSize, segmentSize + 1, 3 * segmentSize} {
+		plaintext := bytes.Repeat([]byte{'x'}, size)
+		var sealed bytes.Buffer
+		w := newEncryptWriter(&sealed, key, header)
+		_, err := w.Write(plaintext)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		opened, err := io.ReadAll(newDecryptReader(bytes.NewReader(sealed.Bytes()), key, header))
+		require.NoError(t, err, "size %d", size)
+		assert.Equal(t, plaintext, opened, "size %d", size)
+
+		if size > segmentSize {
+			// Dropping the last segment must not go unnoticed.
+			truncated := sealed.Bytes()[:segmentSize+tagSize]
+			_, err = io.ReadAll(newDecryptReader(bytes.NewReader(truncated), key, header))
+			assert.ErrorIs(t, err, ErrCorrupt, "size %d", size)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync/atomic"
+	"time"
+)
+
+// SetOptions carries optional per-key settings applied on Set.
+type SetOptions struct {
+	// IdleTimeout expires the key once it has not been read or touched
+	// for the given duration. Zero disables idle expiration.
+	IdleTimeout time.Duration
+	// SoftTTL marks the entry stale after the given duration. Stale
+	// entries are still served until HardTTL elapses.
+	SoftTTL time.Duration
+	// HardTTL expires the entry after the given duration.
+	HardTTL time.Duration
+}
+
+// Item is a key, value and options triple used by batch writes.
+type Item struct {
+	Key     string
+	Value   []byte
+	Options SetOptions
+}
+
+// Entry is a value held by a store together with its metadata.
+// Entries are replaced, never mutated, on Set; only the access
+// timestamp is updated in place.
+type Entry struct {
+	Value []byte
+	// Version is assigned by the store on every write and increases
+	// monotonically across all keys of a store.
+	Version     uint64
+	IdleTimeout time.Duration
+	// StaleAt and ExpiresAt are zero when the entry has no soft or hard TTL.
+	StaleAt   time.Time
+	ExpiresAt time.Time
+
+	lastAccess atomic.Int64
+	refreshing atomic.Bool
+	etag       atomic.Pointer[string]
+}
+
+func newEntry(value []byte, opts SetOptions, now time.Time) *Entry {
+	e := &Entry{
+		Value:       value,
+		IdleTimeout: opts.IdleTimeout,
+	}
+	if opts.SoftTTL > 0 {
+		e.StaleAt = now.Add(opts.SoftTTL)
+	}
+	if opts.HardTTL > 0 {
+		e.ExpiresAt = now.Add(opts.HardTTL)
+	}
+	e.lastAccess.Store(now.UnixNano())
+	return e
+}
+
+// withValue returns a copy of the entry holding value. The expiration
+// metadata is kept, as for an in-place update.
+func (e *Entry) withValue(value []byte, now time.Time) *Entry {
+	next := &Entry{
+		Value:       value,
+		IdleTimeout: e.IdleTimeout,
+		StaleAt:     e.StaleAt,
+		ExpiresAt:   e.ExpiresAt,
+	}
+	next.lastAccess.Store(now.UnixNano())
+	return next
+}
+
+// LastAccess returns the time the entry was last written, read or touched.
+func (e *Entry) LastAccess() time.Time {
+	return time.Unix(0, e.lastAccess.Load())
+}
+
+func (e *Entry) touch(now time.Time) {
+	e.lastAccess.Store(now.UnixNano())
+}
+
+// Expired reports whether the entry is no longer visible at now.
+func (e *Entry) Expired(now time.Time) bool {
+	if !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt) {
+		return true
+	}
+	if e.IdleTimeout <= 0 {
+		return false
+	}
+	return now.Sub(e.LastAccess()) >= e.IdleTimeout
+}
+
+// Expiring reports whether the entry has an idle timeout or a soft or
+// hard TTL.
+func (e *Entry) Expiring() bool {
+	return e.IdleTimeout > 0 || !e.StaleAt.IsZero() || !e.ExpiresAt.IsZero()
+}
+
+// Stale reports whether the soft TTL of the entry has elapsed at now.
+func (e *Entry) Stale(now time.Time) bool {
+	return !e.StaleAt.IsZero() && !now.Before(e.StaleAt)
+}
+
+// ETag returns the content hash of the entry value. It is computed on
+// first use and cached, since entries are never mutated.
+func (e *Entry) ETag() string {
+	if tag := e.etag.Load(); tag != nil {
+		return *tag
+	}
+	tag := ETag(e.Value)
+	e.etag.Store(&tag)
+	return tag
+}
+
+// ETag returns a content hash of value suitable for conditional reads.
+func ETag(value []byte) string {
+	sum := sha256.Sum256(value)
+	return hex.EncodeToString(sum[:16])
+}
+
+// ClaimRefresh returns true for exactly one caller per entry. It is used
+// to tell a single reader of a stale entry to refresh it.
+func (e *Entry) ClaimRefresh() bool {
+	return e.refreshing.CompareAndSwap(false, true)
+}
@@ -16,7 +16,11 @@ package store
 
 type StoreError string
 
-const StoreErrorKeyNotFound StoreError = "key not found"
+const (
+	StoreErrorKeyNotFound StoreError = "key not found"
+	StoreErrorNotInteger  StoreError = "value is not an integer"
+	StoreErrorOverflow    StoreError = "increment would overflow"
+)
 
 func (e StoreError) Error() string {
 	return string(e)
@@ -0,0 +1,53 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import "time"
+
+type EventType string
+
+const (
+	EventSet    EventType = "set"
+	EventDelete EventType = "delete"
+	EventExpire EventType = "expire"
+	EventEvict  EventType = "evict"
+	EventClear  EventType = "clear"
+)
+
+// Event describes a change to the keyspace. Value and Entry are only
+// set for EventSet and Key is empty for EventClear.
+type Event struct {
+	Type  EventType
+	Key   string
+	Value []byte
+	// Entry is the entry stored by the change. Handlers must not modify
+	// it.
+	Entry *Entry
+	// Restored reports that a set comes from Restore, which loads entries
+	// that already exist elsewhere rather than applying a new write.
+	Restored bool
+	Time     time.Time
+}
+
+// EventHandler is called synchronously for every keyspace change, in
+// the order the changes are applied. It must not block or call back
+// into the store.
+type EventHandler func(Event)
+
+// CommitHandler is called once at the end of every store call that
+// emitted events, after the last of them and before the change becomes
+// visible to other callers. Handlers that make events durable can do so
+// once per call rather than once per event.
+type CommitHandler func()
@@ -29,7 +29,7 @@ type EvictionStrategy interface {
 	OnAccess(key string)
 	OnInsert(key string, size int)
 	OnDelete(key string)
-	Evict(data map[string][]byte) (evictedKey string, shouldEvict bool)
+	Evict(data map[string]*Entry) (evictedKey string, shouldEvict bool)
 	Reset()
 }
 
@@ -72,7 +72,7 @@ func (l *LRUStrategy) OnDelete(key string) {
 	delete(l.access, key)
 }
 
-func (l *LRUStrategy) Evict(data map[string][]byte) (string, bool) {
+func (l *LRUStrategy) Evict(data map[string]*Entry) (string, bool) {
 	if len(data) < l.capacity {
 		return "", false
 	}
@@ -24,13 +24,13 @@ import (
 func TestLRUEviction_EvictsLeastRecentlyUsed(t *testing.T) {
 	capacity := 3
 	strategy := NewLRUStrategy(capacity)
-	data := make(map[string][]byte)
+	data := make(map[string]*Entry)
 
 	// Insert 3 keys
 	for i, k := range []string{"a", "b", "c"} {
 		key := k
 		strategy.OnInsert(key, 1)
-		data[key] = []byte{byte(i)}
+		data[key] = &Entry{Value: []byte{byte(i)}}
 		// Sleep to make timestamps different
 		time.Sleep(10 * time.Millisecond)
 	}
@@ -40,7 +40,7 @@ func TestLRUEviction_EvictsLeastRecentlyUsed(t *testing.T) {
 
 	// Insert a 4th key, should trigger eviction
 	strategy.OnInsert("d", 1)
-	data["d"] = []byte{3}
+	data["d"] = &Entry{Value: []byte{3}}
 
 	evictKey, shouldEvict := strategy.Evict(data)
 	assert.True(t, shouldEvict)
@@ -60,8 +60,8 @@ func TestLRUStrategy_Reset(t *testing.T) {
 
 	strategy.Reset()
 
-	evictKey, shouldEvict := strategy.Evict(map[string][]byte{
-		"x": []byte("value"),
+	evictKey, shouldEvict := strategy.Evict(map[string]*Entry{
+		"x": {Value: []byte("value")},
 	})
 	assert.False(t, shouldEvict)
 	assert.Equal(t, "", evictKey)
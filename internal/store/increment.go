@@ -0,0 +1,39 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"math"
+	"strconv"
+)
+
+// incrementValue parses current as a decimal integer, adds delta and
+// returns the encoded and numeric result. A nil current counts as zero.
+func incrementValue(current []byte, delta int64) ([]byte, int64, error) {
+	var n int64
+	if current != nil {
+		var err error
+		n, err = strconv.ParseInt(string(current), 10, 64)
+		if err != nil {
+			return nil, 0, StoreErrorNotInteger
+		}
+	}
+
+	if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+		return nil, 0, StoreErrorOverflow
+	}
+	n += delta
+	return strconv.AppendInt(nil, n, 10), n, nil
+}
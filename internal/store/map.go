@@ -15,87 +15,378 @@
 package store
 
 import (
-	"maps"
-	"slices"
 	"sync"
+	"time"
 
 	"github.com/patrostkowski/protocache/internal/logger"
 )
 
 type MapStore struct {
-	data             map[string][]byte
+	data             map[string]*Entry
 	mu               sync.RWMutex
 	evictionStrategy EvictionStrategy
+	onEvent          EventHandler
+	onCommit         CommitHandler
+	emitted          bool
+	version          uint64
+
+	// snapshotMu serializes snapshots; pit is the running one, if any.
+	snapshotMu sync.Mutex
+	pit        *pointInTime
 }
 
 func NewMapStore(strategy EvictionStrategy) *MapStore {
 	return &MapStore{
-		data:             make(map[string][]byte),
+		data:             make(map[string]*Entry),
 		evictionStrategy: strategy,
 	}
 }
 
 func (m *MapStore) Set(key string, value []byte) error {
+	return m.SetWithOptions(key, value, SetOptions{})
+}
+
+func (m *MapStore) SetWithOptions(key string, value []byte, opts SetOptions) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.commit()
+
+	m.setLocked(key, value, opts, time.Now())
+	return nil
+}
+
+func (m *MapStore) SetMany(items []Item) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	defer m.commit()
 
+	now := time.Now()
+	for _, item := range items {
+		m.setLocked(item.Key, item.Value, item.Options, now)
+	}
+	return nil
+}
+
+func (m *MapStore) Restore(records []Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.commit()
+
+	m.restoreLocked(records, false)
+	return nil
+}
+
+func (m *MapStore) Replay(records []Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.commit()
+
+	m.restoreLocked(records, true)
+	return nil
+}
+
+func (m *MapStore) Replace(records []Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.commit()
+
+	m.clearLocked()
+	m.restoreLocked(records, false)
+	return nil
+}
+
+// restoreLocked stores records. Unless keepVersions is set, versions up
+// to the counter may already be in use and are replaced; restored
+// versions above it are unique, since they come from a single source
+// store.
+func (m *MapStore) restoreLocked(records []Record, keepVersions bool) {
+	base := m.version
+	m.version = max(m.version, maxRecordVersion(records))
+
+	now := time.Now()
+	for _, r := range records {
+		if r.expired(now) {
+			continue
+		}
+		e := r.entry(now)
+		if e.Version == 0 || (!keepVersions && e.Version <= base) {
+			e.Version = m.nextVersion()
+		}
+		m.putLocked(r.Key, e, now, true)
+	}
+}
+
+func (m *MapStore) setLocked(key string, value []byte, opts SetOptions, now time.Time) {
+	e := newEntry(value, opts, now)
+	e.Version = m.nextVersion()
+	m.putLocked(key, e, now, false)
+}
+
+// putLocked inserts a prepared entry, evicting another key first if the
+// eviction policy asks for it. restored marks the set event as coming
+// from Restore.
+func (m *MapStore) putLocked(key string, e *Entry, now time.Time, restored bool) {
 	if m.evictionStrategy != nil {
 		if evictKey, shouldEvict := m.evictionStrategy.Evict(m.data); shouldEvict {
+			m.preserveLocked(evictKey)
 			delete(m.data, evictKey)
 			m.evictionStrategy.OnDelete(evictKey)
 			logger.Debug("Evicted key from store", "key", evictKey)
+			m.emit(Event{Type: EventEvict, Key: evictKey, Time: now})
 		}
-		m.evictionStrategy.OnInsert(key, len(value))
+		m.evictionStrategy.OnInsert(key, len(e.Value))
 	}
 
-	m.data[key] = value
-	return nil
+	m.preserveLocked(key)
+	m.data[key] = e
+	m.emit(Event{Type: EventSet, Key: key, Value: e.Value, Entry: e, Restored: restored, Time: now})
+}
+
+func (m *MapStore) nextVersion() uint64 {
+	m.version++
+	return m.version
 }
 
 func (m *MapStore) Get(key string) ([]byte, error) {
+	e, err := m.GetEntry(key)
+	if err != nil {
+		return nil, err
+	}
+	return e.Value, nil
+}
+
+func (m *MapStore) GetEntry(key string) (*Entry, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	value, exists := m.data[key]
-	if !exists {
+
+	e := m.getLocked(key, time.Now())
+	if e == nil {
 		return nil, StoreErrorKeyNotFound
 	}
+	return e, nil
+}
+
+func (m *MapStore) GetMany(keys []string) []*Entry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	entries := make([]*Entry, len(keys))
+	for i, key := range keys {
+		entries[i] = m.getLocked(key, now)
+	}
+	return entries
+}
+
+func (m *MapStore) getLocked(key string, now time.Time) *Entry {
+	e, exists := m.data[key]
+	if !exists || e.Expired(now) {
+		return nil
+	}
+
 	if m.evictionStrategy != nil {
 		m.evictionStrategy.OnAccess(key)
 	}
-	return value, nil
+	e.touch(now)
+
+	return e
+}
+
+func (m *MapStore) Increment(key string, delta int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.commit()
+
+	now := time.Now()
+	e, exists := m.data[key]
+	if exists && e.Expired(now) {
+		exists = false
+	}
+
+	var current []byte
+	if exists {
+		current = e.Value
+	}
+	value, n, err := incrementValue(current, delta)
+	if err != nil {
+		return 0, err
+	}
+
+	if !exists {
+		m.setLocked(key, value, SetOptions{}, now)
+		return n, nil
+	}
+	next := e.withValue(value, now)
+	next.Version = m.nextVersion()
+	m.preserveLocked(key)
+	m.data[key] = next
+	if m.evictionStrategy != nil {
+		m.evictionStrategy.OnAccess(key)
+	}
+	m.emit(Event{Type: EventSet, Key: key, Value: value, Entry: next, Time: now})
+	return n, nil
+}
+
+func (m *MapStore) Touch(key string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	e, exists := m.data[key]
+	if !exists || e.Expired(now) {
+		return StoreErrorKeyNotFound
+	}
+	e.touch(now)
+	return nil
 }
 
 func (m *MapStore) Delete(key string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	if _, exists := m.data[key]; !exists {
+	defer m.commit()
+
+	if !m.deleteLocked(key, time.Now()) {
 		return StoreErrorKeyNotFound
 	}
+	return nil
+}
+
+func (m *MapStore) DeleteMany(keys []string) []bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.commit()
+
+	now := time.Now()
+	deleted := make([]bool, len(keys))
+	for i, key := range keys {
+		deleted[i] = m.deleteLocked(key, now)
+	}
+	return deleted
+}
+
+func (m *MapStore) DeleteUnchanged(records []Record) []bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.commit()
+
+	now := time.Now()
+	deleted := make([]bool, len(records))
+	for i, r := range records {
+		if e, exists := m.data[r.Key]; exists && e.Version == r.Version {
+			deleted[i] = m.deleteLocked(r.Key, now)
+		}
+	}
+	return deleted
+}
+
+func (m *MapStore) deleteLocked(key string, now time.Time) bool {
+	if _, exists := m.data[key]; !exists {
+		return false
+	}
+
+	m.preserveLocked(key)
 	delete(m.data, key)
 	if m.evictionStrategy != nil {
 		m.evictionStrategy.OnDelete(key)
 	}
-	return nil
+	m.emit(Event{Type: EventDelete, Key: key, Time: now})
+	return true
+}
+
+func (m *MapStore) DeleteExpired(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.commit()
+
+	removed := 0
+	for key, e := range m.data {
+		if !e.Expired(now) {
+			continue
+		}
+		m.preserveLocked(key)
+		delete(m.data, key)
+		if m.evictionStrategy != nil {
+			m.evictionStrategy.OnDelete(key)
+		}
+		m.emit(Event{Type: EventExpire, Key: key, Time: now})
+		removed++
+	}
+	return removed
 }
 
 func (m *MapStore) Clear() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.data = make(map[string][]byte)
+	defer m.commit()
+
+	m.clearLocked()
+}
+
+func (m *MapStore) clearLocked() {
+	m.data = make(map[string]*Entry)
+	// The old map is no longer written to, so a running snapshot can
+	// finish from it.
+	m.pit = nil
 	if m.evictionStrategy != nil {
 		m.evictionStrategy.Reset()
 	}
+	m.emit(Event{Type: EventClear, Time: time.Now()})
+
 	logger.Debug("Cleared entire store")
 }
 
 func (m *MapStore) List() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	keys := make([]string, 0, len(m.data))
+	for key, e := range m.data {
+		if !e.Expired(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (m *MapStore) This() map[string][]byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	snapshot := make(map[string][]byte, len(m.data))
+	for key, e := range m.data {
+		if !e.Expired(now) {
+			snapshot[key] = e.Value
+		}
+	}
+	return snapshot
+}
+
+func (m *MapStore) SetEventHandler(handler EventHandler) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return slices.Collect(maps.Keys(m.data))
+	m.onEvent = handler
 }
 
-func (m *MapStore) This() map[string][]byte {
+func (m *MapStore) SetCommitHandler(handler CommitHandler) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return m.data
+	m.onCommit = handler
+}
+
+func (m *MapStore) emit(e Event) {
+	if m.onEvent != nil {
+		m.onEvent(e)
+	}
+	m.emitted = true
+}
+
+// commit ends a store call. Writers defer it after deferring the unlock,
+// so it runs while m.mu is still held.
+func (m *MapStore) commit() {
+	if m.emitted && m.onCommit != nil {
+		m.onCommit()
+	}
+	m.emitted = false
 }
@@ -0,0 +1,69 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import "time"
+
+// Record is an entry in the portable form used by snapshots. Expiry is
+// kept as absolute times, so a restored entry keeps its remaining
+// lifetime rather than starting over.
+type Record struct {
+	Key         string
+	Value       []byte
+	Version     uint64
+	IdleTimeout time.Duration
+	StaleAt     time.Time
+	ExpiresAt   time.Time
+}
+
+// entry builds the entry for a restored record. The idle timer starts
+// at now, since the last access time is not persisted.
+func (r Record) entry(now time.Time) *Entry {
+	e := &Entry{
+		Value:       r.Value,
+		Version:     r.Version,
+		IdleTimeout: r.IdleTimeout,
+		StaleAt:     r.StaleAt,
+		ExpiresAt:   r.ExpiresAt,
+	}
+	e.lastAccess.Store(now.UnixNano())
+	return e
+}
+
+// record returns the entry stored at key as a Record.
+func (e *Entry) record(key string) Record {
+	return Record{
+		Key:         key,
+		Value:       e.Value,
+		Version:     e.Version,
+		IdleTimeout: e.IdleTimeout,
+		StaleAt:     e.StaleAt,
+		ExpiresAt:   e.ExpiresAt,
+	}
+}
+
+// expired reports whether the record would already be expired when
+// restored at now.
+func (r Record) expired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && !now.Before(r.ExpiresAt)
+}
+
+func maxRecordVersion(records []Record) uint64 {
+	var v uint64
+	for _, r := range records {
+		v = max(v, r.Version)
+	}
+	return v
+}
@@ -0,0 +1,237 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"iter"
+	"sync"
+	"time"
+)
+
+// snapshotChunkSize bounds how many keys a snapshot visits per lock
+// acquisition, and so how long it can hold up writers.
+const snapshotChunkSize = 1024
+
+// visited marks keys a snapshot has already returned.
+var visited = &Entry{}
+
+// pointInTime lets a snapshot iterate a store that keeps changing. The
+// first write to a key after the snapshot began saves the entry the key
+// held at that moment, nil if it had none. Keys the snapshot has already
+// returned are marked visited, so later writes to them are not saved.
+//
+// Every key is thus either still unchanged in the store when the
+// snapshot reaches it, or saved. Saved entries are returned once the
+// store has been iterated.
+type pointInTime struct {
+	// mu guards saved for SyncMapStore; MapStore uses its own lock.
+	mu    sync.Mutex
+	saved map[string]*Entry
+	now   time.Time
+}
+
+func newPointInTime() *pointInTime {
+	return &pointInTime{saved: make(map[string]*Entry), now: time.Now()}
+}
+
+// preserve saves old as the entry of key, unless key was written or
+// visited since the snapshot began.
+func (p *pointInTime) preserve(key string, old *Entry) {
+	if _, ok := p.saved[key]; !ok {
+		p.saved[key] = old
+	}
+}
+
+// visit reports whether the snapshot should take key from the store, and
+// marks it visited.
+func (p *pointInTime) visit(key string) bool {
+	if _, ok := p.saved[key]; ok {
+		return false
+	}
+	p.saved[key] = visited
+	return true
+}
+
+// remaining returns the saved entries that existed when the snapshot
+// began.
+func (p *pointInTime) remaining() []Record {
+	var records []Record
+	for key, e := range p.saved {
+		if e != nil && e != visited && !e.Expired(p.now) {
+			records = append(records, e.record(key))
+		}
+	}
+	return records
+}
+
+func yieldAll(yield func(Record) bool, records []Record) bool {
+	for _, r := range records {
+		if !yield(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Snapshot holds the lock for at most snapshotChunkSize keys at a time
+// and never while calling yield.
+func (m *MapStore) Snapshot() iter.Seq[Record] {
+	return func(yield func(Record) bool) {
+		m.snapshotMu.Lock()
+		defer m.snapshotMu.Unlock()
+
+		m.mu.Lock()
+		p := newPointInTime()
+		m.pit = p
+		// Clear swaps in a new map and detaches the snapshot, which then
+		// keeps iterating the old one.
+		data := m.data
+		defer func() {
+			m.mu.Lock()
+			if m.pit == p {
+				m.pit = nil
+			}
+			m.mu.Unlock()
+		}()
+
+		chunk := make([]Record, 0, snapshotChunkSize)
+		n := 0
+		for key, e := range data {
+			if p.visit(key) && !e.Expired(p.now) {
+				chunk = append(chunk, e.record(key))
+			}
+			if n++; n%snapshotChunkSize != 0 {
+				continue
+			}
+			m.mu.Unlock()
+			if !yieldAll(yield, chunk) {
+				return
+			}
+			chunk = chunk[:0]
+			m.mu.Lock()
+		}
+		if m.pit == p {
+			m.pit = nil
+		}
+		m.mu.Unlock()
+
+		if yieldAll(yield, chunk) {
+			yieldAll(yield, p.remaining())
+		}
+	}
+}
+
+// preserveLocked must be called before every change to m.data[key].
+func (m *MapStore) preserveLocked(key string) {
+	if m.pit != nil {
+		m.pit.preserve(key, m.data[key])
+	}
+}
+
+// Snapshot visits keys without a lock; writers only pay for a mutex
+// while a snapshot is running.
+func (s *SyncMapStore) Snapshot() iter.Seq[Record] {
+	return func(yield func(Record) bool) {
+		s.snapshotMu.Lock()
+		defer s.snapshotMu.Unlock()
+
+		p := newPointInTime()
+		s.pit.Store(p)
+		defer s.pit.Store(nil)
+
+		ok := true
+		s.data.Range(func(k, _ any) bool {
+			key := k.(string)
+			var r Record
+			var take bool
+			p.mu.Lock()
+			if p.visit(key) {
+				if v, loaded := s.data.Load(key); loaded && !v.(*Entry).Expired(p.now) {
+					r, take = v.(*Entry).record(key), true
+				}
+			}
+			p.mu.Unlock()
+			if take && !yield(r) {
+				ok = false
+			}
+			return ok
+		})
+		if !ok {
+			return
+		}
+
+		s.pit.Store(nil)
+		p.mu.Lock()
+		remaining := p.remaining()
+		p.mu.Unlock()
+		yieldAll(yield, remaining)
+	}
+}
+
+// preserve must be called before every change to the entry of key.
+func (s *SyncMapStore) preserve(key string) {
+	p := s.pit.Load()
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var old *Entry
+	if v, ok := s.data.Load(key); ok {
+		old = v.(*Entry)
+	}
+	p.preserve(key, old)
+}
+
+// Scan holds the lock for at most snapshotChunkSize keys at a time and
+// never while calling yield.
+func (m *MapStore) Scan() iter.Seq[Record] {
+	return func(yield func(Record) bool) {
+		m.mu.RLock()
+		// Clear swaps in a new map; the scan keeps iterating the old one.
+		data := m.data
+		now := time.Now()
+
+		chunk := make([]Record, 0, snapshotChunkSize)
+		for key, e := range data {
+			if !e.Expired(now) {
+				chunk = append(chunk, Record{Key: key, Version: e.Version})
+			}
+			if len(chunk) < snapshotChunkSize {
+				continue
+			}
+			m.mu.RUnlock()
+			if !yieldAll(yield, chunk) {
+				return
+			}
+			chunk = chunk[:0]
+			m.mu.RLock()
+		}
+		m.mu.RUnlock()
+		yieldAll(yield, chunk)
+	}
+}
+
+func (s *SyncMapStore) Scan() iter.Seq[Record] {
+	return func(yield func(Record) bool) {
+		now := time.Now()
+		s.data.Range(func(k, v any) bool {
+			if e := v.(*Entry); !e.Expired(now) {
+				return yield(Record{Key: k.(string), Version: e.Version})
+			}
+			return true
+		})
+	}
+}
@@ -15,16 +15,69 @@
 package store
 
 import (
+	"iter"
+	"time"
+
 	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
 )
 
 type Store interface {
 	Set(key string, value []byte) error
+	SetWithOptions(key string, value []byte, opts SetOptions) error
 	Get(key string) ([]byte, error)
+	// GetEntry behaves like Get but returns the entry with its metadata.
+	GetEntry(key string) (*Entry, error)
+	// GetMany looks up keys in one pass. The result is index-aligned with
+	// keys and holds nil for keys that are missing or expired.
+	GetMany(keys []string) []*Entry
+	// SetMany stores all items in one pass.
+	SetMany(items []Item) error
+	// Restore loads records in bulk, keeping their expiry and, unless it
+	// could collide with a version already handed out, their version.
+	// Records that have expired are skipped. Restored keys count against
+	// the eviction policy like any other insert and emit set events.
+	Restore(records []Record) error
+	// Replay loads records like Restore but keeps every nonzero version.
+	// It is meant for records from the store's own history, such as an
+	// append-only log, whose versions are unique by construction.
+	Replay(records []Record) error
+	// Replace clears the store and restores records in one step, like
+	// Clear followed by Restore.
+	Replace(records []Record) error
+	// Increment adds delta to the decimal integer stored at key and
+	// returns the new value. A missing key counts as zero.
+	Increment(key string, delta int64) (int64, error)
+	Touch(key string) error
 	Delete(key string) error
+	// DeleteMany removes keys in one pass and reports, index-aligned with
+	// keys, whether each key existed.
+	DeleteMany(keys []string) []bool
+	// DeleteUnchanged removes the key of each record whose entry still
+	// has the record's version, and reports which keys it removed.
+	DeleteUnchanged(records []Record) []bool
+	// DeleteExpired removes every entry that has expired at now and
+	// returns the number of removed entries.
+	DeleteExpired(now time.Time) int
 	Clear()
 	List() []string
 	This() map[string][]byte
+	// Snapshot returns every live entry with its metadata as of the
+	// moment iteration starts, in no particular order. Writers are not
+	// blocked while the snapshot is consumed; the store keeps the
+	// entries they replace until it ends. Snapshots run one at a time.
+	Snapshot() iter.Seq[Record]
+	// Scan returns the key and version of every live entry, without
+	// values. Unlike Snapshot it is not a point in time: keys written
+	// during the scan may or may not be returned. It does not wait for
+	// snapshots, and does not block writers for more than a chunk.
+	Scan() iter.Seq[Record]
+	// SetEventHandler registers the handler that receives keyspace
+	// events. It must be called before the store is used.
+	SetEventHandler(handler EventHandler)
+	// SetCommitHandler registers the handler called at the end of every
+	// store call that emitted events. It must be called before the store
+	// is used.
+	SetCommitHandler(handler CommitHandler)
 }
 
 func NewStore(engine v1alpha.StoreEngine, policy v1alpha.EvictionPolicy) Store {
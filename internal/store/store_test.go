@@ -15,12 +15,17 @@
 package store
 
 import (
+	"iter"
+	"slices"
 	"sort"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func storesUnderTest() map[string]Store {
@@ -179,3 +184,621 @@ func TestMapStore_LRUResetOnClear(t *testing.T) {
 		})
 	}
 }
+
+func TestStore_IdleTimeout(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			err := store.SetWithOptions("idle", []byte("v"), SetOptions{IdleTimeout: 50 * time.Millisecond})
+			assert.NoError(t, err)
+
+			time.Sleep(30 * time.Millisecond)
+			_, err = store.Get("idle")
+			assert.NoError(t, err, "get should extend the idle deadline")
+
+			time.Sleep(30 * time.Millisecond)
+			assert.NoError(t, store.Touch("idle"), "touch should extend the idle deadline")
+
+			time.Sleep(60 * time.Millisecond)
+			_, err = store.Get("idle")
+			assert.ErrorIs(t, err, StoreErrorKeyNotFound)
+			assert.ErrorIs(t, store.Touch("idle"), StoreErrorKeyNotFound)
+			assert.NotContains(t, store.List(), "idle")
+		})
+	}
+}
+
+func TestStore_DeleteExpired(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			store.Clear()
+			_ = store.SetWithOptions("idle", []byte("1"), SetOptions{IdleTimeout: time.Second})
+			_ = store.Set("plain", []byte("2"))
+
+			assert.Equal(t, 0, store.DeleteExpired(time.Now()))
+			assert.Equal(t, 1, store.DeleteExpired(time.Now().Add(2*time.Second)))
+
+			keys := store.List()
+			assert.Equal(t, []string{"plain"}, keys)
+		})
+	}
+}
+
+func TestStore_SoftAndHardTTL(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			err := store.SetWithOptions("hot", []byte("v"), SetOptions{
+				SoftTTL: 20 * time.Millisecond,
+				HardTTL: 60 * time.Millisecond,
+			})
+			assert.NoError(t, err)
+
+			e, err := store.GetEntry("hot")
+			assert.NoError(t, err)
+			assert.False(t, e.Stale(time.Now()))
+
+			time.Sleep(30 * time.Millisecond)
+			e, err = store.GetEntry("hot")
+			assert.NoError(t, err)
+			assert.True(t, e.Stale(time.Now()))
+			assert.True(t, e.ClaimRefresh(), "first caller should claim the refresh")
+			assert.False(t, e.ClaimRefresh(), "refresh should be claimed only once")
+
+			time.Sleep(40 * time.Millisecond)
+			_, err = store.GetEntry("hot")
+			assert.ErrorIs(t, err, StoreErrorKeyNotFound)
+		})
+	}
+}
+
+func TestStore_Events(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			var events []Event
+			store.SetEventHandler(func(e Event) {
+				events = append(events, e)
+			})
+
+			_ = store.Set("a", []byte("1"))
+			_ = store.SetWithOptions("b", []byte("2"), SetOptions{HardTTL: time.Millisecond})
+			_ = store.Delete("a")
+			store.DeleteExpired(time.Now().Add(time.Second))
+			store.Clear()
+
+			var types []EventType
+			for _, e := range events {
+				types = append(types, e.Type)
+			}
+			assert.Equal(t, []EventType{EventSet, EventSet, EventDelete, EventExpire, EventClear}, types)
+			assert.Equal(t, []byte("1"), events[0].Value)
+			assert.Equal(t, "b", events[3].Key)
+		})
+	}
+}
+
+func TestStore_Commit(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			var events, commits int
+			store.SetEventHandler(func(Event) { events++ })
+			store.SetCommitHandler(func() {
+				commits++
+				assert.Positive(t, events)
+				events = 0
+			})
+
+			_ = store.SetWithOptions("a", []byte("1"), SetOptions{HardTTL: time.Millisecond})
+			_ = store.SetWithOptions("b", []byte("2"), SetOptions{HardTTL: time.Millisecond})
+			assert.Equal(t, 2, commits)
+
+			assert.Equal(t, 2, store.DeleteExpired(time.Now().Add(time.Second)))
+			assert.Equal(t, 3, commits)
+
+			assert.ErrorIs(t, store.Delete("missing"), StoreErrorKeyNotFound)
+			assert.Equal(t, 0, store.DeleteExpired(time.Now()))
+			assert.Equal(t, 3, commits)
+		})
+	}
+}
+
+func TestStore_SetEventCarriesEntry(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			var entry *Entry
+			store.SetEventHandler(func(e Event) { entry = e.Entry })
+
+			_ = store.SetWithOptions("a", []byte("1"), SetOptions{IdleTimeout: time.Minute, HardTTL: time.Hour})
+			require.NotNil(t, entry)
+			assert.Equal(t, time.Minute, entry.IdleTimeout)
+			assert.False(t, entry.ExpiresAt.IsZero())
+
+			stored, err := store.GetEntry("a")
+			require.NoError(t, err)
+			assert.Equal(t, stored.Version, entry.Version)
+		})
+	}
+}
+
+func TestMapStore_EvictEvent(t *testing.T) {
+	store := NewMapStore(NewEvictionStrategy(v1alpha.EvictionLRU, 2))
+
+	var evicted []string
+	store.SetEventHandler(func(e Event) {
+		if e.Type == EventEvict {
+			evicted = append(evicted, e.Key)
+		}
+	})
+
+	_ = store.Set("a", []byte("1"))
+	time.Sleep(time.Millisecond)
+	_ = store.Set("b", []byte("2"))
+	_ = store.Set("c", []byte("3"))
+
+	assert.Equal(t, []string{"a"}, evicted)
+}
+
+func TestStore_BatchOperations(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			err := store.SetMany([]Item{
+				{Key: "a", Value: []byte("1")},
+				{Key: "b", Value: []byte("2")},
+			})
+			assert.NoError(t, err)
+
+			entries := store.GetMany([]string{"a", "missing", "b"})
+			assert.Len(t, entries, 3)
+			assert.Equal(t, []byte("1"), entries[0].Value)
+			assert.Nil(t, entries[1])
+			assert.Equal(t, []byte("2"), entries[2].Value)
+
+			deleted := store.DeleteMany([]string{"a", "missing"})
+			assert.Equal(t, []bool{true, false}, deleted)
+
+			_, err = store.Get("a")
+			assert.ErrorIs(t, err, StoreErrorKeyNotFound)
+		})
+	}
+}
+
+func TestStore_DeleteUnchanged(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			_ = store.Set("same", []byte("1"))
+			_ = store.Set("changed", []byte("1"))
+			same, _ := store.GetEntry("same")
+			changed, _ := store.GetEntry("changed")
+			_ = store.Set("changed", []byte("2"))
+
+			deleted := store.DeleteUnchanged([]Record{
+				{Key: "same", Version: same.Version},
+				{Key: "changed", Version: changed.Version},
+				{Key: "missing", Version: 1},
+			})
+			assert.Equal(t, []bool{true, false, false}, deleted)
+
+			_, err := store.Get("same")
+			assert.ErrorIs(t, err, StoreErrorKeyNotFound)
+			val, err := store.Get("changed")
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("2"), val)
+		})
+	}
+}
+
+func TestStore_Increment(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			n, err := store.Increment("counter", 5)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(5), n)
+
+			n, err = store.Increment("counter", -2)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(3), n)
+
+			val, err := store.Get("counter")
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("3"), val)
+
+			_ = store.Set("text", []byte("abc"))
+			_, err = store.Increment("text", 1)
+			assert.ErrorIs(t, err, StoreErrorNotInteger)
+
+			_ = store.Set("max", []byte("9223372036854775807"))
+			_, err = store.Increment("max", 1)
+			assert.ErrorIs(t, err, StoreErrorOverflow)
+		})
+	}
+}
+
+func TestStore_IncrementKeepsExpiration(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			_ = store.SetWithOptions("counter", []byte("1"), SetOptions{HardTTL: time.Hour})
+			_, err := store.Increment("counter", 1)
+			assert.NoError(t, err)
+
+			e, err := store.GetEntry("counter")
+			assert.NoError(t, err)
+			assert.False(t, e.ExpiresAt.IsZero())
+		})
+	}
+}
+
+func TestStore_Versions(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			_ = store.Set("a", []byte("1"))
+			first, err := store.GetEntry("a")
+			assert.NoError(t, err)
+
+			_ = store.Set("a", []byte("2"))
+			second, err := store.GetEntry("a")
+			assert.NoError(t, err)
+			assert.Greater(t, second.Version, first.Version)
+
+			_, _ = store.Increment("n", 1)
+			third, err := store.GetEntry("n")
+			assert.NoError(t, err)
+			assert.Greater(t, third.Version, second.Version)
+		})
+	}
+}
+
+func TestStore_Transaction(t *testing.T) {
+	for name, s := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			store := s.(Transactional)
+			_ = store.Set("alice", []byte("10"))
+			_ = store.Set("bob", []byte("5"))
+			alice, _ := store.GetEntry("alice")
+
+			results, err := store.Transaction(
+				[]Condition{
+					{Type: ConditionVersionEquals, Key: "alice", Version: alice.Version},
+					{Type: ConditionExists, Key: "bob"},
+				},
+				[]Op{
+					{Type: OpIncrement, Key: "alice", Delta: -3},
+					{Type: OpIncrement, Key: "bob", Delta: 3},
+					{Type: OpGet, Key: "bob"},
+				},
+			)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("7"), results[0].Value)
+			assert.Equal(t, []byte("8"), results[1].Value)
+			assert.Equal(t, []byte("8"), results[2].Value)
+
+			// The version of alice changed, so replaying the same condition fails.
+			_, err = store.Transaction(
+				[]Condition{{Type: ConditionVersionEquals, Key: "alice", Version: alice.Version}},
+				[]Op{{Type: OpDelete, Key: "alice"}},
+			)
+			var condErr *ConditionFailedError
+			assert.ErrorAs(t, err, &condErr)
+			assert.Equal(t, 0, condErr.Index)
+
+			// A failing op discards the writes staged before it.
+			_ = store.Set("text", []byte("abc"))
+			_, err = store.Transaction(nil, []Op{
+				{Type: OpSet, Key: "alice", Value: []byte("0")},
+				{Type: OpIncrement, Key: "text", Delta: 1},
+			})
+			assert.ErrorIs(t, err, StoreErrorNotInteger)
+
+			val, err := store.Get("alice")
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("7"), val)
+		})
+	}
+}
+
+func TestStore_Replace(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			_ = store.Set("old", []byte("1"))
+			_ = store.Set("kept", []byte("1"))
+
+			err := store.Replace([]Record{{Key: "kept", Value: []byte("2")}, {Key: "new", Value: []byte("3")}})
+			assert.NoError(t, err)
+
+			keys := store.List()
+			sort.Strings(keys)
+			assert.Equal(t, []string{"kept", "new"}, keys)
+			val, err := store.Get("kept")
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("2"), val)
+		})
+	}
+}
+
+func TestMapStore_ReplaceIsNeverSeenEmpty(t *testing.T) {
+	store := NewMapStore(nil)
+	_ = store.Set("k", []byte("v"))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 200 {
+			_ = store.Replace([]Record{{Key: "k", Value: []byte("v")}})
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		_, err := store.Get("k")
+		require.NoError(t, err)
+	}
+}
+
+func TestStore_Restore(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			err := store.Restore([]Record{
+				{Key: "plain", Value: []byte("1"), Version: 7},
+				{Key: "ttl", Value: []byte("2"), Version: 3, StaleAt: now.Add(time.Minute), ExpiresAt: now.Add(time.Hour)},
+				{Key: "gone", Value: []byte("3"), Version: 5, ExpiresAt: now.Add(-time.Second)},
+				{Key: "unversioned", Value: []byte("4")},
+			})
+			assert.NoError(t, err)
+
+			keys := store.List()
+			sort.Strings(keys)
+			assert.Equal(t, []string{"plain", "ttl", "unversioned"}, keys)
+
+			plain, err := store.GetEntry("plain")
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(7), plain.Version)
+
+			ttl, err := store.GetEntry("ttl")
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(3), ttl.Version)
+			assert.WithinDuration(t, now.Add(time.Hour), ttl.ExpiresAt, 0)
+			assert.WithinDuration(t, now.Add(time.Minute), ttl.StaleAt, 0)
+
+			unversioned, err := store.GetEntry("unversioned")
+			assert.NoError(t, err)
+			assert.Greater(t, unversioned.Version, uint64(7))
+
+			// Later writes never reuse a restored version.
+			_ = store.Set("next", []byte("5"))
+			next, err := store.GetEntry("next")
+			assert.NoError(t, err)
+			assert.Greater(t, next.Version, unversioned.Version)
+
+			// Versions already handed out are replaced on a merge.
+			assert.NoError(t, store.Restore([]Record{{Key: "merged", Value: []byte("6"), Version: 2}}))
+			merged, err := store.GetEntry("merged")
+			assert.NoError(t, err)
+			assert.Greater(t, merged.Version, next.Version)
+		})
+	}
+}
+
+func TestStore_Replay(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, store.Restore([]Record{{Key: "a", Value: []byte("1"), Version: 9}}))
+
+			// Replayed versions are kept even below the counter.
+			err := store.Replay([]Record{
+				{Key: "b", Value: []byte("2"), Version: 4},
+				{Key: "unversioned", Value: []byte("3")},
+			})
+			assert.NoError(t, err)
+
+			b, err := store.GetEntry("b")
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(4), b.Version)
+
+			unversioned, err := store.GetEntry("unversioned")
+			assert.NoError(t, err)
+			assert.Greater(t, unversioned.Version, uint64(9))
+
+			assert.NoError(t, store.Replay([]Record{{Key: "c", Value: []byte("4"), Version: 12}}))
+			_ = store.Set("next", []byte("5"))
+			next, err := store.GetEntry("next")
+			assert.NoError(t, err)
+			assert.Greater(t, next.Version, uint64(12))
+		})
+	}
+}
+
+func TestMapStore_RestoreRespectsEviction(t *testing.T) {
+	store := NewMapStore(NewLRUStrategy(2))
+	var evicted []string
+	store.SetEventHandler(func(e Event) {
+		if e.Type == EventEvict {
+			evicted = append(evicted, e.Key)
+		}
+	})
+
+	err := store.Restore([]Record{
+		{Key: "a", Value: []byte("1")},
+		{Key: "b", Value: []byte("2")},
+		{Key: "c", Value: []byte("3")},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, store.List(), 2)
+	assert.Len(t, evicted, 1)
+}
+
+func snapshotValues(records []Record) map[string]string {
+	values := make(map[string]string, len(records))
+	for _, r := range records {
+		values[r.Key] = string(r.Value)
+	}
+	return values
+}
+
+func TestStore_SnapshotIsPointInTime(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			const n = 3 * snapshotChunkSize
+			want := make(map[string]string, n)
+			for i := 0; i < n; i++ {
+				key := strconv.Itoa(i)
+				_ = store.Set(key, []byte("old"))
+				want[key] = "old"
+			}
+
+			var records []Record
+			for r := range store.Snapshot() {
+				if len(records) == 0 {
+					// Writers are not blocked while the snapshot runs.
+					for i := 0; i < n; i += 2 {
+						_ = store.Set(strconv.Itoa(i), []byte("new"))
+					}
+					for i := 1; i < n; i += 4 {
+						_ = store.Delete(strconv.Itoa(i))
+					}
+					_ = store.Set("added", []byte("new"))
+					store.DeleteExpired(time.Now())
+				}
+				records = append(records, r)
+			}
+
+			assert.Len(t, records, n, "every key must be returned once")
+			assert.Equal(t, want, snapshotValues(records))
+
+			got, err := store.Get("0")
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("new"), got)
+			assert.Len(t, snapshotValues(slices.Collect(store.Snapshot())), n-n/4+1)
+		})
+	}
+}
+
+func TestStore_SnapshotAcrossClear(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			const n = 2 * snapshotChunkSize
+			for i := 0; i < n; i++ {
+				_ = store.Set(strconv.Itoa(i), []byte("old"))
+			}
+
+			var records []Record
+			for r := range store.Snapshot() {
+				if len(records) == 0 {
+					store.Clear()
+					for i := 0; i < n; i++ {
+						_ = store.Set(strconv.Itoa(i), []byte("new"))
+					}
+				}
+				records = append(records, r)
+			}
+
+			values := snapshotValues(records)
+			assert.Len(t, records, n)
+			assert.Len(t, values, n)
+			for key, value := range values {
+				assert.Equal(t, "old", value, key)
+			}
+		})
+	}
+}
+
+func TestStore_SnapshotStopsEarly(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				_ = store.Set(strconv.Itoa(i), []byte("v"))
+			}
+			for range store.Snapshot() {
+				break
+			}
+
+			// The aborted snapshot must not leave the store in snapshot
+			// mode or block the next one.
+			_ = store.Set("after", []byte("v"))
+			assert.Len(t, slices.Collect(store.Snapshot()), 11)
+		})
+	}
+}
+
+func TestStore_SnapshotKeepsMetadata(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			_ = store.SetWithOptions("ttl", []byte("v"), SetOptions{IdleTimeout: time.Minute, HardTTL: time.Hour})
+			_ = store.SetWithOptions("gone", []byte("v"), SetOptions{HardTTL: time.Nanosecond})
+			time.Sleep(time.Millisecond)
+
+			records := slices.Collect(store.Snapshot())
+			assert.Len(t, records, 1)
+			entry, err := store.GetEntry("ttl")
+			assert.NoError(t, err)
+			assert.Equal(t, entry.record("ttl"), records[0])
+		})
+	}
+}
+
+func TestStore_SnapshotWithConcurrentWriters(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 4*snapshotChunkSize; i++ {
+				_ = store.Set(strconv.Itoa(i), []byte("v"))
+			}
+
+			done := make(chan struct{})
+			var wg sync.WaitGroup
+			for w := 0; w < 4; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; ; i++ {
+						select {
+						case <-done:
+							return
+						default:
+						}
+						key := strconv.Itoa((i*4 + w) % (8 * snapshotChunkSize))
+						if i%3 == 0 {
+							_ = store.Delete(key)
+						} else {
+							_ = store.Set(key, []byte("v"))
+						}
+					}
+				}(w)
+			}
+
+			seen := make(map[string]bool)
+			for r := range store.Snapshot() {
+				assert.False(t, seen[r.Key], "key %q returned twice", r.Key)
+				seen[r.Key] = true
+			}
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+func TestStore_Scan(t *testing.T) {
+	for name, store := range storesUnderTest() {
+		t.Run(name, func(t *testing.T) {
+			for i := range snapshotChunkSize + 10 {
+				_ = store.Set(strconv.Itoa(i), []byte("v"))
+			}
+			_ = store.SetWithOptions("gone", []byte("v"), SetOptions{HardTTL: time.Nanosecond})
+			time.Sleep(time.Millisecond)
+
+			// A running snapshot must not hold up the scan.
+			next, stop := iter.Pull(store.Snapshot())
+			defer stop()
+			_, _ = next()
+
+			seen := 0
+			for r := range store.Scan() {
+				e, err := store.GetEntry(r.Key)
+				require.NoError(t, err)
+				assert.Equal(t, e.Version, r.Version)
+				assert.Nil(t, r.Value)
+				seen++
+			}
+			assert.Equal(t, snapshotChunkSize+10, seen)
+		})
+	}
+}
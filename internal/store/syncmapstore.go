@@ -16,10 +16,22 @@ package store
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type SyncMapStore struct {
-	data sync.Map
+	data     sync.Map
+	onEvent  EventHandler
+	onCommit CommitHandler
+	version  atomic.Uint64
+
+	// txnMu is held shared by every write and exclusively by Transaction.
+	txnMu sync.RWMutex
+
+	// snapshotMu serializes snapshots; pit is the running one, if any.
+	snapshotMu sync.Mutex
+	pit        atomic.Pointer[pointInTime]
 }
 
 func NewSyncMapStore() *SyncMapStore {
@@ -27,48 +39,314 @@ func NewSyncMapStore() *SyncMapStore {
 }
 
 func (s *SyncMapStore) Set(key string, value []byte) error {
-	s.data.Store(key, value)
+	return s.SetWithOptions(key, value, SetOptions{})
+}
+
+func (s *SyncMapStore) SetWithOptions(key string, value []byte, opts SetOptions) error {
+	s.txnMu.RLock()
+	defer s.txnMu.RUnlock()
+	now := time.Now()
+	e := newEntry(value, opts, now)
+	e.Version = s.version.Add(1)
+	s.preserve(key)
+	s.data.Store(key, e)
+	s.emit(Event{Type: EventSet, Key: key, Value: value, Entry: e, Time: now})
+	s.commit()
 	return nil
 }
 
-func (s *SyncMapStore) Get(key string) ([]byte, error) {
+// SetMany stores items one by one; sync.Map has no batch operations, so
+// readers may observe a partially applied batch.
+func (s *SyncMapStore) SetMany(items []Item) error {
+	for _, item := range items {
+		if err := s.SetWithOptions(item.Key, item.Value, item.Options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore stores records one by one, like SetMany.
+func (s *SyncMapStore) Restore(records []Record) error {
+	s.txnMu.RLock()
+	defer s.txnMu.RUnlock()
+
+	if s.restore(records, false) > 0 {
+		s.commit()
+	}
+	return nil
+}
+
+// Replay stores records one by one, like Restore.
+func (s *SyncMapStore) Replay(records []Record) error {
+	s.txnMu.RLock()
+	defer s.txnMu.RUnlock()
+
+	if s.restore(records, true) > 0 {
+		s.commit()
+	}
+	return nil
+}
+
+// Replace holds txnMu exclusively, so no write interleaves with it.
+// Readers may still observe the store half replaced, as with Transaction.
+func (s *SyncMapStore) Replace(records []Record) error {
+	s.txnMu.Lock()
+	defer s.txnMu.Unlock()
+
+	s.clear()
+	s.restore(records, false)
+	s.commit()
+	return nil
+}
+
+// restore stores records one by one and returns how many it stored.
+// Versions up to the counter are replaced unless keepVersions is set.
+func (s *SyncMapStore) restore(records []Record, keepVersions bool) int {
+	// Raise the version counter past the restored versions first, so
+	// that concurrent writers cannot be handed one of them.
+	top := maxRecordVersion(records)
+	base := s.version.Load()
+	for base < top && !s.version.CompareAndSwap(base, top) {
+		base = s.version.Load()
+	}
+
+	now := time.Now()
+	restored := 0
+	for _, r := range records {
+		if r.expired(now) {
+			continue
+		}
+		e := r.entry(now)
+		if e.Version == 0 || (!keepVersions && e.Version <= base) {
+			e.Version = s.version.Add(1)
+		}
+		s.preserve(r.Key)
+		s.data.Store(r.Key, e)
+		s.emit(Event{Type: EventSet, Key: r.Key, Value: r.Value, Entry: e, Restored: true, Time: now})
+		restored++
+	}
+	return restored
+}
+
+func (s *SyncMapStore) load(key string, now time.Time) (*Entry, bool) {
 	val, ok := s.data.Load(key)
+	if !ok {
+		return nil, false
+	}
+	e := val.(*Entry)
+	if e.Expired(now) {
+		return nil, false
+	}
+	return e, true
+}
+
+func (s *SyncMapStore) Get(key string) ([]byte, error) {
+	e, err := s.GetEntry(key)
+	if err != nil {
+		return nil, err
+	}
+	return e.Value, nil
+}
+
+func (s *SyncMapStore) GetEntry(key string) (*Entry, error) {
+	now := time.Now()
+	e, ok := s.load(key, now)
 	if !ok {
 		return nil, StoreErrorKeyNotFound
 	}
-	return val.([]byte), nil
+	e.touch(now)
+	return e, nil
+}
+
+func (s *SyncMapStore) GetMany(keys []string) []*Entry {
+	now := time.Now()
+	entries := make([]*Entry, len(keys))
+	for i, key := range keys {
+		if e, ok := s.load(key, now); ok {
+			e.touch(now)
+			entries[i] = e
+		}
+	}
+	return entries
+}
+
+func (s *SyncMapStore) Increment(key string, delta int64) (int64, error) {
+	s.txnMu.RLock()
+	defer s.txnMu.RUnlock()
+	for {
+		now := time.Now()
+		old, loaded := s.data.Load(key)
+		live := loaded && !old.(*Entry).Expired(now)
+
+		var current []byte
+		if live {
+			current = old.(*Entry).Value
+		}
+		value, n, err := incrementValue(current, delta)
+		if err != nil {
+			return 0, err
+		}
+
+		next := newEntry(value, SetOptions{}, now)
+		if live {
+			next = old.(*Entry).withValue(value, now)
+		}
+		next.Version = s.version.Add(1)
+
+		s.preserve(key)
+		var stored bool
+		if loaded {
+			stored = s.data.CompareAndSwap(key, old, next)
+		} else {
+			_, exists := s.data.LoadOrStore(key, next)
+			stored = !exists
+		}
+		if stored {
+			s.emit(Event{Type: EventSet, Key: key, Value: value, Entry: next, Time: now})
+			s.commit()
+			return n, nil
+		}
+	}
+}
+
+func (s *SyncMapStore) Touch(key string) error {
+	now := time.Now()
+	e, ok := s.load(key, now)
+	if !ok {
+		return StoreErrorKeyNotFound
+	}
+	e.touch(now)
+	return nil
 }
 
 func (s *SyncMapStore) Delete(key string) error {
+	s.txnMu.RLock()
+	defer s.txnMu.RUnlock()
 	_, ok := s.data.Load(key)
 	if !ok {
 		return StoreErrorKeyNotFound
 	}
+	s.preserve(key)
 	s.data.Delete(key)
+	s.emit(Event{Type: EventDelete, Key: key, Time: time.Now()})
+	s.commit()
 	return nil
 }
 
+func (s *SyncMapStore) DeleteMany(keys []string) []bool {
+	deleted := make([]bool, len(keys))
+	for i, key := range keys {
+		deleted[i] = s.Delete(key) == nil
+	}
+	return deleted
+}
+
+func (s *SyncMapStore) DeleteUnchanged(records []Record) []bool {
+	s.txnMu.RLock()
+	defer s.txnMu.RUnlock()
+
+	now := time.Now()
+	removed := 0
+	deleted := make([]bool, len(records))
+	for i, r := range records {
+		v, ok := s.data.Load(r.Key)
+		if !ok || v.(*Entry).Version != r.Version {
+			continue
+		}
+		s.preserve(r.Key)
+		if s.data.CompareAndDelete(r.Key, v) {
+			s.emit(Event{Type: EventDelete, Key: r.Key, Time: now})
+			deleted[i] = true
+			removed++
+		}
+	}
+	if removed > 0 {
+		s.commit()
+	}
+	return deleted
+}
+
+func (s *SyncMapStore) DeleteExpired(now time.Time) int {
+	s.txnMu.RLock()
+	defer s.txnMu.RUnlock()
+	removed := 0
+	s.data.Range(func(k, v any) bool {
+		if !v.(*Entry).Expired(now) {
+			return true
+		}
+		s.preserve(k.(string))
+		if s.data.CompareAndDelete(k, v) {
+			s.emit(Event{Type: EventExpire, Key: k.(string), Time: now})
+			removed++
+		}
+		return true
+	})
+	if removed > 0 {
+		s.commit()
+	}
+	return removed
+}
+
 func (s *SyncMapStore) Clear() {
+	s.txnMu.RLock()
+	defer s.txnMu.RUnlock()
+
+	s.clear()
+	s.commit()
+}
+
+func (s *SyncMapStore) clear() {
 	s.data.Range(func(k, _ any) bool {
+		s.preserve(k.(string))
 		s.data.Delete(k)
 		return true
 	})
+	s.emit(Event{Type: EventClear, Time: time.Now()})
 }
 
 func (s *SyncMapStore) List() []string {
+	now := time.Now()
 	var keys []string
-	s.data.Range(func(k, _ any) bool {
-		keys = append(keys, k.(string))
+	s.data.Range(func(k, v any) bool {
+		if !v.(*Entry).Expired(now) {
+			keys = append(keys, k.(string))
+		}
 		return true
 	})
 	return keys
 }
 
 func (s *SyncMapStore) This() map[string][]byte {
+	now := time.Now()
 	snapshot := make(map[string][]byte)
 	s.data.Range(func(k, v any) bool {
-		snapshot[k.(string)] = v.([]byte)
+		if e := v.(*Entry); !e.Expired(now) {
+			snapshot[k.(string)] = e.Value
+		}
 		return true
 	})
 	return snapshot
 }
+
+func (s *SyncMapStore) SetEventHandler(handler EventHandler) {
+	s.onEvent = handler
+}
+
+func (s *SyncMapStore) SetCommitHandler(handler CommitHandler) {
+	s.onCommit = handler
+}
+
+func (s *SyncMapStore) emit(e Event) {
+	if s.onEvent != nil {
+		s.onEvent(e)
+	}
+}
+
+// commit ends a store call. sync.Map has no lock to hold, so every call
+// commits on its own once its events are emitted.
+func (s *SyncMapStore) commit() {
+	if s.onCommit != nil {
+		s.onCommit()
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+type ConditionType int
+
+const (
+	ConditionExists ConditionType = iota
+	ConditionNotExists
+	ConditionValueEquals
+	// ConditionVersionEquals compares against Entry.Version. Version 0
+	// matches a missing key.
+	ConditionVersionEquals
+)
+
+// Condition is a precondition checked before a transaction is applied.
+type Condition struct {
+	Type    ConditionType
+	Key     string
+	Value   []byte
+	Version uint64
+}
+
+type OpType int
+
+const (
+	OpGet OpType = iota
+	OpSet
+	OpDelete
+	OpIncrement
+)
+
+// Op is a single operation of a transaction.
+type Op struct {
+	Type    OpType
+	Key     string
+	Value   []byte
+	Options SetOptions
+	Delta   int64
+}
+
+// OpResult is the outcome of an Op. Found reports whether the key existed
+// before a get or delete; Value and Version describe the entry after the
+// operation.
+type OpResult struct {
+	Found   bool
+	Value   []byte
+	Version uint64
+}
+
+// ConditionFailedError is returned when a precondition does not hold.
+// Index is the position of the failing condition.
+type ConditionFailedError struct {
+	Index int
+}
+
+func (e *ConditionFailedError) Error() string {
+	return fmt.Sprintf("transaction condition %d failed", e.Index)
+}
+
+// Transactional is implemented by stores that can apply several
+// operations atomically.
+type Transactional interface {
+	Store
+	// Transaction checks every condition and then applies every op, or
+	// applies nothing if a condition fails or an op returns an error.
+	Transaction(conds []Condition, ops []Op) ([]OpResult, error)
+}
+
+func (m *MapStore) Transaction(conds []Condition, ops []Op) ([]OpResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.commit()
+
+	now := time.Now()
+	lookup := func(key string) *Entry {
+		if e, exists := m.data[key]; exists && !e.Expired(now) {
+			return e
+		}
+		return nil
+	}
+
+	version := m.version
+	writes, results, err := stageTransaction(conds, ops, lookup, func() uint64 {
+		version++
+		return version
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+
+	m.version = version
+	for _, w := range writes {
+		if w.entry == nil {
+			m.deleteLocked(w.key, now)
+		} else {
+			m.putLocked(w.key, w.entry, now, false)
+		}
+	}
+	return results, nil
+}
+
+// Transaction holds txnMu exclusively, so no other write can interleave
+// with it. Readers take no lock and may observe a partially applied
+// transaction, as with SetMany.
+func (s *SyncMapStore) Transaction(conds []Condition, ops []Op) ([]OpResult, error) {
+	s.txnMu.Lock()
+	defer s.txnMu.Unlock()
+
+	now := time.Now()
+	lookup := func(key string) *Entry {
+		e, _ := s.load(key, now)
+		return e
+	}
+
+	writes, results, err := stageTransaction(conds, ops, lookup, func() uint64 {
+		return s.version.Add(1)
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+
+	emitted := false
+	for _, w := range writes {
+		if w.entry == nil {
+			if _, ok := s.data.Load(w.key); !ok {
+				continue
+			}
+			s.preserve(w.key)
+			s.data.Delete(w.key)
+			s.emit(Event{Type: EventDelete, Key: w.key, Time: now})
+		} else {
+			s.preserve(w.key)
+			s.data.Store(w.key, w.entry)
+			s.emit(Event{Type: EventSet, Key: w.key, Value: w.entry.Value, Entry: w.entry, Time: now})
+		}
+		emitted = true
+	}
+	if emitted {
+		s.commit()
+	}
+	return results, nil
+}
+
+// txnWrite is a staged transaction write. A nil entry marks a deletion.
+type txnWrite struct {
+	key   string
+	entry *Entry
+}
+
+// stageTransaction checks conds and computes the writes and results of
+// ops without changing the store. lookup returns the live entry of a key
+// and nextVersion hands out the version of each written entry.
+func stageTransaction(conds []Condition, ops []Op, lookup func(string) *Entry, nextVersion func() uint64, now time.Time) ([]txnWrite, []OpResult, error) {
+	for i, cond := range conds {
+		if !conditionHolds(cond, lookup(cond.Key)) {
+			return nil, nil, &ConditionFailedError{Index: i}
+		}
+	}
+
+	// Stage every write in an overlay first so that a failing op leaves
+	// the store untouched. A nil overlay entry marks a deletion.
+	overlay := make(map[string]*Entry)
+	staged := func(key string) *Entry {
+		if e, ok := overlay[key]; ok {
+			return e
+		}
+		return lookup(key)
+	}
+
+	var writes []txnWrite
+	results := make([]OpResult, len(ops))
+
+	for i, op := range ops {
+		current := staged(op.Key)
+		switch op.Type {
+		case OpGet:
+			if current != nil {
+				results[i] = OpResult{Found: true, Value: current.Value, Version: current.Version}
+			}
+			continue
+		case OpDelete:
+			results[i].Found = current != nil
+			overlay[op.Key] = nil
+			writes = append(writes, txnWrite{key: op.Key})
+			continue
+		case OpSet:
+			e := newEntry(op.Value, op.Options, now)
+			e.Version = nextVersion()
+			overlay[op.Key] = e
+			writes = append(writes, txnWrite{key: op.Key, entry: e})
+			results[i] = OpResult{Found: current != nil, Value: e.Value, Version: e.Version}
+		case OpIncrement:
+			var value []byte
+			if current != nil {
+				value = current.Value
+			}
+			value, _, err := incrementValue(value, op.Delta)
+			if err != nil {
+				return nil, nil, fmt.Errorf("op %d on %q: %w", i, op.Key, err)
+			}
+			e := newEntry(value, SetOptions{}, now)
+			if current != nil {
+				e = current.withValue(value, now)
+			}
+			e.Version = nextVersion()
+			overlay[op.Key] = e
+			writes = append(writes, txnWrite{key: op.Key, entry: e})
+			results[i] = OpResult{Found: current != nil, Value: e.Value, Version: e.Version}
+		default:
+			return nil, nil, fmt.Errorf("op %d: unknown type %d", i, op.Type)
+		}
+	}
+	return writes, results, nil
+}
+
+func conditionHolds(cond Condition, e *Entry) bool {
+	switch cond.Type {
+	case ConditionExists:
+		return e != nil
+	case ConditionNotExists:
+		return e == nil
+	case ConditionValueEquals:
+		return e != nil && bytes.Equal(e.Value, cond.Value)
+	case ConditionVersionEquals:
+		if e == nil {
+			return cond.Version == 0
+		}
+		return e.Version == cond.Version
+	default:
+		return false
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writebehind
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+// GRPCSink sends each batch to a Sink service.
+type GRPCSink struct {
+	conn    *grpc.ClientConn
+	client  cachev1alpha.SinkClient
+	timeout time.Duration
+}
+
+func NewGRPCSink(address string, timeout time.Duration) (*GRPCSink, error) {
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return &GRPCSink{
+		conn:    conn,
+		client:  cachev1alpha.NewSinkClient(conn),
+		timeout: timeout,
+	}, nil
+}
+
+func (s *GRPCSink) Write(ctx context.Context, batch []Mutation) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	req := &cachev1alpha.WriteRequest{Mutations: make([]*cachev1alpha.Mutation, 0, len(batch))}
+	for _, m := range batch {
+		op := cachev1alpha.MutationOp_MUTATION_OP_SET
+		if m.Op == OpDelete {
+			op = cachev1alpha.MutationOp_MUTATION_OP_DELETE
+		}
+		req.Mutations = append(req.Mutations, &cachev1alpha.Mutation{
+			Op:        op,
+			Key:       m.Key,
+			Value:     m.Value,
+			Timestamp: m.Timestamp,
+		})
+	}
+
+	_, err := s.client.Write(ctx, req)
+	return err
+}
+
+func (s *GRPCSink) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,51 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writebehind
+
+import (
+	"fmt"
+
+	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+func NewSink(cfg *v1alpha.WriteBehindConfig) (Sink, error) {
+	switch cfg.Sink {
+	case v1alpha.FileWriteBehindSink:
+		return NewFileSink(cfg.FilePath)
+	case v1alpha.HTTPWriteBehindSink:
+		return NewHTTPSink(cfg.URL, cfg.Timeout), nil
+	case v1alpha.GRPCWriteBehindSink:
+		return NewGRPCSink(cfg.Address, cfg.Timeout)
+	default:
+		return nil, fmt.Errorf("unknown write-behind sink %q", cfg.Sink)
+	}
+}
+
+// NewPipelineFromConfig creates the configured sink and a pipeline
+// flushing into it.
+func NewPipelineFromConfig(cfg *v1alpha.WriteBehindConfig) (*Pipeline, error) {
+	sink, err := NewSink(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewPipeline(sink, Options{
+		BatchSize:      cfg.BatchSize,
+		BufferSize:     cfg.BufferSize,
+		FlushInterval:  cfg.FlushInterval,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+		CloseRetries:   defaultCloseRetries,
+	}), nil
+}
@@ -0,0 +1,229 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writebehind
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/patrostkowski/protocache/internal/logger"
+)
+
+const (
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultCloseRetries   = 3
+)
+
+var ErrBufferFull = errors.New("write-behind buffer is full")
+
+type Op string
+
+const (
+	OpSet    Op = "set"
+	OpDelete Op = "delete"
+)
+
+type Mutation struct {
+	Op        Op     `json:"op"`
+	Key       string `json:"key"`
+	Value     []byte `json:"value,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// Sink receives batches of mutations. A batch is either written as a
+// whole or an error is returned and the batch is retried.
+type Sink interface {
+	Write(ctx context.Context, batch []Mutation) error
+	Close() error
+}
+
+type Options struct {
+	BatchSize      int
+	BufferSize     int
+	FlushInterval  time.Duration
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// CloseRetries bounds the flush attempts made while closing.
+	CloseRetries int
+}
+
+// Pipeline coalesces mutations per key and flushes them to a Sink in
+// batches from a background goroutine. Failed batches are retried with
+// exponential backoff while new mutations keep coalescing in the buffer.
+type Pipeline struct {
+	sink Sink
+	opts Options
+
+	mu      sync.Mutex
+	pending map[string]Mutation
+	order   []string
+
+	wake      chan struct{}
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func NewPipeline(sink Sink, opts Options) *Pipeline {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pipeline{
+		sink:    sink,
+		opts:    opts,
+		pending: make(map[string]Mutation),
+		wake:    make(chan struct{}, 1),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go p.run(ctx)
+	return p
+}
+
+// Enqueue adds m to the buffer, replacing any pending mutation of the
+// same key. It returns ErrBufferFull when m is for a new key and the
+// buffer already holds BufferSize keys.
+func (p *Pipeline) Enqueue(m Mutation) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.pending[m.Key]; !ok {
+		if len(p.pending) >= p.opts.BufferSize {
+			return ErrBufferFull
+		}
+		p.order = append(p.order, m.Key)
+	}
+	p.pending[m.Key] = m
+
+	if len(p.pending) >= p.opts.BatchSize {
+		select {
+		case p.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Pending returns the number of buffered keys.
+func (p *Pipeline) Pending() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.pending)
+}
+
+// Close stops the background flusher, flushes what is left in the buffer
+// and closes the sink. It is safe to call Close more than once.
+func (p *Pipeline) Close() error {
+	p.closeOnce.Do(func() {
+		p.cancel()
+		<-p.done
+
+		for {
+			batch := p.take()
+			if len(batch) == 0 {
+				break
+			}
+			if err := p.writeWithRetry(context.Background(), batch, p.opts.CloseRetries); err != nil {
+				logger.Error("Dropping write-behind mutations on close", "count", len(batch)+p.Pending(), "error", err)
+				break
+			}
+		}
+		p.closeErr = p.sink.Close()
+	})
+	return p.closeErr
+}
+
+func (p *Pipeline) run(ctx context.Context) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-p.wake:
+		}
+
+		for {
+			batch := p.take()
+			if len(batch) == 0 {
+				break
+			}
+			if err := p.writeWithRetry(ctx, batch, 0); err != nil {
+				p.requeue(batch)
+				return
+			}
+		}
+	}
+}
+
+// take removes up to BatchSize mutations from the buffer, oldest first.
+func (p *Pipeline) take() []Mutation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := min(len(p.order), p.opts.BatchSize)
+	batch := make([]Mutation, 0, n)
+	for _, key := range p.order[:n] {
+		batch = append(batch, p.pending[key])
+		delete(p.pending, key)
+	}
+	p.order = p.order[n:]
+	return batch
+}
+
+// requeue puts a batch that could not be written back in front of the
+// buffer, unless a key was written again in the meantime.
+func (p *Pipeline) requeue(batch []Mutation) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var keys []string
+	for _, m := range batch {
+		if _, ok := p.pending[m.Key]; ok {
+			continue
+		}
+		p.pending[m.Key] = m
+		keys = append(keys, m.Key)
+	}
+	p.order = append(keys, p.order...)
+}
+
+// writeWithRetry writes batch until it succeeds, ctx is cancelled or,
+// when maxAttempts is positive, maxAttempts attempts have failed.
+func (p *Pipeline) writeWithRetry(ctx context.Context, batch []Mutation, maxAttempts int) error {
+	backoff := p.opts.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := p.sink.Write(ctx, batch)
+		if err == nil {
+			return nil
+		}
+		logger.Warn("Write-behind flush failed", "attempt", attempt, "batch", len(batch), "error", err)
+		if maxAttempts > 0 && attempt >= maxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, p.opts.MaxBackoff)
+	}
+}
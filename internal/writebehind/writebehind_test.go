@@ -0,0 +1,227 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writebehind
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+type memorySink struct {
+	mu       sync.Mutex
+	batches  [][]Mutation
+	failures int
+}
+
+func (s *memorySink) Write(_ context.Context, batch []Mutation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("sink unavailable")
+	}
+	s.batches = append(s.batches, append([]Mutation(nil), batch...))
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+func (s *memorySink) written() []Mutation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var all []Mutation
+	for _, b := range s.batches {
+		all = append(all, b...)
+	}
+	return all
+}
+
+func testOptions() Options {
+	return Options{
+		BatchSize:      2,
+		BufferSize:     4,
+		FlushInterval:  time.Hour,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+		CloseRetries:   3,
+	}
+}
+
+func TestPipeline_CoalescesPerKey(t *testing.T) {
+	sink := &memorySink{}
+	opts := testOptions()
+	opts.BatchSize = 10
+	p := NewPipeline(sink, opts)
+
+	require.NoError(t, p.Enqueue(Mutation{Op: OpSet, Key: "a", Value: []byte("1")}))
+	require.NoError(t, p.Enqueue(Mutation{Op: OpSet, Key: "b", Value: []byte("1")}))
+	require.NoError(t, p.Enqueue(Mutation{Op: OpSet, Key: "a", Value: []byte("2")}))
+	require.NoError(t, p.Enqueue(Mutation{Op: OpDelete, Key: "b"}))
+	assert.Equal(t, 2, p.Pending())
+
+	require.NoError(t, p.Close())
+
+	assert.Equal(t, []Mutation{
+		{Op: OpSet, Key: "a", Value: []byte("2")},
+		{Op: OpDelete, Key: "b"},
+	}, sink.written())
+}
+
+func TestPipeline_FlushesFullBatches(t *testing.T) {
+	sink := &memorySink{}
+	p := NewPipeline(sink, testOptions())
+	defer p.Close()
+
+	require.NoError(t, p.Enqueue(Mutation{Op: OpSet, Key: "a"}))
+	require.NoError(t, p.Enqueue(Mutation{Op: OpSet, Key: "b"}))
+
+	assert.Eventually(t, func() bool { return len(sink.written()) == 2 }, time.Second, 5*time.Millisecond)
+}
+
+func TestPipeline_BufferFull(t *testing.T) {
+	sink := &memorySink{}
+	opts := testOptions()
+	opts.BatchSize = 10
+	opts.BufferSize = 2
+	p := NewPipeline(sink, opts)
+	defer p.Close()
+
+	require.NoError(t, p.Enqueue(Mutation{Op: OpSet, Key: "a"}))
+	require.NoError(t, p.Enqueue(Mutation{Op: OpSet, Key: "b"}))
+	assert.ErrorIs(t, p.Enqueue(Mutation{Op: OpSet, Key: "c"}), ErrBufferFull)
+	assert.NoError(t, p.Enqueue(Mutation{Op: OpSet, Key: "a"}), "existing keys still coalesce when full")
+}
+
+func TestPipeline_RetriesWithBackoff(t *testing.T) {
+	sink := &memorySink{failures: 3}
+	p := NewPipeline(sink, testOptions())
+	defer p.Close()
+
+	require.NoError(t, p.Enqueue(Mutation{Op: OpSet, Key: "a"}))
+	require.NoError(t, p.Enqueue(Mutation{Op: OpSet, Key: "b"}))
+
+	assert.Eventually(t, func() bool { return len(sink.written()) == 2 }, time.Second, 5*time.Millisecond)
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sink", "mutations.ndjson")
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+
+	batch := []Mutation{
+		{Op: OpSet, Key: "a", Value: []byte("1"), Timestamp: 1},
+		{Op: OpDelete, Key: "b", Timestamp: 2},
+	}
+	require.NoError(t, sink.Write(context.Background(), batch))
+	require.NoError(t, sink.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var got []Mutation
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var m Mutation
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &m))
+		got = append(got, m)
+	}
+	assert.Equal(t, batch, got)
+}
+
+func TestHTTPSink(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	sink := NewHTTPSink(srv.URL, time.Second)
+	defer sink.Close()
+
+	err := sink.Write(context.Background(), []Mutation{{Op: OpSet, Key: "a", Value: []byte("1")}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"op":"set","key":"a","value":"MQ==","timestamp":0}`, string(body))
+}
+
+func TestHTTPSink_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	sink := NewHTTPSink(srv.URL, time.Second)
+	defer sink.Close()
+
+	err := sink.Write(context.Background(), []Mutation{{Op: OpDelete, Key: "a"}})
+	assert.ErrorContains(t, err, "503")
+}
+
+type fakeSinkServer struct {
+	cachev1alpha.UnimplementedSinkServer
+	received chan *cachev1alpha.WriteRequest
+}
+
+func (f *fakeSinkServer) Write(ctx context.Context, req *cachev1alpha.WriteRequest) (*cachev1alpha.WriteResponse, error) {
+	f.received <- req
+	return &cachev1alpha.WriteResponse{}, nil
+}
+
+func TestGRPCSink(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	fake := &fakeSinkServer{received: make(chan *cachev1alpha.WriteRequest, 1)}
+	grpcServer := grpc.NewServer()
+	cachev1alpha.RegisterSinkServer(grpcServer, fake)
+	go func() {
+		_ = grpcServer.Serve(lis)
+	}()
+	defer grpcServer.Stop()
+
+	sink, err := NewGRPCSink(lis.Addr().String(), time.Second)
+	require.NoError(t, err)
+	defer sink.Close()
+
+	err = sink.Write(context.Background(), []Mutation{
+		{Op: OpSet, Key: "a", Value: []byte("1")},
+		{Op: OpDelete, Key: "b"},
+	})
+	require.NoError(t, err)
+
+	req := <-fake.received
+	require.Len(t, req.Mutations, 2)
+	assert.Equal(t, cachev1alpha.MutationOp_MUTATION_OP_SET, req.Mutations[0].Op)
+	assert.Equal(t, cachev1alpha.MutationOp_MUTATION_OP_DELETE, req.Mutations[1].Op)
+	assert.Equal(t, "b", req.Mutations[1].Key)
+}
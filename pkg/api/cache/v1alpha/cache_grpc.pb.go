@@ -33,12 +33,28 @@ import (
 const _ = grpc.SupportPackageIsVersion8
 
 const (
-	CacheService_List_FullMethodName   = "/cache.v1alpha.CacheService/List"
-	CacheService_Set_FullMethodName    = "/cache.v1alpha.CacheService/Set"
-	CacheService_Get_FullMethodName    = "/cache.v1alpha.CacheService/Get"
-	CacheService_Delete_FullMethodName = "/cache.v1alpha.CacheService/Delete"
-	CacheService_Clear_FullMethodName  = "/cache.v1alpha.CacheService/Clear"
-	CacheService_Stats_FullMethodName  = "/cache.v1alpha.CacheService/Stats"
+	CacheService_List_FullMethodName                    = "/cache.v1alpha.CacheService/List"
+	CacheService_Set_FullMethodName                     = "/cache.v1alpha.CacheService/Set"
+	CacheService_Get_FullMethodName                     = "/cache.v1alpha.CacheService/Get"
+	CacheService_Delete_FullMethodName                  = "/cache.v1alpha.CacheService/Delete"
+	CacheService_Clear_FullMethodName                   = "/cache.v1alpha.CacheService/Clear"
+	CacheService_Stats_FullMethodName                   = "/cache.v1alpha.CacheService/Stats"
+	CacheService_Touch_FullMethodName                   = "/cache.v1alpha.CacheService/Touch"
+	CacheService_SetWithLease_FullMethodName            = "/cache.v1alpha.CacheService/SetWithLease"
+	CacheService_SubscribeKeyspaceEvents_FullMethodName = "/cache.v1alpha.CacheService/SubscribeKeyspaceEvents"
+	CacheService_Watch_FullMethodName                   = "/cache.v1alpha.CacheService/Watch"
+	CacheService_Incr_FullMethodName                    = "/cache.v1alpha.CacheService/Incr"
+	CacheService_Pipeline_FullMethodName                = "/cache.v1alpha.CacheService/Pipeline"
+	CacheService_Transaction_FullMethodName             = "/cache.v1alpha.CacheService/Transaction"
+	CacheService_SetStream_FullMethodName               = "/cache.v1alpha.CacheService/SetStream"
+	CacheService_GetStream_FullMethodName               = "/cache.v1alpha.CacheService/GetStream"
+	CacheService_DeleteMatching_FullMethodName          = "/cache.v1alpha.CacheService/DeleteMatching"
+	CacheService_Track_FullMethodName                   = "/cache.v1alpha.CacheService/Track"
+	CacheService_MGet_FullMethodName                    = "/cache.v1alpha.CacheService/MGet"
+	CacheService_MSet_FullMethodName                    = "/cache.v1alpha.CacheService/MSet"
+	CacheService_MDelete_FullMethodName                 = "/cache.v1alpha.CacheService/MDelete"
+	CacheService_Publish_FullMethodName                 = "/cache.v1alpha.CacheService/Publish"
+	CacheService_Subscribe_FullMethodName               = "/cache.v1alpha.CacheService/Subscribe"
 )
 
 // CacheServiceClient is the client API for CacheService service.
@@ -51,6 +67,22 @@ type CacheServiceClient interface {
 	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
 	Clear(ctx context.Context, in *ClearRequest, opts ...grpc.CallOption) (*ClearResponse, error)
 	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error)
+	Touch(ctx context.Context, in *TouchRequest, opts ...grpc.CallOption) (*TouchResponse, error)
+	SetWithLease(ctx context.Context, in *SetWithLeaseRequest, opts ...grpc.CallOption) (*SetResponse, error)
+	SubscribeKeyspaceEvents(ctx context.Context, in *SubscribeKeyspaceEventsRequest, opts ...grpc.CallOption) (CacheService_SubscribeKeyspaceEventsClient, error)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (CacheService_WatchClient, error)
+	Incr(ctx context.Context, in *IncrRequest, opts ...grpc.CallOption) (*IncrResponse, error)
+	Pipeline(ctx context.Context, opts ...grpc.CallOption) (CacheService_PipelineClient, error)
+	Transaction(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+	SetStream(ctx context.Context, opts ...grpc.CallOption) (CacheService_SetStreamClient, error)
+	GetStream(ctx context.Context, in *GetStreamRequest, opts ...grpc.CallOption) (CacheService_GetStreamClient, error)
+	DeleteMatching(ctx context.Context, in *DeleteMatchingRequest, opts ...grpc.CallOption) (*DeleteMatchingResponse, error)
+	Track(ctx context.Context, in *TrackRequest, opts ...grpc.CallOption) (CacheService_TrackClient, error)
+	MGet(ctx context.Context, in *MGetRequest, opts ...grpc.CallOption) (*MGetResponse, error)
+	MSet(ctx context.Context, in *MSetRequest, opts ...grpc.CallOption) (*MSetResponse, error)
+	MDelete(ctx context.Context, in *MDeleteRequest, opts ...grpc.CallOption) (*MDeleteResponse, error)
+	Publish(ctx context.Context, in *PublishRequest, opts ...grpc.CallOption) (*PublishResponse, error)
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (CacheService_SubscribeClient, error)
 }
 
 type cacheServiceClient struct {
@@ -121,6 +153,328 @@ func (c *cacheServiceClient) Stats(ctx context.Context, in *StatsRequest, opts .
 	return out, nil
 }
 
+func (c *cacheServiceClient) Touch(ctx context.Context, in *TouchRequest, opts ...grpc.CallOption) (*TouchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TouchResponse)
+	err := c.cc.Invoke(ctx, CacheService_Touch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheServiceClient) SetWithLease(ctx context.Context, in *SetWithLeaseRequest, opts ...grpc.CallOption) (*SetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetResponse)
+	err := c.cc.Invoke(ctx, CacheService_SetWithLease_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheServiceClient) SubscribeKeyspaceEvents(ctx context.Context, in *SubscribeKeyspaceEventsRequest, opts ...grpc.CallOption) (CacheService_SubscribeKeyspaceEventsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CacheService_ServiceDesc.Streams[0], CacheService_SubscribeKeyspaceEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cacheServiceSubscribeKeyspaceEventsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CacheService_SubscribeKeyspaceEventsClient interface {
+	Recv() (*KeyspaceEvent, error)
+	grpc.ClientStream
+}
+
+type cacheServiceSubscribeKeyspaceEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *cacheServiceSubscribeKeyspaceEventsClient) Recv() (*KeyspaceEvent, error) {
+	m := new(KeyspaceEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *cacheServiceClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (CacheService_WatchClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CacheService_ServiceDesc.Streams[1], CacheService_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cacheServiceWatchClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CacheService_WatchClient interface {
+	Recv() (*WatchEvent, error)
+	grpc.ClientStream
+}
+
+type cacheServiceWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *cacheServiceWatchClient) Recv() (*WatchEvent, error) {
+	m := new(WatchEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *cacheServiceClient) Incr(ctx context.Context, in *IncrRequest, opts ...grpc.CallOption) (*IncrResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IncrResponse)
+	err := c.cc.Invoke(ctx, CacheService_Incr_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheServiceClient) Pipeline(ctx context.Context, opts ...grpc.CallOption) (CacheService_PipelineClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CacheService_ServiceDesc.Streams[2], CacheService_Pipeline_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cacheServicePipelineClient{ClientStream: stream}
+	return x, nil
+}
+
+type CacheService_PipelineClient interface {
+	Send(*PipelineRequest) error
+	Recv() (*PipelineResponse, error)
+	grpc.ClientStream
+}
+
+type cacheServicePipelineClient struct {
+	grpc.ClientStream
+}
+
+func (x *cacheServicePipelineClient) Send(m *PipelineRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *cacheServicePipelineClient) Recv() (*PipelineResponse, error) {
+	m := new(PipelineResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *cacheServiceClient) Transaction(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransactionResponse)
+	err := c.cc.Invoke(ctx, CacheService_Transaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheServiceClient) SetStream(ctx context.Context, opts ...grpc.CallOption) (CacheService_SetStreamClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CacheService_ServiceDesc.Streams[3], CacheService_SetStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cacheServiceSetStreamClient{ClientStream: stream}
+	return x, nil
+}
+
+type CacheService_SetStreamClient interface {
+	Send(*SetStreamRequest) error
+	CloseAndRecv() (*SetResponse, error)
+	grpc.ClientStream
+}
+
+type cacheServiceSetStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *cacheServiceSetStreamClient) Send(m *SetStreamRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *cacheServiceSetStreamClient) CloseAndRecv() (*SetResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(SetResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *cacheServiceClient) GetStream(ctx context.Context, in *GetStreamRequest, opts ...grpc.CallOption) (CacheService_GetStreamClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CacheService_ServiceDesc.Streams[4], CacheService_GetStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cacheServiceGetStreamClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CacheService_GetStreamClient interface {
+	Recv() (*GetStreamResponse, error)
+	grpc.ClientStream
+}
+
+type cacheServiceGetStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *cacheServiceGetStreamClient) Recv() (*GetStreamResponse, error) {
+	m := new(GetStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *cacheServiceClient) DeleteMatching(ctx context.Context, in *DeleteMatchingRequest, opts ...grpc.CallOption) (*DeleteMatchingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteMatchingResponse)
+	err := c.cc.Invoke(ctx, CacheService_DeleteMatching_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheServiceClient) Track(ctx context.Context, in *TrackRequest, opts ...grpc.CallOption) (CacheService_TrackClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CacheService_ServiceDesc.Streams[5], CacheService_Track_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cacheServiceTrackClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CacheService_TrackClient interface {
+	Recv() (*Invalidation, error)
+	grpc.ClientStream
+}
+
+type cacheServiceTrackClient struct {
+	grpc.ClientStream
+}
+
+func (x *cacheServiceTrackClient) Recv() (*Invalidation, error) {
+	m := new(Invalidation)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *cacheServiceClient) MGet(ctx context.Context, in *MGetRequest, opts ...grpc.CallOption) (*MGetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MGetResponse)
+	err := c.cc.Invoke(ctx, CacheService_MGet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheServiceClient) MSet(ctx context.Context, in *MSetRequest, opts ...grpc.CallOption) (*MSetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MSetResponse)
+	err := c.cc.Invoke(ctx, CacheService_MSet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheServiceClient) MDelete(ctx context.Context, in *MDeleteRequest, opts ...grpc.CallOption) (*MDeleteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MDeleteResponse)
+	err := c.cc.Invoke(ctx, CacheService_MDelete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheServiceClient) Publish(ctx context.Context, in *PublishRequest, opts ...grpc.CallOption) (*PublishResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PublishResponse)
+	err := c.cc.Invoke(ctx, CacheService_Publish_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheServiceClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (CacheService_SubscribeClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CacheService_ServiceDesc.Streams[6], CacheService_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cacheServiceSubscribeClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CacheService_SubscribeClient interface {
+	Recv() (*PubSubMessage, error)
+	grpc.ClientStream
+}
+
+type cacheServiceSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *cacheServiceSubscribeClient) Recv() (*PubSubMessage, error) {
+	m := new(PubSubMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // CacheServiceServer is the server API for CacheService service.
 // All implementations must embed UnimplementedCacheServiceServer
 // for forward compatibility
@@ -131,6 +485,22 @@ type CacheServiceServer interface {
 	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
 	Clear(context.Context, *ClearRequest) (*ClearResponse, error)
 	Stats(context.Context, *StatsRequest) (*StatsResponse, error)
+	Touch(context.Context, *TouchRequest) (*TouchResponse, error)
+	SetWithLease(context.Context, *SetWithLeaseRequest) (*SetResponse, error)
+	SubscribeKeyspaceEvents(*SubscribeKeyspaceEventsRequest, CacheService_SubscribeKeyspaceEventsServer) error
+	Watch(*WatchRequest, CacheService_WatchServer) error
+	Incr(context.Context, *IncrRequest) (*IncrResponse, error)
+	Pipeline(CacheService_PipelineServer) error
+	Transaction(context.Context, *TransactionRequest) (*TransactionResponse, error)
+	SetStream(CacheService_SetStreamServer) error
+	GetStream(*GetStreamRequest, CacheService_GetStreamServer) error
+	DeleteMatching(context.Context, *DeleteMatchingRequest) (*DeleteMatchingResponse, error)
+	Track(*TrackRequest, CacheService_TrackServer) error
+	MGet(context.Context, *MGetRequest) (*MGetResponse, error)
+	MSet(context.Context, *MSetRequest) (*MSetResponse, error)
+	MDelete(context.Context, *MDeleteRequest) (*MDeleteResponse, error)
+	Publish(context.Context, *PublishRequest) (*PublishResponse, error)
+	Subscribe(*SubscribeRequest, CacheService_SubscribeServer) error
 	mustEmbedUnimplementedCacheServiceServer()
 }
 
@@ -156,6 +526,54 @@ func (UnimplementedCacheServiceServer) Clear(context.Context, *ClearRequest) (*C
 func (UnimplementedCacheServiceServer) Stats(context.Context, *StatsRequest) (*StatsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Stats not implemented")
 }
+func (UnimplementedCacheServiceServer) Touch(context.Context, *TouchRequest) (*TouchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Touch not implemented")
+}
+func (UnimplementedCacheServiceServer) SetWithLease(context.Context, *SetWithLeaseRequest) (*SetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetWithLease not implemented")
+}
+func (UnimplementedCacheServiceServer) SubscribeKeyspaceEvents(*SubscribeKeyspaceEventsRequest, CacheService_SubscribeKeyspaceEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeKeyspaceEvents not implemented")
+}
+func (UnimplementedCacheServiceServer) Watch(*WatchRequest, CacheService_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedCacheServiceServer) Incr(context.Context, *IncrRequest) (*IncrResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Incr not implemented")
+}
+func (UnimplementedCacheServiceServer) Pipeline(CacheService_PipelineServer) error {
+	return status.Errorf(codes.Unimplemented, "method Pipeline not implemented")
+}
+func (UnimplementedCacheServiceServer) Transaction(context.Context, *TransactionRequest) (*TransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Transaction not implemented")
+}
+func (UnimplementedCacheServiceServer) SetStream(CacheService_SetStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method SetStream not implemented")
+}
+func (UnimplementedCacheServiceServer) GetStream(*GetStreamRequest, CacheService_GetStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetStream not implemented")
+}
+func (UnimplementedCacheServiceServer) DeleteMatching(context.Context, *DeleteMatchingRequest) (*DeleteMatchingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMatching not implemented")
+}
+func (UnimplementedCacheServiceServer) Track(*TrackRequest, CacheService_TrackServer) error {
+	return status.Errorf(codes.Unimplemented, "method Track not implemented")
+}
+func (UnimplementedCacheServiceServer) MGet(context.Context, *MGetRequest) (*MGetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MGet not implemented")
+}
+func (UnimplementedCacheServiceServer) MSet(context.Context, *MSetRequest) (*MSetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MSet not implemented")
+}
+func (UnimplementedCacheServiceServer) MDelete(context.Context, *MDeleteRequest) (*MDeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MDelete not implemented")
+}
+func (UnimplementedCacheServiceServer) Publish(context.Context, *PublishRequest) (*PublishResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Publish not implemented")
+}
+func (UnimplementedCacheServiceServer) Subscribe(*SubscribeRequest, CacheService_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
 func (UnimplementedCacheServiceServer) mustEmbedUnimplementedCacheServiceServer() {}
 
 // UnsafeCacheServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -277,6 +695,325 @@ func _CacheService_Stats_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CacheService_Touch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TouchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServiceServer).Touch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheService_Touch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServiceServer).Touch(ctx, req.(*TouchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheService_SetWithLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetWithLeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServiceServer).SetWithLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheService_SetWithLease_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServiceServer).SetWithLease(ctx, req.(*SetWithLeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheService_SubscribeKeyspaceEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeKeyspaceEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CacheServiceServer).SubscribeKeyspaceEvents(m, &cacheServiceSubscribeKeyspaceEventsServer{ServerStream: stream})
+}
+
+type CacheService_SubscribeKeyspaceEventsServer interface {
+	Send(*KeyspaceEvent) error
+	grpc.ServerStream
+}
+
+type cacheServiceSubscribeKeyspaceEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *cacheServiceSubscribeKeyspaceEventsServer) Send(m *KeyspaceEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _CacheService_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CacheServiceServer).Watch(m, &cacheServiceWatchServer{ServerStream: stream})
+}
+
+type CacheService_WatchServer interface {
+	Send(*WatchEvent) error
+	grpc.ServerStream
+}
+
+type cacheServiceWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *cacheServiceWatchServer) Send(m *WatchEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _CacheService_Incr_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IncrRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServiceServer).Incr(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheService_Incr_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServiceServer).Incr(ctx, req.(*IncrRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheService_Pipeline_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CacheServiceServer).Pipeline(&cacheServicePipelineServer{ServerStream: stream})
+}
+
+type CacheService_PipelineServer interface {
+	Send(*PipelineResponse) error
+	Recv() (*PipelineRequest, error)
+	grpc.ServerStream
+}
+
+type cacheServicePipelineServer struct {
+	grpc.ServerStream
+}
+
+func (x *cacheServicePipelineServer) Send(m *PipelineResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *cacheServicePipelineServer) Recv() (*PipelineRequest, error) {
+	m := new(PipelineRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _CacheService_Transaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServiceServer).Transaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheService_Transaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServiceServer).Transaction(ctx, req.(*TransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheService_SetStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CacheServiceServer).SetStream(&cacheServiceSetStreamServer{ServerStream: stream})
+}
+
+type CacheService_SetStreamServer interface {
+	SendAndClose(*SetResponse) error
+	Recv() (*SetStreamRequest, error)
+	grpc.ServerStream
+}
+
+type cacheServiceSetStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *cacheServiceSetStreamServer) SendAndClose(m *SetResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *cacheServiceSetStreamServer) Recv() (*SetStreamRequest, error) {
+	m := new(SetStreamRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _CacheService_GetStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetStreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CacheServiceServer).GetStream(m, &cacheServiceGetStreamServer{ServerStream: stream})
+}
+
+type CacheService_GetStreamServer interface {
+	Send(*GetStreamResponse) error
+	grpc.ServerStream
+}
+
+type cacheServiceGetStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *cacheServiceGetStreamServer) Send(m *GetStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _CacheService_DeleteMatching_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteMatchingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServiceServer).DeleteMatching(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheService_DeleteMatching_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServiceServer).DeleteMatching(ctx, req.(*DeleteMatchingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheService_Track_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TrackRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CacheServiceServer).Track(m, &cacheServiceTrackServer{ServerStream: stream})
+}
+
+type CacheService_TrackServer interface {
+	Send(*Invalidation) error
+	grpc.ServerStream
+}
+
+type cacheServiceTrackServer struct {
+	grpc.ServerStream
+}
+
+func (x *cacheServiceTrackServer) Send(m *Invalidation) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _CacheService_MGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServiceServer).MGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheService_MGet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServiceServer).MGet(ctx, req.(*MGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheService_MSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServiceServer).MSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheService_MSet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServiceServer).MSet(ctx, req.(*MSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheService_MDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServiceServer).MDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheService_MDelete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServiceServer).MDelete(ctx, req.(*MDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheService_Publish_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublishRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServiceServer).Publish(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheService_Publish_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServiceServer).Publish(ctx, req.(*PublishRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CacheServiceServer).Subscribe(m, &cacheServiceSubscribeServer{ServerStream: stream})
+}
+
+type CacheService_SubscribeServer interface {
+	Send(*PubSubMessage) error
+	grpc.ServerStream
+}
+
+type cacheServiceSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *cacheServiceSubscribeServer) Send(m *PubSubMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // CacheService_ServiceDesc is the grpc.ServiceDesc for CacheService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -308,7 +1045,80 @@ var CacheService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Stats",
 			Handler:    _CacheService_Stats_Handler,
 		},
+		{
+			MethodName: "Touch",
+			Handler:    _CacheService_Touch_Handler,
+		},
+		{
+			MethodName: "SetWithLease",
+			Handler:    _CacheService_SetWithLease_Handler,
+		},
+		{
+			MethodName: "Incr",
+			Handler:    _CacheService_Incr_Handler,
+		},
+		{
+			MethodName: "Transaction",
+			Handler:    _CacheService_Transaction_Handler,
+		},
+		{
+			MethodName: "DeleteMatching",
+			Handler:    _CacheService_DeleteMatching_Handler,
+		},
+		{
+			MethodName: "MGet",
+			Handler:    _CacheService_MGet_Handler,
+		},
+		{
+			MethodName: "MSet",
+			Handler:    _CacheService_MSet_Handler,
+		},
+		{
+			MethodName: "MDelete",
+			Handler:    _CacheService_MDelete_Handler,
+		},
+		{
+			MethodName: "Publish",
+			Handler:    _CacheService_Publish_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeKeyspaceEvents",
+			Handler:       _CacheService_SubscribeKeyspaceEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _CacheService_Watch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Pipeline",
+			Handler:       _CacheService_Pipeline_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "SetStream",
+			Handler:       _CacheService_SetStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "GetStream",
+			Handler:       _CacheService_GetStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Track",
+			Handler:       _CacheService_Track_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Subscribe",
+			Handler:       _CacheService_Subscribe_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/api/cache/v1alpha/cache.proto",
 }
@@ -0,0 +1,130 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.19.6
+// source: pkg/api/cache/v1alpha/loader.proto
+
+package v1alpha
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	Loader_Load_FullMethodName = "/cache.v1alpha.Loader/Load"
+)
+
+// LoaderClient is the client API for Loader service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Loader is implemented by an upstream service that protocache calls to
+// fill cache misses.
+type LoaderClient interface {
+	Load(ctx context.Context, in *LoadRequest, opts ...grpc.CallOption) (*LoadResponse, error)
+}
+
+type loaderClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLoaderClient(cc grpc.ClientConnInterface) LoaderClient {
+	return &loaderClient{cc}
+}
+
+func (c *loaderClient) Load(ctx context.Context, in *LoadRequest, opts ...grpc.CallOption) (*LoadResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoadResponse)
+	err := c.cc.Invoke(ctx, Loader_Load_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LoaderServer is the server API for Loader service.
+// All implementations must embed UnimplementedLoaderServer
+// for forward compatibility
+//
+// Loader is implemented by an upstream service that protocache calls to
+// fill cache misses.
+type LoaderServer interface {
+	Load(context.Context, *LoadRequest) (*LoadResponse, error)
+	mustEmbedUnimplementedLoaderServer()
+}
+
+// UnimplementedLoaderServer must be embedded to have forward compatible implementations.
+type UnimplementedLoaderServer struct {
+}
+
+func (UnimplementedLoaderServer) Load(context.Context, *LoadRequest) (*LoadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Load not implemented")
+}
+func (UnimplementedLoaderServer) mustEmbedUnimplementedLoaderServer() {}
+
+// UnsafeLoaderServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LoaderServer will
+// result in compilation errors.
+type UnsafeLoaderServer interface {
+	mustEmbedUnimplementedLoaderServer()
+}
+
+func RegisterLoaderServer(s grpc.ServiceRegistrar, srv LoaderServer) {
+	s.RegisterService(&Loader_ServiceDesc, srv)
+}
+
+func _Loader_Load_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoaderServer).Load(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Loader_Load_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoaderServer).Load(ctx, req.(*LoadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Loader_ServiceDesc is the grpc.ServiceDesc for Loader service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Loader_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cache.v1alpha.Loader",
+	HandlerType: (*LoaderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Load",
+			Handler:    _Loader_Load_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/api/cache/v1alpha/loader.proto",
+}
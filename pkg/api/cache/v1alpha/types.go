@@ -70,9 +70,102 @@ const (
 )
 
 type StoreConfig struct {
-	Engine             StoreEngine    `yaml:"engine"`
-	EvictionPolicy     EvictionPolicy `yaml:"eviction_policy"`
-	DumpEnabled        bool           `yaml:"dump_enabled"`
-	MemoryDumpPath     string         `yaml:"memory_dump_path"`
-	MemoryDumpFileName string         `yaml:"memory_dump_file_name"`
+	Engine              StoreEngine          `yaml:"engine"`
+	EvictionPolicy      EvictionPolicy       `yaml:"eviction_policy"`
+	DumpEnabled         bool                 `yaml:"dump_enabled"`
+	MemoryDumpPath      string               `yaml:"memory_dump_path"`
+	MemoryDumpFileName  string               `yaml:"memory_dump_file_name"`
+	SnapshotInterval    time.Duration        `yaml:"snapshot_interval"`     // 0 disables periodic snapshots
+	SnapshotAfterWrites int                  `yaml:"snapshot_after_writes"` // 0 disables write-count snapshots
+	SnapshotCompression SnapshotCompression  `yaml:"snapshot_compression"`  // none, gzip or flate
+	SnapshotRetention   int                  `yaml:"snapshot_retention"`    // timestamped snapshots to keep; 0 overwrites a single file
+	SnapshotSinks       []SnapshotSinkConfig `yaml:"snapshot_sinks"`
+	ExpirationInterval  time.Duration        `yaml:"expiration_interval"`
+	LeaseTTL            time.Duration        `yaml:"lease_ttl"`
+	MaxValueSize        int                  `yaml:"max_value_size"`   // bytes
+	MaxRestoreSize      int64                `yaml:"max_restore_size"` // bytes
+	Loader              *LoaderConfig        `yaml:"loader"`
+	WriteBehind         *WriteBehindConfig   `yaml:"write_behind"`
+	AppendOnly          *AppendOnlyConfig    `yaml:"append_only"`
+	Encryption          *EncryptionConfig    `yaml:"encryption"`
+}
+
+type LoaderConfig struct {
+	Address     string        `yaml:"address"` // e.g., "localhost:50052"
+	Timeout     time.Duration `yaml:"timeout"`
+	TTL         time.Duration `yaml:"ttl"`
+	NegativeTTL time.Duration `yaml:"negative_ttl"`
+}
+
+type SnapshotCompression string
+
+const (
+	SnapshotCompressionNone  SnapshotCompression = "none"
+	SnapshotCompressionGzip  SnapshotCompression = "gzip"
+	SnapshotCompressionFlate SnapshotCompression = "flate"
+)
+
+type SnapshotSinkType string
+
+const (
+	DirSnapshotSink  SnapshotSinkType = "dir"
+	HTTPSnapshotSink SnapshotSinkType = "http"
+)
+
+// SnapshotSinkConfig describes a destination every snapshot is shipped
+// to after it has been written locally.
+type SnapshotSinkConfig struct {
+	Name      string           `yaml:"name"` // defaults to the type
+	Type      SnapshotSinkType `yaml:"type"`
+	Path      string           `yaml:"path"`      // directory for the "dir" sink
+	Retention int              `yaml:"retention"` // snapshots the "dir" sink keeps; 0 keeps all
+	URL       string           `yaml:"url"`       // base URL for the "http" sink; snapshots are PUT to url/name
+	Timeout   time.Duration    `yaml:"timeout"`
+}
+
+type AppendFsyncPolicy string
+
+const (
+	AppendFsyncAlways   AppendFsyncPolicy = "always"
+	AppendFsyncEverySec AppendFsyncPolicy = "everysec"
+	AppendFsyncNever    AppendFsyncPolicy = "never"
+)
+
+type AppendOnlyConfig struct {
+	Enabled     bool              `yaml:"enabled"`
+	Path        string            `yaml:"path"`         // defaults to appendonly.aof in memory_dump_path
+	Fsync       AppendFsyncPolicy `yaml:"fsync"`        // always, everysec or never
+	RewriteSize int64             `yaml:"rewrite_size"` // bytes; the log is compacted once it grows past this
+}
+
+// EncryptionKeySource names where a key is read from. Keys are 32 bytes,
+// hex or base64 encoded.
+type EncryptionKeySource struct {
+	File string `yaml:"file"`
+	Env  string `yaml:"env"` // environment variable, used when file is empty
+}
+
+type EncryptionConfig struct {
+	Key            EncryptionKeySource   `yaml:"key"`             // encrypts snapshots and the append-only file
+	DecryptionKeys []EncryptionKeySource `yaml:"decryption_keys"` // previous keys, still accepted when reading
+}
+
+type WriteBehindSinkType string
+
+const (
+	FileWriteBehindSink WriteBehindSinkType = "file"
+	HTTPWriteBehindSink WriteBehindSinkType = "http"
+	GRPCWriteBehindSink WriteBehindSinkType = "grpc"
+)
+
+type WriteBehindConfig struct {
+	Sink          WriteBehindSinkType `yaml:"sink"`
+	FilePath      string              `yaml:"file_path"` // NDJSON file for the "file" sink
+	URL           string              `yaml:"url"`       // endpoint for the "http" sink
+	Address       string              `yaml:"address"`   // Sink service for the "grpc" sink
+	Timeout       time.Duration       `yaml:"timeout"`
+	BatchSize     int                 `yaml:"batch_size"`
+	BufferSize    int                 `yaml:"buffer_size"`
+	FlushInterval time.Duration       `yaml:"flush_interval"`
+	MaxBackoff    time.Duration       `yaml:"max_backoff"`
 }
@@ -0,0 +1,145 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+// Snapshot asks the server to write its memory store dump and waits for
+// it to finish.
+func (c *Client) Snapshot(ctx context.Context) (*cachev1alpha.SnapshotInfo, error) {
+	return c.admin.Snapshot(ctx, &cachev1alpha.SnapshotRequest{})
+}
+
+// LastSnapshot describes the most recent snapshot taken by the server.
+func (c *Client) LastSnapshot(ctx context.Context) (*cachev1alpha.SnapshotInfo, error) {
+	return c.admin.LastSnapshot(ctx, &cachev1alpha.LastSnapshotRequest{})
+}
+
+// Backup streams a point-in-time dump of the server into w and returns
+// the number of bytes written. The dump can be loaded with Restore.
+func (c *Client) Backup(ctx context.Context, w io.Writer) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.admin.Backup(ctx, &cachev1alpha.BackupRequest{})
+	if err != nil {
+		return 0, err
+	}
+
+	var written int64
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+		n, err := w.Write(chunk.Data)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// Restore sends a dump produced by Backup to the server, which either
+// replaces its keys with the dump or merges the dump over them. It
+// returns the number of keys loaded.
+func (c *Client) Restore(ctx context.Context, r io.Reader, mode cachev1alpha.RestoreMode) (uint64, error) {
+	stream, err := c.admin.Restore(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	err = stream.Send(&cachev1alpha.RestoreRequest{Part: &cachev1alpha.RestoreRequest_Header{
+		Header: &cachev1alpha.RestoreHeader{Mode: mode},
+	}})
+	if err != nil {
+		return 0, restoreSendError(stream, err)
+	}
+
+	buf := make([]byte, StreamChunkSize)
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			chunk := append([]byte(nil), buf[:n]...)
+			if err := stream.Send(&cachev1alpha.RestoreRequest{Part: &cachev1alpha.RestoreRequest_Chunk{Chunk: chunk}}); err != nil {
+				return 0, restoreSendError(stream, err)
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			_ = stream.CloseSend()
+			return 0, fmt.Errorf("read dump: %w", readErr)
+		}
+	}
+
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return 0, err
+	}
+	if c.near != nil {
+		c.near.flush()
+	}
+	return res.Keys, nil
+}
+
+// ListSnapshots lists the timestamped snapshots kept by the server, or by
+// the named snapshot sink if sink is not empty. Newest first.
+func (c *Client) ListSnapshots(ctx context.Context, sink string) ([]*cachev1alpha.SnapshotEntry, error) {
+	res, err := c.admin.ListSnapshots(ctx, &cachev1alpha.ListSnapshotsRequest{Sink: sink})
+	if err != nil {
+		return nil, err
+	}
+	return res.Snapshots, nil
+}
+
+// RestoreSnapshot makes the server load the snapshot taken at t from its
+// own timestamped snapshots, or from the named snapshot sink if sink is
+// not empty. t must match the snapshot time exactly. It returns the
+// number of keys loaded.
+func (c *Client) RestoreSnapshot(ctx context.Context, t time.Time, sink string, mode cachev1alpha.RestoreMode) (uint64, error) {
+	res, err := c.admin.RestoreSnapshot(ctx, &cachev1alpha.RestoreSnapshotRequest{
+		Timestamp: t.UnixNano(),
+		Mode:      mode,
+		Sink:      sink,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if c.near != nil {
+		c.near.flush()
+	}
+	return res.Keys, nil
+}
+
+// restoreSendError is streamSendError for Restore streams.
+func restoreSendError(stream cachev1alpha.AdminService_RestoreClient, err error) error {
+	if errors.Is(err, io.EOF) {
+		_, err = stream.CloseAndRecv()
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	v1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+// mockAdminServer keeps the last restored dump and serves it as backup.
+type mockAdminServer struct {
+	v1alpha.UnimplementedAdminServiceServer
+
+	dump []byte
+	mode v1alpha.RestoreMode
+}
+
+func (m *mockAdminServer) Backup(_ *v1alpha.BackupRequest, stream v1alpha.AdminService_BackupServer) error {
+	for data := m.dump; len(data) > 0; {
+		n := min(len(data), 3)
+		if err := stream.Send(&v1alpha.BackupChunk{Data: data[:n]}); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+func (m *mockAdminServer) Restore(stream v1alpha.AdminService_RestoreServer) error {
+	var dump []byte
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch part := msg.Part.(type) {
+		case *v1alpha.RestoreRequest_Header:
+			m.mode = part.Header.Mode
+		case *v1alpha.RestoreRequest_Chunk:
+			dump = append(dump, part.Chunk...)
+		}
+	}
+	m.dump = dump
+	return stream.SendAndClose(&v1alpha.RestoreResponse{Keys: 1})
+}
+
+func TestClient_BackupAndRestore(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	admin := &mockAdminServer{}
+	grpcServer := grpc.NewServer()
+	v1alpha.RegisterAdminServiceServer(grpcServer, admin)
+	go func() {
+		_ = grpcServer.Serve(lis)
+	}()
+	defer grpcServer.Stop()
+
+	c, err := New(Config{Host: "127.0.0.1", Port: parsePort(lis.Addr().String()), Timeout: 2 * time.Second})
+	require.NoError(t, err)
+	defer c.Close()
+	ctx := context.Background()
+
+	dump := bytes.Repeat([]byte("dump"), StreamChunkSize/2)
+	keys, err := c.Restore(ctx, bytes.NewReader(dump), v1alpha.RestoreMode_RESTORE_MODE_MERGE)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), keys)
+	assert.Equal(t, v1alpha.RestoreMode_RESTORE_MODE_MERGE, admin.mode)
+	assert.Equal(t, dump, admin.dump)
+
+	admin.dump = []byte("small dump")
+	var out bytes.Buffer
+	n, err := c.Backup(ctx, &out)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("small dump")), n)
+	assert.Equal(t, "small dump", out.String())
+}
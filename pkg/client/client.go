@@ -19,12 +19,17 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
+	"github.com/patrostkowski/protocache/internal/glob"
 	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 type Config struct {
@@ -34,11 +39,28 @@ type Config struct {
 	Cert    string
 	Key     string
 	Timeout time.Duration
+	// NearCacheSize is the number of values kept in an in-process cache
+	// in front of Get. The server invalidates them when they change.
+	// Zero disables the near cache.
+	NearCacheSize int
+}
+
+// SetOptions carries optional per-key settings for SetWithOptions.
+type SetOptions struct {
+	// IdleTimeout expires the key after it has not been read or touched
+	// for the given duration. Zero disables idle expiration.
+	IdleTimeout time.Duration
+	// SoftTTL marks the value stale after the given duration.
+	SoftTTL time.Duration
+	// HardTTL removes the key after the given duration.
+	HardTTL time.Duration
 }
 
 type Client struct {
 	conn   *grpc.ClientConn
 	client cachev1alpha.CacheServiceClient
+	admin  cachev1alpha.AdminServiceClient
+	near   *nearCache
 }
 
 func New(cfg Config) (*Client, error) {
@@ -76,40 +98,226 @@ func New(cfg Config) (*Client, error) {
 		return nil, err
 	}
 
-	return &Client{
+	c := &Client{
 		conn:   conn,
 		client: cachev1alpha.NewCacheServiceClient(conn),
-	}, nil
+		admin:  cachev1alpha.NewAdminServiceClient(conn),
+	}
+	if cfg.NearCacheSize > 0 {
+		c.near = newNearCache(cfg.NearCacheSize)
+		c.near.start(c.client)
+	}
+	return c, nil
 }
 
 // Close gracefully closes the gRPC connection.
 func (c *Client) Close() error {
+	if c.near != nil {
+		c.near.stop()
+	}
 	return c.conn.Close()
 }
 
+// forget drops keys from the near cache after a write through this
+// client, so that the write is visible before the server invalidation
+// arrives.
+func (c *Client) forget(keys ...string) {
+	if c.near != nil {
+		c.near.remove(keys...)
+	}
+}
+
+// forgetMatching drops every key for which match returns true from the
+// near cache.
+func (c *Client) forgetMatching(match func(key string) bool) {
+	if c.near != nil {
+		c.near.removeMatching(match)
+	}
+}
+
 // Set stores a key-value pair in the cache.
 func (c *Client) Set(ctx context.Context, key, value string) error {
 	_, err := c.client.Set(ctx, &cachev1alpha.SetRequest{
 		Key:   key,
 		Value: []byte(value),
 	})
+	c.forget(key)
+	return err
+}
+
+// SetWithOptions stores a key-value pair in the cache with per-key options.
+func (c *Client) SetWithOptions(ctx context.Context, key, value string, opts SetOptions) error {
+	req := &cachev1alpha.SetRequest{
+		Key:   key,
+		Value: []byte(value),
+	}
+	if opts.IdleTimeout > 0 {
+		req.IdleTimeout = durationpb.New(opts.IdleTimeout)
+	}
+	if opts.SoftTTL > 0 {
+		req.SoftTtl = durationpb.New(opts.SoftTTL)
+	}
+	if opts.HardTTL > 0 {
+		req.HardTtl = durationpb.New(opts.HardTTL)
+	}
+	_, err := c.client.Set(ctx, req)
+	c.forget(key)
+	return err
+}
+
+// Incr adds delta to the integer stored at key and returns the new value.
+// A missing key counts as zero.
+func (c *Client) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	res, err := c.client.Incr(ctx, &cachev1alpha.IncrRequest{Key: key, Delta: delta})
+	c.forget(key)
+	if err != nil {
+		return 0, err
+	}
+	return res.Value, nil
+}
+
+// Touch refreshes the idle deadline of a key without transferring its value.
+func (c *Client) Touch(ctx context.Context, key string) error {
+	_, err := c.client.Touch(ctx, &cachev1alpha.TouchRequest{Key: key})
 	return err
 }
 
-// Get retrieves a value from the cache by key.
+// Get retrieves a value from the cache by key. With a near cache
+// configured, found values are served locally until invalidated.
 func (c *Client) Get(ctx context.Context, key string) (*cachev1alpha.GetResponse, error) {
-	return c.client.Get(ctx, &cachev1alpha.GetRequest{Key: key})
+	if c.near == nil {
+		return c.client.Get(ctx, &cachev1alpha.GetRequest{Key: key})
+	}
+	if res, ok := c.near.get(key); ok {
+		return res, nil
+	}
+
+	id, generation := c.near.begin(key)
+	res, err := c.client.Get(ctx, &cachev1alpha.GetRequest{Key: key, TrackingId: id})
+	if status.Code(err) == codes.FailedPrecondition && id != 0 {
+		// The tracking id is gone, e.g. after a server restart; the Track
+		// stream reconnects on its own.
+		c.near.finish(key, generation, nil)
+		return c.client.Get(ctx, &cachev1alpha.GetRequest{Key: key})
+	}
+	c.near.finish(key, generation, res)
+	return res, err
+}
+
+// GetIfNoneMatch retrieves a value unless its etag equals etag. In that
+// case the response has NotModified set and carries no value.
+func (c *Client) GetIfNoneMatch(ctx context.Context, key, etag string) (*cachev1alpha.GetResponse, error) {
+	return c.client.Get(ctx, &cachev1alpha.GetRequest{Key: key, IfNoneMatch: etag})
+}
+
+// GetWithLease retrieves a value from the cache by key. On a miss the
+// response carries either a lease token to pass to SetWithLease, or
+// LeaseWait when another caller is already filling the key.
+func (c *Client) GetWithLease(ctx context.Context, key string) (*cachev1alpha.GetResponse, error) {
+	return c.client.Get(ctx, &cachev1alpha.GetRequest{Key: key, Lease: true})
+}
+
+// SetWithLease stores a value obtained after a leased miss. It fails with
+// FailedPrecondition if the key was written or deleted since the lease was
+// granted.
+func (c *Client) SetWithLease(ctx context.Context, key, value string, token uint64) error {
+	_, err := c.client.SetWithLease(ctx, &cachev1alpha.SetWithLeaseRequest{
+		Set:        &cachev1alpha.SetRequest{Key: key, Value: []byte(value)},
+		LeaseToken: token,
+	})
+	c.forget(key)
+	return err
 }
 
 // Delete removes a key from the cache.
 func (c *Client) Delete(ctx context.Context, key string) error {
 	_, err := c.client.Delete(ctx, &cachev1alpha.DeleteRequest{Key: key})
+	c.forget(key)
 	return err
 }
 
+// Transaction applies ops atomically once every condition holds. When a
+// condition fails the response is not committed and names the condition.
+func (c *Client) Transaction(ctx context.Context, conds []*cachev1alpha.TxnCondition, ops []*cachev1alpha.TxnOp) (*cachev1alpha.TransactionResponse, error) {
+	res, err := c.client.Transaction(ctx, &cachev1alpha.TransactionRequest{Conditions: conds, Ops: ops})
+	for _, op := range ops {
+		if op.Type != cachev1alpha.TxnOpType_TXN_OP_TYPE_GET {
+			c.forget(op.Key)
+		}
+	}
+	return res, err
+}
+
+// DeletePrefix removes every key starting with prefix and returns the
+// number of deleted keys. With dryRun set, keys are only counted.
+func (c *Client) DeletePrefix(ctx context.Context, prefix string, dryRun bool) (uint64, error) {
+	res, err := c.client.DeleteMatching(ctx, &cachev1alpha.DeleteMatchingRequest{Prefix: prefix, DryRun: dryRun})
+	if !dryRun {
+		c.forgetMatching(func(key string) bool { return strings.HasPrefix(key, prefix) })
+	}
+	if err != nil {
+		return 0, err
+	}
+	return res.Count, nil
+}
+
+// DeleteMatching removes every key matching the glob pattern and returns
+// the number of deleted keys. With dryRun set, keys are only counted.
+func (c *Client) DeleteMatching(ctx context.Context, pattern string, dryRun bool) (uint64, error) {
+	res, err := c.client.DeleteMatching(ctx, &cachev1alpha.DeleteMatchingRequest{Pattern: pattern, DryRun: dryRun})
+	if !dryRun {
+		c.forgetMatching(func(key string) bool { return glob.Match(pattern, key) })
+	}
+	if err != nil {
+		return 0, err
+	}
+	return res.Count, nil
+}
+
+// MGet retrieves several keys in one request. Results are in the order of
+// keys and report misses per key.
+func (c *Client) MGet(ctx context.Context, keys ...string) ([]*cachev1alpha.MGetResult, error) {
+	res, err := c.client.MGet(ctx, &cachev1alpha.MGetRequest{Keys: keys})
+	if err != nil {
+		return nil, err
+	}
+	return res.Results, nil
+}
+
+// MSet stores several key-value pairs in one request and returns the
+// result for each key.
+func (c *Client) MSet(ctx context.Context, entries map[string]string) ([]*cachev1alpha.MSetResult, error) {
+	req := &cachev1alpha.MSetRequest{Entries: make([]*cachev1alpha.SetRequest, 0, len(entries))}
+	for key, value := range entries {
+		req.Entries = append(req.Entries, &cachev1alpha.SetRequest{Key: key, Value: []byte(value)})
+	}
+	res, err := c.client.MSet(ctx, req)
+	for key := range entries {
+		c.forget(key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.Results, nil
+}
+
+// MDelete removes several keys in one request. Results are in the order of
+// keys and report whether each key existed.
+func (c *Client) MDelete(ctx context.Context, keys ...string) ([]*cachev1alpha.MDeleteResult, error) {
+	res, err := c.client.MDelete(ctx, &cachev1alpha.MDeleteRequest{Keys: keys})
+	c.forget(keys...)
+	if err != nil {
+		return nil, err
+	}
+	return res.Results, nil
+}
+
 // Clear removes all keys from the cache.
 func (c *Client) Clear(ctx context.Context) error {
 	_, err := c.client.Clear(ctx, &cachev1alpha.ClearRequest{})
+	if c.near != nil {
+		c.near.flush()
+	}
 	return err
 }
 
@@ -126,3 +334,44 @@ func (c *Client) List(ctx context.Context) ([]string, error) {
 func (c *Client) Stats(ctx context.Context) (*cachev1alpha.StatsResponse, error) {
 	return c.client.Stats(ctx, &cachev1alpha.StatsRequest{})
 }
+
+// SubscribeKeyspaceEvents streams keyspace events for keys matching any of
+// patterns, optionally restricted to the given event types. The stream ends
+// with ResourceExhausted if the subscriber falls behind.
+func (c *Client) SubscribeKeyspaceEvents(ctx context.Context, patterns []string, types ...cachev1alpha.KeyspaceEventType) (cachev1alpha.CacheService_SubscribeKeyspaceEventsClient, error) {
+	return c.client.SubscribeKeyspaceEvents(ctx, &cachev1alpha.SubscribeKeyspaceEventsRequest{
+		Patterns: patterns,
+		Types:    types,
+	})
+}
+
+// Watch streams changes to the given keys and key prefixes. With snapshot
+// set, the current values are sent first. A RESYNC event means the watcher
+// fell behind and must re-watch with a snapshot.
+func (c *Client) Watch(ctx context.Context, keys, prefixes []string, snapshot bool) (cachev1alpha.CacheService_WatchClient, error) {
+	return c.client.Watch(ctx, &cachev1alpha.WatchRequest{
+		Keys:            keys,
+		Prefixes:        prefixes,
+		InitialSnapshot: snapshot,
+	})
+}
+
+// Publish sends payload to every subscriber of channel and returns the
+// number of subscriptions it was delivered to.
+func (c *Client) Publish(ctx context.Context, channel string, payload []byte) (int64, error) {
+	res, err := c.client.Publish(ctx, &cachev1alpha.PublishRequest{Channel: channel, Payload: payload})
+	if err != nil {
+		return 0, err
+	}
+	return res.Receivers, nil
+}
+
+// Subscribe streams messages published to the given channels or to
+// channels matching the given glob patterns. Messages are not buffered
+// for slow subscribers and may be dropped.
+func (c *Client) Subscribe(ctx context.Context, channels, patterns []string) (cachev1alpha.CacheService_SubscribeClient, error) {
+	return c.client.Subscribe(ctx, &cachev1alpha.SubscribeRequest{
+		Channels: channels,
+		Patterns: patterns,
+	})
+}
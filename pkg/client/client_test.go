@@ -15,8 +15,11 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"hash/crc32"
+	"io"
 	"net"
 	"testing"
 	"time"
@@ -24,6 +27,8 @@ import (
 	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // mockServer implements the CacheServiceServer interface for test purposes.
@@ -43,7 +48,11 @@ func (s *mockServer) Set(ctx context.Context, req *v1alpha.SetRequest) (*v1alpha
 
 func (s *mockServer) Get(ctx context.Context, req *v1alpha.GetRequest) (*v1alpha.GetResponse, error) {
 	val, ok := s.store[req.Key]
-	return &v1alpha.GetResponse{Value: val, Found: ok}, nil
+	etag := string(val)
+	if ok && req.IfNoneMatch == etag {
+		return &v1alpha.GetResponse{Found: true, Etag: etag, NotModified: true}, nil
+	}
+	return &v1alpha.GetResponse{Value: val, Found: ok, Etag: etag}, nil
 }
 
 func (s *mockServer) Delete(ctx context.Context, req *v1alpha.DeleteRequest) (*v1alpha.DeleteResponse, error) {
@@ -64,6 +73,109 @@ func (s *mockServer) List(ctx context.Context, req *v1alpha.ListRequest) (*v1alp
 	return &v1alpha.ListResponse{Keys: keys}, nil
 }
 
+func (s *mockServer) Touch(ctx context.Context, req *v1alpha.TouchRequest) (*v1alpha.TouchResponse, error) {
+	if _, ok := s.store[req.Key]; !ok {
+		return nil, status.Errorf(codes.NotFound, "key %q not found", req.Key)
+	}
+	return &v1alpha.TouchResponse{Success: true}, nil
+}
+
+func (s *mockServer) MGet(ctx context.Context, req *v1alpha.MGetRequest) (*v1alpha.MGetResponse, error) {
+	res := &v1alpha.MGetResponse{}
+	for _, key := range req.Keys {
+		val, ok := s.store[key]
+		res.Results = append(res.Results, &v1alpha.MGetResult{Key: key, Found: ok, Value: val})
+	}
+	return res, nil
+}
+
+func (s *mockServer) MSet(ctx context.Context, req *v1alpha.MSetRequest) (*v1alpha.MSetResponse, error) {
+	res := &v1alpha.MSetResponse{}
+	for _, entry := range req.Entries {
+		s.store[entry.Key] = entry.Value
+		res.Results = append(res.Results, &v1alpha.MSetResult{Key: entry.Key, Success: true})
+	}
+	return res, nil
+}
+
+func (s *mockServer) MDelete(ctx context.Context, req *v1alpha.MDeleteRequest) (*v1alpha.MDeleteResponse, error) {
+	res := &v1alpha.MDeleteResponse{}
+	for _, key := range req.Keys {
+		_, ok := s.store[key]
+		delete(s.store, key)
+		res.Results = append(res.Results, &v1alpha.MDeleteResult{Key: key, Deleted: ok})
+	}
+	return res, nil
+}
+
+func (s *mockServer) Pipeline(stream v1alpha.CacheService_PipelineServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		res := &v1alpha.PipelineResponse{Tag: req.Tag}
+		switch {
+		case req.GetSet() != nil:
+			s.store[req.GetSet().Key] = req.GetSet().Value
+			res.Result = &v1alpha.PipelineResponse_Set{Set: &v1alpha.SetResponse{Success: true}}
+		case req.GetGet() != nil:
+			val, ok := s.store[req.GetGet().Key]
+			if !ok {
+				res.Code = int32(codes.NotFound)
+				res.Error = "not found"
+				break
+			}
+			res.Result = &v1alpha.PipelineResponse_Get{Get: &v1alpha.GetResponse{Found: true, Value: val}}
+		default:
+			res.Code = int32(codes.Unimplemented)
+		}
+		if err := stream.Send(res); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *mockServer) SetStream(stream v1alpha.CacheService_SetStreamServer) error {
+	var key string
+	var value []byte
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			s.store[key] = value
+			return stream.SendAndClose(&v1alpha.SetResponse{Success: true})
+		}
+		if err != nil {
+			return err
+		}
+		if h := req.GetHeader(); h != nil {
+			key = h.Key
+		}
+		value = append(value, req.GetChunk()...)
+	}
+}
+
+func (s *mockServer) GetStream(req *v1alpha.GetStreamRequest, stream v1alpha.CacheService_GetStreamServer) error {
+	val, ok := s.store[req.Key]
+	if !ok {
+		return status.Errorf(codes.NotFound, "key %q not found", req.Key)
+	}
+	for _, part := range []*v1alpha.GetStreamResponse{
+		{Part: &v1alpha.GetStreamResponse_Header{Header: &v1alpha.ValueHeader{Size: uint64(len(val))}}},
+		{Part: &v1alpha.GetStreamResponse_Chunk{Chunk: val}},
+		{Part: &v1alpha.GetStreamResponse_Checksum{Checksum: &v1alpha.ValueChecksum{Crc32C: crc32.Checksum(val, crc32cTable)}}},
+	} {
+		if err := stream.Send(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *mockServer) Stats(ctx context.Context, req *v1alpha.StatsRequest) (*v1alpha.StatsResponse, error) {
 	return &v1alpha.StatsResponse{KeyCount: uint64(len(s.store))}, nil
 }
@@ -111,6 +223,18 @@ func TestClient(t *testing.T) {
 	require.True(t, res.Found)
 	require.Equal(t, "bar", string(res.Value))
 
+	// Test GetIfNoneMatch
+	res, err = c.GetIfNoneMatch(ctx, "foo", res.Etag)
+	require.NoError(t, err)
+	require.True(t, res.NotModified)
+	require.Empty(t, res.Value)
+
+	// Test Touch
+	err = c.Touch(ctx, "foo")
+	require.NoError(t, err)
+	err = c.Touch(ctx, "missing")
+	require.Equal(t, codes.NotFound, status.Code(err))
+
 	// Test Delete
 	err = c.Delete(ctx, "foo")
 	require.NoError(t, err)
@@ -131,6 +255,43 @@ func TestClient(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, uint64(2), stats.KeyCount)
 
+	// Test MSet, MGet and MDelete
+	setResults, err := c.MSet(ctx, map[string]string{"x": "10", "y": "20"})
+	require.NoError(t, err)
+	require.Len(t, setResults, 2)
+
+	getResults, err := c.MGet(ctx, "x", "missing", "y")
+	require.NoError(t, err)
+	require.Len(t, getResults, 3)
+	require.Equal(t, "10", string(getResults[0].Value))
+	require.False(t, getResults[1].Found)
+	require.Equal(t, "20", string(getResults[2].Value))
+
+	delResults, err := c.MDelete(ctx, "x", "y")
+	require.NoError(t, err)
+	require.True(t, delResults[0].Deleted)
+	require.True(t, delResults[1].Deleted)
+
+	// Test Pipeline
+	var batch Batch
+	batch.Set("p", "1").Get("p").Get("missing")
+	pipelined, err := c.Pipeline(ctx, &batch)
+	require.NoError(t, err)
+	require.Len(t, pipelined, 3)
+	require.NoError(t, ResultError(pipelined[0]))
+	require.Equal(t, "1", string(pipelined[1].GetGet().Value))
+	require.Equal(t, codes.NotFound, status.Code(ResultError(pipelined[2])))
+
+	// Test SetFromReader and GetToWriter
+	blob := bytes.Repeat([]byte("x"), 3*StreamChunkSize+7)
+	err = c.SetFromReader(ctx, "blob", bytes.NewReader(blob), SetOptions{})
+	require.NoError(t, err)
+	var out bytes.Buffer
+	n, err := c.GetToWriter(ctx, "blob", &out)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(blob)), n)
+	require.Equal(t, blob, out.Bytes())
+
 	// Test Clear
 	err = c.Clear(ctx)
 	require.NoError(t, err)
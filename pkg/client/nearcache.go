@@ -0,0 +1,234 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	nearCacheMinBackoff = 100 * time.Millisecond
+	nearCacheMaxBackoff = 5 * time.Second
+)
+
+type nearEntry struct {
+	key string
+	res *cachev1alpha.GetResponse
+}
+
+// nearCache is a bounded LRU of Get responses kept in sync with the server
+// through a Track invalidation stream. Nothing is cached while the stream
+// is down, since invalidations could be missed. Values with an idle
+// timeout or TTL are not cached either: reads must reach the server to
+// keep them alive and to report them stale.
+type nearCache struct {
+	mu    sync.Mutex
+	max   int
+	lru   *list.List
+	items map[string]*list.Element
+
+	// trackingID is zero while the Track stream is not connected.
+	trackingID uint64
+	// generation changes whenever the cache is flushed, so that responses
+	// to requests started before the flush are not cached.
+	generation uint64
+	// inflight counts Gets per key; dirty marks keys invalidated while a
+	// Get was in flight, whose response may already be stale.
+	inflight map[string]int
+	dirty    map[string]bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newNearCache(size int) *nearCache {
+	return &nearCache{
+		max:      size,
+		lru:      list.New(),
+		items:    make(map[string]*list.Element),
+		inflight: make(map[string]int),
+		dirty:    make(map[string]bool),
+		done:     make(chan struct{}),
+	}
+}
+
+func (n *nearCache) start(client cachev1alpha.CacheServiceClient) {
+	ctx, cancel := context.WithCancel(context.Background())
+	n.cancel = cancel
+	go n.run(ctx, client)
+}
+
+func (n *nearCache) stop() {
+	n.cancel()
+	<-n.done
+}
+
+func (n *nearCache) get(key string) (*cachev1alpha.GetResponse, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	el, ok := n.items[key]
+	if !ok {
+		return nil, false
+	}
+	n.lru.MoveToFront(el)
+	return proto.Clone(el.Value.(*nearEntry).res).(*cachev1alpha.GetResponse), true
+}
+
+// begin marks a Get for key as in flight and returns the tracking id to
+// send with it together with the current generation.
+func (n *nearCache) begin(key string) (uint64, uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.inflight[key]++
+	return n.trackingID, n.generation
+}
+
+// finish ends a Get started with begin and caches res if no invalidation
+// for key arrived in the meantime.
+func (n *nearCache) finish(key string, generation uint64, res *cachev1alpha.GetResponse) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	cacheable := res != nil && res.Found && !res.Stale && !res.NotModified && !res.Expiring &&
+		n.trackingID != 0 && generation == n.generation && !n.dirty[key]
+
+	n.inflight[key]--
+	if n.inflight[key] <= 0 {
+		delete(n.inflight, key)
+		delete(n.dirty, key)
+	}
+	if !cacheable {
+		return
+	}
+
+	entry := &nearEntry{key: key, res: proto.Clone(res).(*cachev1alpha.GetResponse)}
+	if el, ok := n.items[key]; ok {
+		el.Value = entry
+		n.lru.MoveToFront(el)
+		return
+	}
+	n.items[key] = n.lru.PushFront(entry)
+	for n.lru.Len() > n.max {
+		oldest := n.lru.Back()
+		n.lru.Remove(oldest)
+		delete(n.items, oldest.Value.(*nearEntry).key)
+	}
+}
+
+func (n *nearCache) remove(keys ...string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := n.items[key]; ok {
+			n.lru.Remove(el)
+			delete(n.items, key)
+		}
+		if n.inflight[key] > 0 {
+			n.dirty[key] = true
+		}
+	}
+}
+
+// removeMatching removes every key for which match returns true.
+func (n *nearCache) removeMatching(match func(key string) bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for key, el := range n.items {
+		if match(key) {
+			n.lru.Remove(el)
+			delete(n.items, key)
+		}
+	}
+	for key := range n.inflight {
+		if match(key) {
+			n.dirty[key] = true
+		}
+	}
+}
+
+// reset drops every cached value and switches to trackingID.
+func (n *nearCache) reset(trackingID uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.lru.Init()
+	n.items = make(map[string]*list.Element)
+	n.trackingID = trackingID
+	n.generation++
+}
+
+func (n *nearCache) flush() {
+	n.mu.Lock()
+	id := n.trackingID
+	n.mu.Unlock()
+	n.reset(id)
+}
+
+// run keeps a Track stream open, reconnecting with backoff until ctx is
+// cancelled.
+func (n *nearCache) run(ctx context.Context, client cachev1alpha.CacheServiceClient) {
+	defer close(n.done)
+
+	backoff := nearCacheMinBackoff
+	for {
+		connected := n.track(ctx, client)
+		n.reset(0)
+		if connected {
+			backoff = nearCacheMinBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, nearCacheMaxBackoff)
+	}
+}
+
+// track consumes one Track stream and reports whether it was established.
+func (n *nearCache) track(ctx context.Context, client cachev1alpha.CacheServiceClient) bool {
+	stream, err := client.Track(ctx, &cachev1alpha.TrackRequest{})
+	if err != nil {
+		return false
+	}
+	hello, err := stream.Recv()
+	if err != nil {
+		return false
+	}
+	n.reset(hello.TrackingId)
+
+	for {
+		inv, err := stream.Recv()
+		if err != nil {
+			return true
+		}
+		if inv.Flush {
+			n.flush()
+		} else {
+			n.remove(inv.Keys...)
+		}
+	}
+}
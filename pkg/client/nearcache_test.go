@@ -0,0 +1,184 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/patrostkowski/protocache/internal/server"
+	"github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// trackingMockServer counts Gets and forwards invalidations pushed by the
+// test to the tracking client.
+type trackingMockServer struct {
+	*mockServer
+	gets          atomic.Int64
+	invalidations chan *v1alpha.Invalidation
+}
+
+func (s *trackingMockServer) Get(ctx context.Context, req *v1alpha.GetRequest) (*v1alpha.GetResponse, error) {
+	s.gets.Add(1)
+	return s.mockServer.Get(ctx, req)
+}
+
+func (s *trackingMockServer) Track(_ *v1alpha.TrackRequest, stream v1alpha.CacheService_TrackServer) error {
+	if err := stream.Send(&v1alpha.Invalidation{TrackingId: 7}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case inv := <-s.invalidations:
+			if err := stream.Send(inv); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func TestClient_NearCache(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	mock := &trackingMockServer{mockServer: newMockServer(), invalidations: make(chan *v1alpha.Invalidation)}
+	grpcServer := grpc.NewServer()
+	v1alpha.RegisterCacheServiceServer(grpcServer, mock)
+	go func() { _ = grpcServer.Serve(lis) }()
+	defer grpcServer.Stop()
+
+	c, err := New(Config{
+		Host:          "127.0.0.1",
+		Port:          parsePort(lis.Addr().String()),
+		Timeout:       2 * time.Second,
+		NearCacheSize: 2,
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx := context.Background()
+	mock.store["a"] = []byte("1")
+	mock.store["b"] = []byte("2")
+	mock.store["c"] = []byte("3")
+
+	// Values are only cached once the tracking stream is established.
+	require.Eventually(t, func() bool {
+		_, _ = c.Get(ctx, "a")
+		_, ok := c.near.get("a")
+		return ok
+	}, time.Second, 10*time.Millisecond)
+
+	gets := mock.gets.Load()
+	res, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, "1", string(res.Value))
+	require.Equal(t, gets, mock.gets.Load())
+
+	// A server invalidation drops the key.
+	mock.store["a"] = []byte("10")
+	mock.invalidations <- &v1alpha.Invalidation{Keys: []string{"a"}}
+	require.Eventually(t, func() bool {
+		_, ok := c.near.get("a")
+		return !ok
+	}, time.Second, time.Millisecond)
+	res, err = c.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, "10", string(res.Value))
+
+	// Writes through the client are visible immediately.
+	require.NoError(t, c.Set(ctx, "a", "11"))
+	res, err = c.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, "11", string(res.Value))
+
+	// The cache is bounded by NearCacheSize.
+	_, _ = c.Get(ctx, "b")
+	_, _ = c.Get(ctx, "c")
+	_, ok := c.near.get("a")
+	require.False(t, ok)
+}
+
+func TestNearCache_RemoveMatching(t *testing.T) {
+	n := newNearCache(10)
+	n.reset(7)
+	for _, key := range []string{"user:1", "user:2", "order:1"} {
+		_, generation := n.begin(key)
+		n.finish(key, generation, &v1alpha.GetResponse{Found: true, Value: []byte("v")})
+	}
+	_, generation := n.begin("user:3")
+
+	n.removeMatching(func(key string) bool { return strings.HasPrefix(key, "user:") })
+
+	_, ok := n.get("user:1")
+	require.False(t, ok)
+	_, ok = n.get("order:1")
+	require.True(t, ok)
+
+	// A Get in flight during the delete must not cache its response.
+	n.finish("user:3", generation, &v1alpha.GetResponse{Found: true, Value: []byte("v")})
+	_, ok = n.get("user:3")
+	require.False(t, ok)
+}
+
+func TestClient_NearCacheSkipsExpiringValues(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := server.NewTestServer(t)
+	grpcServer := grpc.NewServer()
+	v1alpha.RegisterCacheServiceServer(grpcServer, s)
+	go func() { _ = grpcServer.Serve(lis) }()
+	defer grpcServer.Stop()
+
+	c, err := New(Config{
+		Host:          "127.0.0.1",
+		Port:          parsePort(lis.Addr().String()),
+		Timeout:       2 * time.Second,
+		NearCacheSize: 10,
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "plain", "v"))
+	require.NoError(t, c.SetWithOptions(ctx, "hot", "v", SetOptions{SoftTTL: 50 * time.Millisecond, HardTTL: time.Minute}))
+
+	// Wait for the tracking stream, then read the soft-TTL key while fresh.
+	require.Eventually(t, func() bool {
+		_, _ = c.Get(ctx, "plain")
+		_, ok := c.near.get("plain")
+		return ok
+	}, time.Second, 10*time.Millisecond)
+	res, err := c.Get(ctx, "hot")
+	require.NoError(t, err)
+	require.False(t, res.Stale)
+	require.True(t, res.Expiring)
+	_, ok := c.near.get("hot")
+	require.False(t, ok)
+
+	time.Sleep(60 * time.Millisecond)
+	res, err = c.Get(ctx, "hot")
+	require.NoError(t, err)
+	require.True(t, res.Stale)
+	require.True(t, res.Refresh)
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Batch collects commands that are sent over a single Pipeline stream.
+// The zero value is an empty batch.
+type Batch struct {
+	cmds []*cachev1alpha.PipelineRequest
+}
+
+// Get queues a get command.
+func (b *Batch) Get(key string) *Batch {
+	req := b.next()
+	req.Command = &cachev1alpha.PipelineRequest_Get{Get: &cachev1alpha.GetRequest{Key: key}}
+	return b
+}
+
+// Set queues a set command.
+func (b *Batch) Set(key, value string) *Batch {
+	req := b.next()
+	req.Command = &cachev1alpha.PipelineRequest_Set{Set: &cachev1alpha.SetRequest{Key: key, Value: []byte(value)}}
+	return b
+}
+
+// Delete queues a delete command.
+func (b *Batch) Delete(key string) *Batch {
+	req := b.next()
+	req.Command = &cachev1alpha.PipelineRequest_Delete{Delete: &cachev1alpha.DeleteRequest{Key: key}}
+	return b
+}
+
+// Incr queues an increment of the integer stored at key by delta.
+func (b *Batch) Incr(key string, delta int64) *Batch {
+	req := b.next()
+	req.Command = &cachev1alpha.PipelineRequest_Incr{Incr: &cachev1alpha.IncrRequest{Key: key, Delta: delta}}
+	return b
+}
+
+// Len returns the number of queued commands.
+func (b *Batch) Len() int {
+	return len(b.cmds)
+}
+
+// writtenKeys returns the keys of the queued commands that change a key.
+func (b *Batch) writtenKeys() []string {
+	var keys []string
+	for _, cmd := range b.cmds {
+		switch c := cmd.Command.(type) {
+		case *cachev1alpha.PipelineRequest_Set:
+			keys = append(keys, c.Set.Key)
+		case *cachev1alpha.PipelineRequest_Delete:
+			keys = append(keys, c.Delete.Key)
+		case *cachev1alpha.PipelineRequest_Incr:
+			keys = append(keys, c.Incr.Key)
+		}
+	}
+	return keys
+}
+
+func (b *Batch) next() *cachev1alpha.PipelineRequest {
+	req := &cachev1alpha.PipelineRequest{Tag: uint64(len(b.cmds))}
+	b.cmds = append(b.cmds, req)
+	return req
+}
+
+// Pipeline sends every command of the batch over one stream and returns
+// the responses in the same order. Commands are written while responses
+// are read, so large batches are paced by gRPC flow control. Use
+// ResultError to check the outcome of a single command.
+func (c *Client) Pipeline(ctx context.Context, batch *Batch) ([]*cachev1alpha.PipelineResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer c.forget(batch.writtenKeys()...)
+
+	stream, err := c.client.Pipeline(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sendErr := make(chan error, 1)
+	go func() {
+		for _, cmd := range batch.cmds {
+			if err := stream.Send(cmd); err != nil {
+				sendErr <- err
+				return
+			}
+		}
+		sendErr <- stream.CloseSend()
+	}()
+
+	results := make([]*cachev1alpha.PipelineResponse, 0, len(batch.cmds))
+	for range batch.cmds {
+		res, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if res.Tag != uint64(len(results)) {
+			return nil, fmt.Errorf("pipeline response out of order: got tag %d, want %d", res.Tag, len(results))
+		}
+		results = append(results, res)
+	}
+
+	if err := <-sendErr; err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ResultError returns the error of a single pipelined command, or nil if
+// it succeeded.
+func ResultError(res *cachev1alpha.PipelineResponse) error {
+	if res.Code == int32(codes.OK) {
+		return nil
+	}
+	return status.Error(codes.Code(res.Code), res.Error)
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// StreamChunkSize is the chunk size used by SetFromReader.
+const StreamChunkSize = 256 << 10
+
+// ErrChecksumMismatch is returned by GetToWriter when the received value
+// does not match the checksum sent by the server.
+var ErrChecksumMismatch = errors.New("value checksum mismatch")
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// SetFromReader streams the contents of r into key in chunks, so values
+// larger than a single gRPC message can be stored.
+func (c *Client) SetFromReader(ctx context.Context, key string, r io.Reader, opts SetOptions) error {
+	defer c.forget(key)
+
+	stream, err := c.client.SetStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	header := &cachev1alpha.SetRequest{Key: key}
+	if opts.IdleTimeout > 0 {
+		header.IdleTimeout = durationpb.New(opts.IdleTimeout)
+	}
+	if opts.SoftTTL > 0 {
+		header.SoftTtl = durationpb.New(opts.SoftTTL)
+	}
+	if opts.HardTTL > 0 {
+		header.HardTtl = durationpb.New(opts.HardTTL)
+	}
+	if err := stream.Send(&cachev1alpha.SetStreamRequest{Part: &cachev1alpha.SetStreamRequest_Header{Header: header}}); err != nil {
+		return streamSendError(stream, err)
+	}
+
+	hash := crc32.New(crc32cTable)
+	buf := make([]byte, StreamChunkSize)
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			hash.Write(buf[:n])
+			chunk := append([]byte(nil), buf[:n]...)
+			if err := stream.Send(&cachev1alpha.SetStreamRequest{Part: &cachev1alpha.SetStreamRequest_Chunk{Chunk: chunk}}); err != nil {
+				return streamSendError(stream, err)
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			_ = stream.CloseSend()
+			return fmt.Errorf("read value: %w", readErr)
+		}
+	}
+
+	err = stream.Send(&cachev1alpha.SetStreamRequest{Part: &cachev1alpha.SetStreamRequest_Checksum{
+		Checksum: &cachev1alpha.ValueChecksum{Crc32C: hash.Sum32()},
+	}})
+	if err != nil {
+		return streamSendError(stream, err)
+	}
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+// streamSendError returns the status that ended the stream when a send
+// fails with io.EOF, which only signals that the server has stopped.
+func streamSendError(stream cachev1alpha.CacheService_SetStreamClient, err error) error {
+	if errors.Is(err, io.EOF) {
+		_, err = stream.CloseAndRecv()
+	}
+	return err
+}
+
+// GetToWriter streams the value of key into w and returns the number of
+// bytes written. The checksum is verified once the whole value has been
+// written, so w may already hold data when ErrChecksumMismatch is returned.
+func (c *Client) GetToWriter(ctx context.Context, key string, w io.Writer) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.client.GetStream(ctx, &cachev1alpha.GetStreamRequest{Key: key})
+	if err != nil {
+		return 0, err
+	}
+
+	hash := crc32.New(crc32cTable)
+	var written int64
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return written, fmt.Errorf("stream for %q ended without a checksum", key)
+		}
+		if err != nil {
+			return written, err
+		}
+
+		switch part := msg.Part.(type) {
+		case *cachev1alpha.GetStreamResponse_Chunk:
+			hash.Write(part.Chunk)
+			n, err := w.Write(part.Chunk)
+			written += int64(n)
+			if err != nil {
+				return written, err
+			}
+		case *cachev1alpha.GetStreamResponse_Checksum:
+			if part.Checksum.Crc32C != hash.Sum32() {
+				return written, ErrChecksumMismatch
+			}
+			return written, nil
+		}
+	}
+}
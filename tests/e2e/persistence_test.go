@@ -0,0 +1,121 @@
+// Copyright 2025 Patryk Rostkowski
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/patrostkowski/protocache/internal/config"
+	"github.com/patrostkowski/protocache/internal/server"
+	cachev1alpha "github.com/patrostkowski/protocache/pkg/api/cache/v1alpha"
+)
+
+func persistenceConfig(dir string, engine cachev1alpha.StoreEngine) *config.Config {
+	return &config.Config{
+		ServerConfig: &cachev1alpha.ServerConfig{
+			GracefulTimeout: 5 * time.Second,
+		},
+		HTTPServer: &cachev1alpha.HTTPServerConfig{
+			Address: "127.0.0.1",
+		},
+		GRPCListener: &cachev1alpha.GRPCServerListenerConfig{
+			GRPCServerUnixListener: &cachev1alpha.GRPCServerUnixListener{
+				SocketPath: filepath.Join(dir, "protocache.sock"),
+			},
+		},
+		StoreConfig: &cachev1alpha.StoreConfig{
+			Engine:             engine,
+			DumpEnabled:        true,
+			MemoryDumpPath:     dir,
+			MemoryDumpFileName: "protocache.gob.gz",
+		},
+		TLSConfig: &cachev1alpha.TLSConfig{},
+	}
+}
+
+// runServer starts a full server and returns a client for it and a
+// function that shuts it down, which writes the final snapshot.
+func runServer(t *testing.T, cfg *config.Config) (cachev1alpha.CacheServiceClient, func()) {
+	t.Helper()
+
+	srv := server.NewServer(cfg, server.DefaultPrometheusRegistry())
+	require.NoError(t, srv.Init())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Start(ctx)
+	}()
+
+	conn, err := grpc.NewClient("unix://"+cfg.GRPCListener.SocketPath,
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	stop := func() {
+		conn.Close()
+		cancel()
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("server did not shut down")
+		}
+	}
+	return cachev1alpha.NewCacheServiceClient(conn), stop
+}
+
+func TestPersistence_E2E(t *testing.T) {
+	for _, engine := range []cachev1alpha.StoreEngine{
+		cachev1alpha.MapStoreEngine,
+		cachev1alpha.SyncMapStoreEngine,
+	} {
+		t.Run(string(engine), func(t *testing.T) {
+			cfg := persistenceConfig(t.TempDir(), engine)
+			ctx := context.Background()
+
+			client, stop := runServer(t, cfg)
+			for i := 0; i < 100; i++ {
+				_, err := client.Set(ctx, &cachev1alpha.SetRequest{
+					Key:   fmt.Sprintf("key-%d", i),
+					Value: []byte(fmt.Sprintf("value-%d", i)),
+				}, grpc.WaitForReady(true))
+				require.NoError(t, err)
+			}
+			stop()
+
+			client, stop = runServer(t, cfg)
+			defer stop()
+
+			list, err := client.List(ctx, &cachev1alpha.ListRequest{}, grpc.WaitForReady(true))
+			require.NoError(t, err)
+			assert.Len(t, list.Keys, 100)
+
+			for i := 0; i < 100; i++ {
+				resp, err := client.Get(ctx, &cachev1alpha.GetRequest{Key: fmt.Sprintf("key-%d", i)})
+				require.NoError(t, err)
+				assert.Equal(t, []byte(fmt.Sprintf("value-%d", i)), resp.Value)
+			}
+		})
+	}
+}